@@ -0,0 +1,209 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/servercfg"
+)
+
+// ARCHIVE_PASSPHRASE_ENV - the environment variable the export and import commands read the passphrase of an archive from
+const ARCHIVE_PASSPHRASE_ENV = "ARCHIVE_PASSPHRASE"
+
+// runCommand - runs a command given on the command line instead of the server, returns the exit code
+func runCommand(command string, args []string) int {
+	switch command {
+	case "export":
+		return exportCommand(args)
+	case "import":
+		return importCommand(args)
+	case "restore":
+		return restoreCommand(args)
+	case "migrate":
+		return migrateCommand(args)
+	default:
+		fmt.Fprintln(os.Stderr, "unknown command "+command+", the commands are export, import, restore and migrate, run without a command to start the server")
+		return 2
+	}
+}
+
+// exportCommand - writes an archive of the database to a file
+func exportCommand(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	file := flags.String("file", "netmaker.archive", "the file the archive is written to")
+	networks := flags.String("networks", "", "comma separated networks to export, all tables are exported when empty")
+	passphrase := flags.String("passphrase", os.Getenv(ARCHIVE_PASSPHRASE_ENV), "encrypts the archive, defaults to "+ARCHIVE_PASSPHRASE_ENV)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if err := database.InitializeDatabase(); err != nil {
+		fmt.Fprintln(os.Stderr, "could not connect to the database: "+err.Error())
+		return 1
+	}
+	defer database.CloseDB()
+
+	var selected []string
+	if *networks != "" {
+		selected = strings.Split(*networks, ",")
+	}
+	archive, err := logic.ExportState(selected)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not export: "+err.Error())
+		return 1
+	}
+	data, err := logic.EncodeArchive(&archive, *passphrase)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not write the archive: "+err.Error())
+		return 1
+	}
+	// the archive holds the private keys of the server and its nodes
+	if err = ioutil.WriteFile(*file, data, 0600); err != nil {
+		fmt.Fprintln(os.Stderr, "could not write the archive: "+err.Error())
+		return 1
+	}
+	for _, table := range sortedTables(archive.Tables) {
+		fmt.Printf("%s: %d records\n", table, len(archive.Tables[table]))
+	}
+	fmt.Println("exported to " + *file)
+	return 0
+}
+
+// importCommand - restores an archive into the configured database
+func importCommand(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := flags.String("file", "netmaker.archive", "the file the archive is read from")
+	replace := flags.Bool("replace", false, "removes the tables, or the networks of a network archive, before restoring")
+	passphrase := flags.String("passphrase", os.Getenv(ARCHIVE_PASSPHRASE_ENV), "decrypts the archive, defaults to "+ARCHIVE_PASSPHRASE_ENV)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	data, err := ioutil.ReadFile(*file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not read the archive: "+err.Error())
+		return 1
+	}
+	archive, err := logic.DecodeArchive(data, *passphrase)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not read the archive: "+err.Error())
+		return 1
+	}
+	if err = database.InitializeDatabase(); err != nil {
+		fmt.Fprintln(os.Stderr, "could not connect to the database: "+err.Error())
+		return 1
+	}
+	defer database.CloseDB()
+
+	summary, err := logic.ImportState(&archive, *replace)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not import: "+err.Error())
+		return 1
+	}
+	for _, table := range sortedTables(archive.Tables) {
+		if count, ok := summary.Records[table]; ok {
+			fmt.Printf("%s: %d records\n", table, count)
+		}
+	}
+	for _, skipped := range summary.Skipped {
+		fmt.Println("skipped " + skipped)
+	}
+	fmt.Println("imported " + *file + " from a " + archive.Database + " database of server " + archive.ServerVersion + " into " + servercfg.GetDB())
+	return 0
+}
+
+// restoreCommand - replaces the database with one of the backups taken by the server
+func restoreCommand(args []string) int {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	backup := flags.String("backup", "", "the name of a backup in the backup directory, or the path of a backup")
+	list := flags.Bool("list", false, "lists the backups in the backup directory")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *list {
+		backups, err := logic.GetBackups()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "could not list the backups: "+err.Error())
+			return 1
+		}
+		for _, backup := range backups {
+			fmt.Printf("%s\t%s\t%d bytes\t%s\n", backup.Name, backup.Type, backup.Size, time.Unix(backup.CreatedAt, 0).Format(time.RFC3339))
+		}
+		return 0
+	}
+	if *backup == "" {
+		fmt.Fprintln(os.Stderr, "a backup is needed, see -list for the backups")
+		return 2
+	}
+	path, err := logic.GetBackupPath(*backup)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if err = database.InitializeDatabase(); err != nil {
+		fmt.Fprintln(os.Stderr, "could not connect to the database: "+err.Error())
+		return 1
+	}
+	defer database.CloseDB()
+	if err = logic.RestoreBackup(path); err != nil {
+		fmt.Fprintln(os.Stderr, "could not restore "+path+": "+err.Error())
+		return 1
+	}
+	fmt.Println("restored " + path + " into " + servercfg.GetDB())
+	return 0
+}
+
+// migrateCommand - copies an existing sqlite database into the configured database, e.g. to move a server to bbolt
+func migrateCommand(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	path := flags.String("sqlite", filepath.Join("data", database.DATABASE_FILENAME), "the sqlite database file to migrate")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if servercfg.GetDB() == "sqlite" {
+		fmt.Fprintln(os.Stderr, "the configured database is sqlite, set DATABASE to the database to migrate to")
+		return 2
+	}
+	// opening a missing file would create an empty database
+	if _, err := os.Stat(*path); err != nil {
+		fmt.Fprintln(os.Stderr, "could not find the sqlite database: "+err.Error())
+		return 1
+	}
+	source := database.NewSqliteStore(*path)
+	if err := source.Init(); err != nil {
+		fmt.Fprintln(os.Stderr, "could not open the sqlite database: "+err.Error())
+		return 1
+	}
+	defer source.Close()
+	if err := database.InitializeDatabase(); err != nil {
+		fmt.Fprintln(os.Stderr, "could not connect to the database: "+err.Error())
+		return 1
+	}
+	defer database.CloseDB()
+
+	copied, err := database.CopyStore(source, database.GetStore())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not migrate: "+err.Error())
+		return 1
+	}
+	for _, table := range database.Tables() {
+		fmt.Printf("%s: %d records\n", table, copied[table])
+	}
+	fmt.Println("migrated " + *path + " into " + servercfg.GetDB())
+	return 0
+}
+
+func sortedTables(tables map[string]map[string]string) []string {
+	var names []string
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
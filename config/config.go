@@ -47,10 +47,14 @@ type ServerConfig struct {
 	DNSKey                string `yaml:"dnskey"`
 	AllowedOrigin         string `yaml:"allowedorigin"`
 	NodeID                string `yaml:"nodeid"`
+	ServerID              string `yaml:"serverid"`
 	RestBackend           string `yaml:"restbackend"`
 	AgentBackend          string `yaml:"agentbackend"`
 	ClientMode            string `yaml:"clientmode"`
 	DNSMode               string `yaml:"dnsmode"`
+	EmbeddedDNS           string `yaml:"embeddeddns"`
+	DNSListenAddr         string `yaml:"dnslistenaddr"`
+	DNSUpstreams          string `yaml:"dnsupstreams"`
 	SplitDNS              string `yaml:"splitdns"`
 	DisableRemoteIPCheck  string `yaml:"disableremoteipcheck"`
 	DisableDefaultNet     string `yaml:"disabledefaultnet"`
@@ -63,6 +67,14 @@ type ServerConfig struct {
 	DefaultNodeLimit      int32  `yaml:"defaultnodelimit"`
 	Verbosity             int32  `yaml:"verbosity"`
 	ServerCheckinInterval int64  `yaml:"servercheckininterval"`
+	KeyRotationInterval   int64  `yaml:"keyrotationinterval"`
+	BackupMode            string `yaml:"backupmode"`
+	BackupDir             string `yaml:"backupdir"`
+	BackupInterval        int64  `yaml:"backupinterval"`
+	BackupRetention       int32  `yaml:"backupretention"`
+	CacheMode             string `yaml:"cachemode"`
+	CacheSize             int32  `yaml:"cachesize"`
+	CacheTTL              int64  `yaml:"cachettl"`
 	AuthProvider          string `yaml:"authprovider"`
 	ClientID              string `yaml:"clientid"`
 	ClientSecret          string `yaml:"clientsecret"`
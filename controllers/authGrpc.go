@@ -69,7 +69,7 @@ func grpcAuthorize(ctx context.Context) error {
 
 	authToken := authHeader[0]
 
-	mac, network, err := logic.VerifyToken(authToken)
+	nodeid, network, err := logic.VerifyToken(authToken)
 	if err != nil {
 		return err
 	}
@@ -80,9 +80,9 @@ func grpcAuthorize(ctx context.Context) error {
 		return status.Errorf(codes.Unauthenticated, "Unauthorized. Network does not exist: "+network)
 	}
 	emptynode := models.Node{}
-	node, err := logic.GetNodeByMacAddress(network, mac)
+	node, err := logic.GetNodeByID(network, nodeid)
 	if database.IsEmptyRecord(err) {
-		if node, err = logic.GetDeletedNodeByMacAddress(network, mac); err == nil {
+		if node, err = logic.GetDeletedNodeByID(network, nodeid); err == nil {
 			if functions.RemoveDeletedNode(node.ID) {
 				return status.Errorf(codes.Unauthenticated, models.NODE_DELETE)
 			}
@@ -90,7 +90,7 @@ func grpcAuthorize(ctx context.Context) error {
 		}
 		return status.Errorf(codes.Unauthenticated, "Empty record")
 	}
-	if err != nil || node.MacAddress == emptynode.MacAddress {
+	if err != nil || node.ID == emptynode.ID {
 		return status.Errorf(codes.Unauthenticated, "Node does not exist.")
 	}
 
@@ -109,7 +109,7 @@ func (s *NodeServiceServer) Login(ctx context.Context, req *nodepb.Object) (*nod
 		return nil, err
 	}
 
-	macaddress := reqNode.MacAddress
+	nodeid := reqNode.GetNodeID()
 	network := reqNode.Network
 	password := reqNode.Password
 
@@ -117,27 +117,35 @@ func (s *NodeServiceServer) Login(ctx context.Context, req *nodepb.Object) (*nod
 
 	err := errors.New("Generic server error.")
 
-	if macaddress == "" {
+	if nodeid == "" {
 		//TODO: Set Error  response
-		err = errors.New("Missing Mac Address.")
+		err = errors.New("Missing Node ID.")
 		return nil, err
 	} else if password == "" {
 		err = errors.New("Missing Password.")
 		return nil, err
 	} else {
-		//Search DB for node with Mac Address. Ignore pending nodes (they should not be able to authenticate with API until approved).
+		//Search DB for node with the ID, clients joined before node uuids only know their Mac Address. Ignore pending nodes (they should not be able to authenticate with API until approved).
 		collection, err := database.FetchRecords(database.NODES_TABLE_NAME)
 		if err != nil {
 			return nil, err
 		}
+		found := false
 		for _, value := range collection {
 			if err = json.Unmarshal([]byte(value), &result); err != nil {
 				continue // finish going through nodes
 			}
-			if result.MacAddress == macaddress && result.Network == network {
+			if result.Network != network {
+				continue
+			}
+			if (reqNode.UUID != "" && result.UUID == reqNode.UUID) || (reqNode.UUID == "" && result.MacAddress == nodeid) {
+				found = true
 				break
 			}
 		}
+		if !found {
+			return nil, errors.New("node " + nodeid + " not found in network " + network)
+		}
 
 		//compare password from request to stored password in database
 		//might be able to have a common hash (certificates?) and compare those so that a password isn't passed in in plain text...
@@ -147,7 +155,7 @@ func (s *NodeServiceServer) Login(ctx context.Context, req *nodepb.Object) (*nod
 			return nil, err
 		} else {
 			//Create a new JWT for the node
-			tokenString, err := logic.CreateJWT(macaddress, result.Network)
+			tokenString, err := logic.CreateJWT(result.UUID, result.Network)
 
 			if err != nil {
 				return nil, err
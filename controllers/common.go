@@ -56,27 +56,12 @@ func DeleteIntClient(clientid string) (bool, error) {
 	return true, nil
 }
 
-func GetNode(macaddress string, network string) (models.Node, error) {
+func GetNode(nodeid string, network string) (models.Node, error) {
 
-	var node models.Node
-
-	key, err := logic.GetRecordKey(macaddress, network)
-	if err != nil {
-		return node, err
+	node, err := logic.GetNodeByID(network, nodeid)
+	if database.IsEmptyRecord(err) {
+		return logic.GetDeletedNodeByID(network, nodeid)
 	}
-	data, err := database.FetchRecord(database.NODES_TABLE_NAME, key)
-	if err != nil {
-		if data == "" {
-			data, err = database.FetchRecord(database.DELETED_NODES_TABLE_NAME, key)
-			err = json.Unmarshal([]byte(data), &node)
-		}
-		return node, err
-	}
-	if err = json.Unmarshal([]byte(data), &node); err != nil {
-		return node, err
-	}
-	logic.SetNodeDefaults(&node)
-
 	return node, err
 }
 
@@ -46,11 +46,11 @@ func TestDeleteNode(t *testing.T) {
 	createNet()
 	node := createTestNode()
 	t.Run("NodeExists", func(t *testing.T) {
-		err := DeleteNode(node.MacAddress, true)
+		err := DeleteNode(node.ID, true)
 		assert.Nil(t, err)
 	})
 	t.Run("NonExistantNode", func(t *testing.T) {
-		err := DeleteNode(node.MacAddress, true)
+		err := DeleteNode(node.ID, true)
 		assert.Nil(t, err)
 	})
 }
@@ -61,7 +61,7 @@ func TestGetNode(t *testing.T) {
 	t.Run("NoNode", func(t *testing.T) {
 		response, err := GetNode("01:02:03:04:05:06", "skynet")
 		assert.Equal(t, models.Node{}, response)
-		assert.EqualError(t, err, "unexpected end of JSON input")
+		assert.True(t, database.IsEmptyRecord(err))
 	})
 	createNet()
 	node := createTestNode()
@@ -80,12 +80,12 @@ func TestGetNode(t *testing.T) {
 	t.Run("BadMac", func(t *testing.T) {
 		response, err := GetNode("01:02:03:04:05:07", node.Network)
 		assert.Equal(t, models.Node{}, response)
-		assert.EqualError(t, err, "unexpected end of JSON input")
+		assert.True(t, database.IsEmptyRecord(err))
 	})
 	t.Run("BadNetwork", func(t *testing.T) {
 		response, err := GetNode(node.MacAddress, "badnet")
 		assert.Equal(t, models.Node{}, response)
-		assert.EqualError(t, err, "unexpected end of JSON input")
+		assert.True(t, database.IsEmptyRecord(err))
 	})
 
 }
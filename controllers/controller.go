@@ -33,6 +33,7 @@ func HandleRESTRequests(wg *sync.WaitGroup) {
 	fileHandlers(r)
 	serverHandlers(r)
 	extClientHandlers(r)
+	templateHandlers(r)
 
 	port := servercfg.GetAPIPort()
 
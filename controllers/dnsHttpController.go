@@ -2,6 +2,7 @@ package controller
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 	"strings"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/gravitl/netmaker/functions"
 	"github.com/gravitl/netmaker/logic"
 	"github.com/gravitl/netmaker/models"
+	"github.com/miekg/dns"
 )
 
 func dnsHandlers(r *mux.Router) {
@@ -76,26 +78,7 @@ func GetAllDNS() ([]models.DNSEntry, error) {
 
 // GetNodeDNS - gets node dns
 func GetNodeDNS(network string) ([]models.DNSEntry, error) {
-
-	var dns []models.DNSEntry
-
-	collection, err := database.FetchRecords(database.NODES_TABLE_NAME)
-	if err != nil {
-		return dns, err
-	}
-
-	for _, value := range collection {
-		var entry models.DNSEntry
-		var node models.Node
-		if err = json.Unmarshal([]byte(value), &node); err != nil {
-			continue
-		}
-		if err = json.Unmarshal([]byte(value), &entry); node.Network == network && err == nil {
-			dns = append(dns, entry)
-		}
-	}
-
-	return dns, nil
+	return logic.GetNodeDNS(network)
 }
 
 //Gets all nodes associated with network, including pending nodes
@@ -163,6 +146,7 @@ func createDNS(w http.ResponseWriter, r *http.Request) {
 	//get node from body of request
 	_ = json.NewDecoder(r.Body).Decode(&entry)
 	entry.Network = params["network"]
+	entry.Type = strings.ToUpper(entry.Type)
 
 	err := ValidateDNSCreate(entry)
 	if err != nil {
@@ -207,14 +191,35 @@ func updateDNS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// fill in any missing fields
+	dnschange.Type = strings.ToUpper(dnschange.Type)
 	if dnschange.Name == "" {
 		dnschange.Name = entry.Name
 	}
 	if dnschange.Network == "" {
 		dnschange.Network = entry.Network
 	}
-	if dnschange.Address == "" {
-		dnschange.Address = entry.Address
+	if dnschange.TTL == 0 {
+		dnschange.TTL = entry.TTL
+	}
+	// record data is only kept when the record type does not change
+	if dnschange.Type == "" || dnschange.Type == entry.RecordType() {
+		if dnschange.Type == "" {
+			dnschange.Type = entry.Type
+		}
+		if dnschange.Address == "" {
+			dnschange.Address = entry.Address
+		}
+		if dnschange.Target == "" {
+			dnschange.Target = entry.Target
+		}
+		if dnschange.Text == "" {
+			dnschange.Text = entry.Text
+		}
+		if dnschange.Priority == 0 && dnschange.Weight == 0 && dnschange.Port == 0 {
+			dnschange.Priority = entry.Priority
+			dnschange.Weight = entry.Weight
+			dnschange.Port = entry.Port
+		}
 	}
 
 	err = ValidateDNSUpdate(dnschange, entry)
@@ -302,9 +307,27 @@ func UpdateDNS(dnschange models.DNSEntry, entry models.DNSEntry) (models.DNSEntr
 	if dnschange.Name != "" {
 		entry.Name = dnschange.Name
 	}
+	if dnschange.Type != "" && dnschange.Type != entry.RecordType() {
+		// a new record type replaces the record data
+		entry = models.DNSEntry{Name: entry.Name, Network: entry.Network, Type: dnschange.Type, TTL: entry.TTL}
+	}
 	if dnschange.Address != "" {
 		entry.Address = dnschange.Address
 	}
+	if dnschange.Target != "" {
+		entry.Target = dnschange.Target
+	}
+	if dnschange.Text != "" {
+		entry.Text = dnschange.Text
+	}
+	if dnschange.TTL != 0 {
+		entry.TTL = dnschange.TTL
+	}
+	if entry.RecordType() == models.DNS_RECORD_SRV {
+		entry.Priority = dnschange.Priority
+		entry.Weight = dnschange.Weight
+		entry.Port = dnschange.Port
+	}
 	newkey, err := logic.GetRecordKey(entry.Name, entry.Network)
 
 	err = database.DeleteRecord(database.DNS_TABLE_NAME, key)
@@ -355,6 +378,7 @@ func ValidateDNSCreate(entry models.DNSEntry) error {
 		_, err := logic.GetParentNetwork(entry.Network)
 		return err == nil
 	})
+	v.RegisterStructValidation(validateDNSRecord, models.DNSEntry{})
 
 	err := v.Struct(entry)
 	if err != nil {
@@ -385,6 +409,7 @@ func ValidateDNSUpdate(change models.DNSEntry, entry models.DNSEntry) error {
 		}
 		return err == nil
 	})
+	v.RegisterStructValidation(validateDNSRecord, models.DNSEntry{})
 
 	//	_ = v.RegisterValidation("name_valid", func(fl validator.FieldLevel) bool {
 	//		isvalid := functions.NameInDNSCharSet(entry.Name)
@@ -410,6 +435,45 @@ func ValidateDNSUpdate(change models.DNSEntry, entry models.DNSEntry) error {
 	return err
 }
 
+// validateDNSRecord - checks the record data matches the record type of an entry
+func validateDNSRecord(sl validator.StructLevel) {
+	entry := sl.Current().Interface().(models.DNSEntry)
+	ip := net.ParseIP(entry.Address)
+	switch entry.RecordType() {
+	case models.DNS_RECORD_A:
+		if entry.Address == "" {
+			sl.ReportError(entry.Address, "Address", "Address", "required", "")
+		} else if ip != nil && ip.To4() == nil {
+			sl.ReportError(entry.Address, "Address", "Address", "ipv4", "")
+		}
+	case models.DNS_RECORD_AAAA:
+		if entry.Address == "" {
+			sl.ReportError(entry.Address, "Address", "Address", "required", "")
+		} else if ip != nil && ip.To4() != nil {
+			sl.ReportError(entry.Address, "Address", "Address", "ipv6", "")
+		}
+	case models.DNS_RECORD_CNAME, models.DNS_RECORD_SRV:
+		if entry.Address != "" {
+			sl.ReportError(entry.Address, "Address", "Address", "excluded", "")
+		}
+		if entry.Target == "" {
+			sl.ReportError(entry.Target, "Target", "Target", "required", "")
+		} else if _, ok := dns.IsDomainName(entry.Target); !ok {
+			sl.ReportError(entry.Target, "Target", "Target", "hostname", "")
+		}
+		if entry.RecordType() == models.DNS_RECORD_SRV && entry.Port == 0 {
+			sl.ReportError(entry.Port, "Port", "Port", "required", "")
+		}
+	case models.DNS_RECORD_TXT:
+		if entry.Address != "" {
+			sl.ReportError(entry.Address, "Address", "Address", "excluded", "")
+		}
+		if entry.Text == "" {
+			sl.ReportError(entry.Text, "Text", "Text", "required", "")
+		}
+	}
+}
+
 //Security check DNS is middleware for every DNS function and just checks to make sure that its the master or dns token calling
 //Only admin should have access to all these network-level actions
 //DNS token should have access to only read functions
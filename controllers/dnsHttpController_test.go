@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gravitl/netmaker/database"
 	"github.com/gravitl/netmaker/logic"
 	"github.com/gravitl/netmaker/models"
@@ -22,14 +23,14 @@ func TestGetAllDNS(t *testing.T) {
 		assert.Equal(t, []models.DNSEntry(nil), entries)
 	})
 	t.Run("OneEntry", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.0.3", "newhost", "skynet"}
+		entry := models.DNSEntry{Address: "10.0.0.3", Name: "newhost", Network: "skynet"}
 		CreateDNS(entry)
 		entries, err := GetAllDNS()
 		assert.Nil(t, err)
 		assert.Equal(t, 1, len(entries))
 	})
 	t.Run("MultipleEntry", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.0.7", "anotherhost", "skynet"}
+		entry := models.DNSEntry{Address: "10.0.0.7", Name: "anotherhost", Network: "skynet"}
 		CreateDNS(entry)
 		entries, err := GetAllDNS()
 		assert.Nil(t, err)
@@ -84,14 +85,14 @@ func TestGetCustomDNS(t *testing.T) {
 		assert.Equal(t, 0, len(dns))
 	})
 	t.Run("EntryExist", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.0.3", "newhost", "skynet"}
+		entry := models.DNSEntry{Address: "10.0.0.3", Name: "newhost", Network: "skynet"}
 		CreateDNS(entry)
 		dns, err := logic.GetCustomDNS("skynet")
 		assert.Nil(t, err)
 		assert.Equal(t, 1, len(dns))
 	})
 	t.Run("MultipleEntries", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.0.4", "host4", "skynet"}
+		entry := models.DNSEntry{Address: "10.0.0.4", Name: "host4", Network: "skynet"}
 		CreateDNS(entry)
 		dns, err := logic.GetCustomDNS("skynet")
 		assert.Nil(t, err)
@@ -110,7 +111,7 @@ func TestGetDNSEntryNum(t *testing.T) {
 		assert.Equal(t, 0, num)
 	})
 	t.Run("NodeExists", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.0.2", "newhost", "skynet"}
+		entry := models.DNSEntry{Address: "10.0.0.2", Name: "newhost", Network: "skynet"}
 		_, err := CreateDNS(entry)
 		assert.Nil(t, err)
 		num, err := GetDNSEntryNum("newhost", "skynet")
@@ -129,7 +130,7 @@ func TestGetDNS(t *testing.T) {
 		assert.Nil(t, dns)
 	})
 	t.Run("CustomDNSExists", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.0.2", "newhost", "skynet"}
+		entry := models.DNSEntry{Address: "10.0.0.2", Name: "newhost", Network: "skynet"}
 		_, err := CreateDNS(entry)
 		assert.Nil(t, err)
 		dns, err := logic.GetDNS("skynet")
@@ -149,7 +150,7 @@ func TestGetDNS(t *testing.T) {
 		assert.Equal(t, 1, len(dns))
 	})
 	t.Run("NodeAndCustomDNS", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.0.2", "newhost", "skynet"}
+		entry := models.DNSEntry{Address: "10.0.0.2", Name: "newhost", Network: "skynet"}
 		_, err := CreateDNS(entry)
 		dns, err := logic.GetDNS("skynet")
 		t.Log(dns)
@@ -166,7 +167,7 @@ func TestCreateDNS(t *testing.T) {
 	deleteAllDNS(t)
 	deleteAllNetworks()
 	createNet()
-	entry := models.DNSEntry{"10.0.0.2", "newhost", "skynet"}
+	entry := models.DNSEntry{Address: "10.0.0.2", Name: "newhost", Network: "skynet"}
 	dns, err := CreateDNS(entry)
 	assert.Nil(t, err)
 	assert.Equal(t, "newhost", dns.Name)
@@ -205,7 +206,7 @@ func TestSetDNS(t *testing.T) {
 		assert.Contains(t, string(content), "testnode.skynet")
 	})
 	t.Run("EntryExists", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.0.3", "newhost", "skynet"}
+		entry := models.DNSEntry{Address: "10.0.0.3", Name: "newhost", Network: "skynet"}
 		CreateDNS(entry)
 		err := logic.SetDNS()
 		assert.Nil(t, err)
@@ -225,7 +226,7 @@ func TestGetDNSEntry(t *testing.T) {
 	deleteAllNetworks()
 	createNet()
 	createTestNode()
-	entry := models.DNSEntry{"10.0.0.2", "newhost", "skynet"}
+	entry := models.DNSEntry{Address: "10.0.0.2", Name: "newhost", Network: "skynet"}
 	CreateDNS(entry)
 	t.Run("wrong net", func(t *testing.T) {
 		entry, err := GetDNSEntry("newhost", "w286 Toronto Street South, Uxbridge, ONirecat")
@@ -254,7 +255,7 @@ func TestUpdateDNS(t *testing.T) {
 	deleteAllDNS(t)
 	deleteAllNetworks()
 	createNet()
-	entry := models.DNSEntry{"10.0.0.2", "newhost", "skynet"}
+	entry := models.DNSEntry{Address: "10.0.0.2", Name: "newhost", Network: "skynet"}
 	CreateDNS(entry)
 	t.Run("change address", func(t *testing.T) {
 		newentry.Address = "10.0.0.75"
@@ -268,6 +269,14 @@ func TestUpdateDNS(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, newentry.Name, updated.Name)
 	})
+	t.Run("change type", func(t *testing.T) {
+		change := models.DNSEntry{Type: models.DNS_RECORD_CNAME, Target: "othernode.skynet"}
+		updated, err := UpdateDNS(change, entry)
+		assert.Nil(t, err)
+		assert.Equal(t, models.DNS_RECORD_CNAME, updated.Type)
+		assert.Equal(t, "othernode.skynet", updated.Target)
+		assert.Equal(t, "", updated.Address)
+	})
 	t.Run("change network", func(t *testing.T) {
 		newentry.Network = "wirecat"
 		updated, err := UpdateDNS(newentry, entry)
@@ -280,7 +289,7 @@ func TestDeleteDNS(t *testing.T) {
 	deleteAllDNS(t)
 	deleteAllNetworks()
 	createNet()
-	entry := models.DNSEntry{"10.0.0.2", "newhost", "skynet"}
+	entry := models.DNSEntry{Address: "10.0.0.2", Name: "newhost", Network: "skynet"}
 	CreateDNS(entry)
 	t.Run("EntryExists", func(t *testing.T) {
 		err := DeleteDNS("newhost", "skynet")
@@ -302,36 +311,36 @@ func TestValidateDNSUpdate(t *testing.T) {
 	deleteAllDNS(t)
 	deleteAllNetworks()
 	createNet()
-	entry := models.DNSEntry{"10.0.0.2", "myhost", "skynet"}
+	entry := models.DNSEntry{Address: "10.0.0.2", Name: "myhost", Network: "skynet"}
 	t.Run("BadNetwork", func(t *testing.T) {
-		change := models.DNSEntry{"10.0.0.2", "myhost", "badnet"}
+		change := models.DNSEntry{Address: "10.0.0.2", Name: "myhost", Network: "badnet"}
 		err := ValidateDNSUpdate(change, entry)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Field validation for 'Network' failed on the 'network_exists' tag")
 	})
 	t.Run("EmptyNetwork", func(t *testing.T) {
 		//this can't actually happen as change.Network is populated if is blank
-		change := models.DNSEntry{"10.0.0.2", "myhost", ""}
+		change := models.DNSEntry{Address: "10.0.0.2", Name: "myhost", Network: ""}
 		err := ValidateDNSUpdate(change, entry)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Field validation for 'Network' failed on the 'network_exists' tag")
 	})
 	t.Run("EmptyAddress", func(t *testing.T) {
 		//this can't actually happen as change.Address is populated if is blank
-		change := models.DNSEntry{"", "myhost", "skynet"}
+		change := models.DNSEntry{Address: "", Name: "myhost", Network: "skynet"}
 		err := ValidateDNSUpdate(change, entry)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Field validation for 'Address' failed on the 'required' tag")
 	})
 	t.Run("BadAddress", func(t *testing.T) {
-		change := models.DNSEntry{"10.0.256.1", "myhost", "skynet"}
+		change := models.DNSEntry{Address: "10.0.256.1", Name: "myhost", Network: "skynet"}
 		err := ValidateDNSUpdate(change, entry)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Field validation for 'Address' failed on the 'ip' tag")
 	})
 	t.Run("EmptyName", func(t *testing.T) {
 		//this can't actually happen as change.Name is populated if is blank
-		change := models.DNSEntry{"10.0.0.2", "", "skynet"}
+		change := models.DNSEntry{Address: "10.0.0.2", Name: "", Network: "skynet"}
 		err := ValidateDNSUpdate(change, entry)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Field validation for 'Name' failed on the 'required' tag")
@@ -341,13 +350,13 @@ func TestValidateDNSUpdate(t *testing.T) {
 		for i := 1; i < 194; i++ {
 			name = name + "a"
 		}
-		change := models.DNSEntry{"10.0.0.2", name, "skynet"}
+		change := models.DNSEntry{Address: "10.0.0.2", Name: name, Network: "skynet"}
 		err := ValidateDNSUpdate(change, entry)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Field validation for 'Name' failed on the 'max' tag")
 	})
 	t.Run("NameUnique", func(t *testing.T) {
-		change := models.DNSEntry{"10.0.0.2", "myhost", "wirecat"}
+		change := models.DNSEntry{Address: "10.0.0.2", Name: "myhost", Network: "wirecat"}
 		CreateDNS(entry)
 		CreateDNS(change)
 		err := ValidateDNSUpdate(change, entry)
@@ -362,26 +371,27 @@ func TestValidateDNSUpdate(t *testing.T) {
 func TestValidateDNSCreate(t *testing.T) {
 	database.InitializeDatabase()
 	_ = DeleteDNS("mynode", "skynet")
+	createNet()
 	t.Run("NoNetwork", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.0.2", "myhost", "badnet"}
+		entry := models.DNSEntry{Address: "10.0.0.2", Name: "myhost", Network: "badnet"}
 		err := ValidateDNSCreate(entry)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Field validation for 'Network' failed on the 'network_exists' tag")
 	})
 	t.Run("EmptyAddress", func(t *testing.T) {
-		entry := models.DNSEntry{"", "myhost", "skynet"}
+		entry := models.DNSEntry{Address: "", Name: "myhost", Network: "skynet"}
 		err := ValidateDNSCreate(entry)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Field validation for 'Address' failed on the 'required' tag")
 	})
 	t.Run("BadAddress", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.256.1", "myhost", "skynet"}
+		entry := models.DNSEntry{Address: "10.0.256.1", Name: "myhost", Network: "skynet"}
 		err := ValidateDNSCreate(entry)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Field validation for 'Address' failed on the 'ip' tag")
 	})
 	t.Run("EmptyName", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.0.2", "", "skynet"}
+		entry := models.DNSEntry{Address: "10.0.0.2", Name: "", Network: "skynet"}
 		err := ValidateDNSCreate(entry)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Field validation for 'Name' failed on the 'required' tag")
@@ -391,13 +401,55 @@ func TestValidateDNSCreate(t *testing.T) {
 		for i := 1; i < 194; i++ {
 			name = name + "a"
 		}
-		entry := models.DNSEntry{"10.0.0.2", name, "skynet"}
+		entry := models.DNSEntry{Address: "10.0.0.2", Name: name, Network: "skynet"}
 		err := ValidateDNSCreate(entry)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "Field validation for 'Name' failed on the 'max' tag")
 	})
+	t.Run("AddressTypeMismatch", func(t *testing.T) {
+		entry := models.DNSEntry{Address: "10.0.0.2", Name: "myhost", Network: "skynet", Type: models.DNS_RECORD_AAAA}
+		err := ValidateDNSCreate(entry)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Field validation for 'Address' failed on the 'ipv6' tag")
+	})
+	t.Run("BadType", func(t *testing.T) {
+		entry := models.DNSEntry{Address: "10.0.0.2", Name: "myhost", Network: "skynet", Type: "MX"}
+		err := ValidateDNSCreate(entry)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Field validation for 'Type' failed on the 'oneof' tag")
+	})
+	t.Run("CNAMEWithAddress", func(t *testing.T) {
+		entry := models.DNSEntry{Address: "10.0.0.2", Name: "myalias", Network: "skynet", Type: models.DNS_RECORD_CNAME, Target: "myhost.skynet"}
+		err := ValidateDNSCreate(entry)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Field validation for 'Address' failed on the 'excluded' tag")
+	})
+	t.Run("CNAMENoTarget", func(t *testing.T) {
+		entry := models.DNSEntry{Name: "myalias", Network: "skynet", Type: models.DNS_RECORD_CNAME}
+		err := ValidateDNSCreate(entry)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Field validation for 'Target' failed on the 'required' tag")
+	})
+	t.Run("SRVNoPort", func(t *testing.T) {
+		entry := models.DNSEntry{Name: "_sip._udp", Network: "skynet", Type: models.DNS_RECORD_SRV, Target: "myhost.skynet"}
+		err := ValidateDNSCreate(entry)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Field validation for 'Port' failed on the 'required' tag")
+	})
+	t.Run("TXTNoText", func(t *testing.T) {
+		entry := models.DNSEntry{Name: "mytext", Network: "skynet", Type: models.DNS_RECORD_TXT}
+		err := ValidateDNSCreate(entry)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Field validation for 'Text' failed on the 'required' tag")
+	})
+	t.Run("BadTTL", func(t *testing.T) {
+		entry := models.DNSEntry{Name: "mytext", Network: "skynet", Type: models.DNS_RECORD_TXT, Text: "hello", TTL: 1000000}
+		err := ValidateDNSCreate(entry)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Field validation for 'TTL' failed on the 'max' tag")
+	})
 	t.Run("NameUnique", func(t *testing.T) {
-		entry := models.DNSEntry{"10.0.0.2", "myhost", "skynet"}
+		entry := models.DNSEntry{Address: "10.0.0.2", Name: "myhost", Network: "skynet"}
 		_, _ = CreateDNS(entry)
 		err := ValidateDNSCreate(entry)
 		assert.NotNil(t, err)
@@ -405,6 +457,34 @@ func TestValidateDNSCreate(t *testing.T) {
 	})
 }
 
+func TestValidateDNSRecord(t *testing.T) {
+	v := validator.New()
+	_ = v.RegisterValidation("name_unique", func(fl validator.FieldLevel) bool { return true })
+	_ = v.RegisterValidation("network_exists", func(fl validator.FieldLevel) bool { return true })
+	v.RegisterStructValidation(validateDNSRecord, models.DNSEntry{})
+	t.Run("Legacy", func(t *testing.T) {
+		err := v.Struct(models.DNSEntry{Address: "fd00::2", Name: "myhost", Network: "skynet"})
+		assert.Nil(t, err)
+	})
+	t.Run("CNAME", func(t *testing.T) {
+		err := v.Struct(models.DNSEntry{Name: "myalias", Network: "skynet", Type: models.DNS_RECORD_CNAME, Target: "myhost.skynet"})
+		assert.Nil(t, err)
+	})
+	t.Run("SRV", func(t *testing.T) {
+		err := v.Struct(models.DNSEntry{Name: "_sip._udp", Network: "skynet", Type: models.DNS_RECORD_SRV, Target: "myhost.skynet", Priority: 10, Weight: 5, Port: 5060, TTL: 60})
+		assert.Nil(t, err)
+	})
+	t.Run("TXT", func(t *testing.T) {
+		err := v.Struct(models.DNSEntry{Name: "mytext", Network: "skynet", Type: models.DNS_RECORD_TXT, Text: "v=spf1 -all"})
+		assert.Nil(t, err)
+	})
+	t.Run("BadTarget", func(t *testing.T) {
+		err := v.Struct(models.DNSEntry{Name: "myalias", Network: "skynet", Type: models.DNS_RECORD_CNAME, Target: "my..host"})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Field validation for 'Target' failed on the 'hostname' tag")
+	})
+}
+
 func deleteAllDNS(t *testing.T) {
 	dns, err := GetAllDNS()
 	assert.Nil(t, err)
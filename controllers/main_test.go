@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/logic"
+)
+
+// TestMain - runs the tests on an in-memory database, so they leave no database file behind
+func TestMain(m *testing.M) {
+	database.SetStore(database.NewMemoryStore())
+	// the tests run as the only server instance, which leads and writes the dns files
+	database.InitializeDatabase()
+	logic.RunLeaderElection()
+	os.Exit(m.Run())
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,6 +30,8 @@ func networkHandlers(r *mux.Router) {
 	r.HandleFunc("/api/networks/{networkname}/nodelimit", securityCheck(true, http.HandlerFunc(updateNetworkNodeLimit))).Methods("PUT")
 	r.HandleFunc("/api/networks/{networkname}", securityCheck(true, http.HandlerFunc(deleteNetwork))).Methods("DELETE")
 	r.HandleFunc("/api/networks/{networkname}/keyupdate", securityCheck(false, http.HandlerFunc(keyUpdate))).Methods("POST")
+	r.HandleFunc("/api/networks/{networkname}/keyupdate/overdue", securityCheck(false, http.HandlerFunc(getOverdueKeys))).Methods("GET")
+	r.HandleFunc("/api/networks/{networkname}/keyrotation", securityCheck(false, http.HandlerFunc(updateKeyRotationPolicy))).Methods("PUT")
 	r.HandleFunc("/api/networks/{networkname}/keys", securityCheck(false, http.HandlerFunc(createAccessKey))).Methods("POST")
 	r.HandleFunc("/api/networks/{networkname}/keys", securityCheck(false, http.HandlerFunc(getAccessKeys))).Methods("GET")
 	r.HandleFunc("/api/networks/{networkname}/signuptoken", securityCheck(false, http.HandlerFunc(getSignupToken))).Methods("GET")
@@ -236,7 +239,58 @@ func KeyUpdate(netname string) (models.Network, error) {
 	if err != nil {
 		return models.Network{}, err
 	}
-	return models.Network{}, nil
+	network, err := logic.GetParentNetwork(netname)
+	if err != nil {
+		return models.Network{}, err
+	}
+	network.KeyUpdateTimeStamp = time.Now().Unix()
+	data, err := json.Marshal(&network)
+	if err != nil {
+		return models.Network{}, err
+	}
+	if err = database.Insert(netname, string(data), database.NETWORKS_TABLE_NAME); err != nil {
+		return models.Network{}, err
+	}
+	return network, nil
+}
+
+// getOverdueKeys - lists the nodes of a network whose keys are past the rotation policy of the network
+func getOverdueKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var params = mux.Vars(r)
+	netname := params["networkname"]
+	overdue, err := logic.GetOverdueKeys(netname, time.Now().Unix())
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, "internal"))
+		return
+	}
+	functions.PrintUserLog(r.Header.Get("user"), "fetched overdue keys on network "+netname, 2)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(overdue)
+}
+
+// updateKeyRotationPolicy - sets the key rotation days and stagger hours of a network, the other settings are kept
+func updateKeyRotationPolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var params = mux.Vars(r)
+	netname := params["networkname"]
+	var policy models.KeyRotationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		returnErrorResponse(w, r, formatError(err, "badrequest"))
+		return
+	}
+	network, err := logic.SetKeyRotationPolicy(netname, policy)
+	if err != nil {
+		errtype := "badrequest"
+		if database.IsEmptyRecord(err) {
+			errtype = "notfound"
+		}
+		returnErrorResponse(w, r, formatError(err, errtype))
+		return
+	}
+	functions.PrintUserLog(r.Header.Get("user"), "updated key rotation policy of network "+netname, 1)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(network)
 }
 
 //Update a network
@@ -281,6 +335,25 @@ func updateNetwork(w http.ResponseWriter, r *http.Request) {
 		returnErrorResponse(w, r, formatError(err, "badrequest"))
 		return
 	}
+	if newNetwork.MTUDiscovery == "yes" && network.MTUDiscovery != "yes" {
+		if _, err = logic.SetNetworkMTU(network.NetID); err != nil {
+			returnErrorResponse(w, r, formatError(err, "internal"))
+			return
+		}
+	}
+	if logic.DNSSettingsChanged(&network, &newNetwork) {
+		if servercfg.IsDNSMode() {
+			err = logic.SetDNS()
+			if err != nil {
+				returnErrorResponse(w, r, formatError(err, "internal"))
+				return
+			}
+		}
+		if err = functions.NetworkNodesUpdatePullChanges(network.NetID); err != nil {
+			returnErrorResponse(w, r, formatError(err, "internal"))
+			return
+		}
+	}
 
 	// if newNetwork.IsDualStack != currentNetwork.IsDualStack && newNetwork.IsDualStack == "no" {
 	// 	// Remove IPv6 address from network nodes
@@ -343,6 +416,27 @@ func deleteNetwork(w http.ResponseWriter, r *http.Request) {
 
 	var params = mux.Vars(r)
 	network := params["networkname"]
+	if r.URL.Query().Get("force") == "true" {
+		timeout := logic.NETWORK_DELETE_TIMEOUT
+		if value := r.URL.Query().Get("timeout"); value != "" {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 0 {
+				returnErrorResponse(w, r, formatError(errors.New("invalid timeout "+value), "badrequest"))
+				return
+			}
+			timeout = time.Duration(seconds) * time.Second
+		}
+		operation, err := logic.StartNetworkDelete(network, timeout)
+		if err != nil {
+			returnErrorResponse(w, r, formatError(err, "badrequest"))
+			return
+		}
+		functions.PrintUserLog(r.Header.Get("user"), "started forced delete of network "+network, 1)
+		w.Header().Set("Location", "/api/operations/"+operation.ID)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(operation)
+		return
+	}
 	err := DeleteNetwork(network)
 
 	if err != nil {
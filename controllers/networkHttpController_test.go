@@ -1,9 +1,14 @@
 package controller
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/gravitl/netmaker/database"
 	"github.com/gravitl/netmaker/logic"
 	"github.com/gravitl/netmaker/models"
@@ -355,3 +360,45 @@ func getNet() models.Network {
 	network, _ := GetNetwork("skynet")
 	return network
 }
+
+func TestUpdateKeyRotationPolicy(t *testing.T) {
+	database.InitializeDatabase()
+	deleteAllNetworks()
+	network := models.Network{NetID: "skynet", AddressRange: "10.0.0.0/24", DisplayName: "mynetwork"}
+	network.SetDefaults()
+	data, err := json.Marshal(&network)
+	assert.Nil(t, err)
+	assert.Nil(t, database.Insert(network.NetID, string(data), database.NETWORKS_TABLE_NAME))
+	update := func(body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPut, "/api/networks/skynet/keyrotation", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"networkname": "skynet"})
+		w := httptest.NewRecorder()
+		updateKeyRotationPolicy(w, req)
+		return w.Result()
+	}
+	t.Run("Set", func(t *testing.T) {
+		resp := update(`{"keyrotationdays":90,"keyrotationstaggerhours":24}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		stored, err := logic.GetParentNetwork("skynet")
+		assert.Nil(t, err)
+		assert.Equal(t, int32(90), stored.KeyRotationDays)
+		assert.Equal(t, int32(24), stored.KeyRotationStaggerHours)
+		assert.Equal(t, "mynetwork", stored.DisplayName)
+		assert.Equal(t, "10.0.0.0/24", stored.AddressRange)
+	})
+	t.Run("Disable", func(t *testing.T) {
+		resp := update(`{"keyrotationdays":0}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		stored, err := logic.GetParentNetwork("skynet")
+		assert.Nil(t, err)
+		assert.Equal(t, int32(0), stored.KeyRotationDays)
+		assert.Equal(t, int32(24), stored.KeyRotationStaggerHours)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		resp := update(`{"keyrotationdays":-1}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		stored, err := logic.GetParentNetwork("skynet")
+		assert.Nil(t, err)
+		assert.Equal(t, int32(0), stored.KeyRotationDays)
+	})
+}
@@ -20,12 +20,12 @@ type NodeServiceServer struct {
 // NodeServiceServer.ReadNode - reads node and responds with gRPC
 func (s *NodeServiceServer) ReadNode(ctx context.Context, req *nodepb.Object) (*nodepb.Object, error) {
 	// convert string id (from proto) to mongoDB ObjectId
-	macAndNetwork := strings.Split(req.Data, "###")
+	idAndNetwork := strings.Split(req.Data, "###")
 
-	if len(macAndNetwork) != 2 {
+	if len(idAndNetwork) != 2 {
 		return nil, errors.New("could not read node, invalid node id given")
 	}
-	node, err := GetNode(macAndNetwork[0], macAndNetwork[1])
+	node, err := GetNode(idAndNetwork[0], idAndNetwork[1])
 	if err != nil {
 		return nil, err
 	}
@@ -33,13 +33,19 @@ func (s *NodeServiceServer) ReadNode(ctx context.Context, req *nodepb.Object) (*
 	if err != nil {
 		return nil, err
 	}
-	node.SetLastCheckIn()
+	// a read only read leaves the last check in alone, so it does not make a stopped client look alive
+	readOnly := req.Metadata == nodepb.READ_ONLY
+	if !readOnly {
+		node.SetLastCheckIn()
+	}
 	// Cast to ReadNodeRes type
 	nodeData, errN := json.Marshal(&node)
 	if errN != nil {
 		return nil, err
 	}
-	logic.UpdateNode(&node, &node)
+	if !readOnly {
+		logic.UpdateNode(&node, &node)
+	}
 	response := &nodepb.Object{
 		Data: string(nodeData),
 		Type: nodepb.NODE_TYPE,
@@ -58,7 +64,7 @@ func (s *NodeServiceServer) CreateNode(ctx context.Context, req *nodepb.Object)
 	}
 
 	//Check to see if key is valid
-	//TODO: Triple inefficient!!! This is the third call to the DB we make for networks
+	// the network is looked up several times while creating the node, after the first it comes from the networks cache
 	validKey := logic.IsKeyValid(node.Network, node.AccessKey)
 	network, err := logic.GetParentNetwork(node.Network)
 	if err != nil {
@@ -107,17 +113,30 @@ func (s *NodeServiceServer) UpdateNode(ctx context.Context, req *nodepb.Object)
 	if err := json.Unmarshal([]byte(req.GetData()), &newnode); err != nil {
 		return nil, err
 	}
-	macaddress := newnode.MacAddress
 	networkName := newnode.Network
 
-	node, err := logic.GetNodeByMacAddress(networkName, macaddress)
+	node, err := logic.GetNodeByID(networkName, newnode.GetNodeID())
 	if err != nil {
 		return nil, err
 	}
+	// a node told to leave keeps that action until it leaves
+	if node.Action == models.NODE_DELETE {
+		newnode.Action = models.NODE_DELETE
+	}
 	err = logic.UpdateNode(&node, &newnode)
 	if err != nil {
 		return nil, err
 	}
+	if len(newnode.RouteErrors) > 0 && strings.Join(newnode.RouteErrors, "\n") != strings.Join(node.RouteErrors, "\n") {
+		logic.Log("node "+newnode.Name+" on network "+networkName+" could not set routes: "+strings.Join(newnode.RouteErrors, "; "), 1)
+	}
+	if newnode.RecommendedMTU != node.RecommendedMTU {
+		if mtu, err := logic.SetNetworkMTU(networkName); err != nil {
+			logic.Log("failed to set mtu of network "+networkName+": "+err.Error(), 1)
+		} else if mtu != 0 {
+			newnode.MTU = mtu
+		}
+	}
 	newnode.NetworkSettings, err = logic.GetNetworkSettings(node.Network)
 	if err != nil {
 		return nil, err
@@ -134,9 +153,15 @@ func (s *NodeServiceServer) UpdateNode(ctx context.Context, req *nodepb.Object)
 
 // NodeServiceServer.DeleteNode - deletes a node and responds over gRPC
 func (s *NodeServiceServer) DeleteNode(ctx context.Context, req *nodepb.Object) (*nodepb.Object, error) {
-	nodeID := req.GetData()
-
-	err := DeleteNode(nodeID, true)
+	idAndNetwork := strings.Split(req.GetData(), "###")
+	if len(idAndNetwork) != 2 {
+		return nil, errors.New("could not delete node, invalid node id given")
+	}
+	node, err := GetNode(idAndNetwork[0], idAndNetwork[1])
+	if err != nil {
+		return nil, err
+	}
+	err = DeleteNode(node.ID, true)
 	if err != nil {
 		return nil, err
 	}
@@ -149,10 +174,10 @@ func (s *NodeServiceServer) DeleteNode(ctx context.Context, req *nodepb.Object)
 
 // NodeServiceServer.GetPeers - fetches peers over gRPC
 func (s *NodeServiceServer) GetPeers(ctx context.Context, req *nodepb.Object) (*nodepb.Object, error) {
-	macAndNetwork := strings.Split(req.Data, "###")
-	if len(macAndNetwork) == 2 {
+	idAndNetwork := strings.Split(req.Data, "###")
+	if len(idAndNetwork) == 2 {
 		// TODO: Make constant and new variable for isServer
-		node, err := GetNode(macAndNetwork[0], macAndNetwork[1])
+		node, err := GetNode(idAndNetwork[0], idAndNetwork[1])
 		if err != nil {
 			return nil, err
 		}
@@ -164,7 +189,7 @@ func (s *NodeServiceServer) GetPeers(ctx context.Context, req *nodepb.Object) (*
 		if node.IsRelayed == "yes" {
 			relayedNode = node.Address
 		}
-		peers, err := logic.GetPeersList(macAndNetwork[1], excludeIsRelayed, relayedNode)
+		peers, err := logic.GetPeersList(idAndNetwork[1], excludeIsRelayed, relayedNode)
 		if err != nil {
 			return nil, err
 		}
@@ -187,11 +212,15 @@ func (s *NodeServiceServer) GetExtPeers(ctx context.Context, req *nodepb.Object)
 	// Initiate a NodeItem type to write decoded data to
 	//data := &models.PeersResponse{}
 	// collection.Find returns a cursor for our (empty) query
-	macAndNetwork := strings.Split(req.Data, "###")
-	if len(macAndNetwork) != 2 {
+	idAndNetwork := strings.Split(req.Data, "###")
+	if len(idAndNetwork) != 2 {
 		return nil, errors.New("did not receive valid node id when fetching ext peers")
 	}
-	peers, err := logic.GetExtPeersList(macAndNetwork[0], macAndNetwork[1])
+	node, err := logic.GetNodeByID(idAndNetwork[1], idAndNetwork[0])
+	if err != nil {
+		return nil, err
+	}
+	peers, err := logic.GetExtPeersList(node.UUID, idAndNetwork[1])
 	if err != nil {
 		return nil, err
 	}
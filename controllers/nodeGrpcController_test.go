@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gravitl/netmaker/database"
+	nodepb "github.com/gravitl/netmaker/grpc"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadNode(t *testing.T) {
+	database.InitializeDatabase()
+	deleteAllNetworks()
+	network := models.Network{NetID: "skynet", AddressRange: "10.0.0.0/24"}
+	network.SetDefaults()
+	data, err := json.Marshal(&network)
+	assert.Nil(t, err)
+	assert.Nil(t, database.Insert(network.NetID, string(data), database.NETWORKS_TABLE_NAME))
+	node := models.Node{UUID: "b6a2e5c0-5f5d-4a5e-9f26-3c2b8f0d1e4a", Name: "testnode", Network: "skynet", Address: "10.0.0.1",
+		PublicKey: "DM5qhLAE20PG9BbfBCger+Ac9D2NDOwCtY1rbYDLf34=", Endpoint: "10.0.0.1", Password: "password"}
+	logic.SetNodeDefaults(&node)
+	node.LastCheckIn = 1
+	node.SetID()
+	data, err = json.Marshal(&node)
+	assert.Nil(t, err)
+	assert.Nil(t, database.Insert(node.ID, string(data), database.NODES_TABLE_NAME))
+	server := &NodeServiceServer{}
+	req := &nodepb.Object{Data: node.UUID + "###skynet", Type: nodepb.STRING_TYPE}
+	t.Run("ReadOnly", func(t *testing.T) {
+		req.Metadata = nodepb.READ_ONLY
+		res, err := server.ReadNode(context.Background(), req)
+		assert.Nil(t, err)
+		var read models.Node
+		assert.Nil(t, json.Unmarshal([]byte(res.Data), &read))
+		assert.Equal(t, node.UUID, read.UUID)
+		assert.Equal(t, int64(1), storedCheckIn(t, node.ID))
+	})
+	t.Run("CheckIn", func(t *testing.T) {
+		req.Metadata = ""
+		_, err := server.ReadNode(context.Background(), req)
+		assert.Nil(t, err)
+		assert.Greater(t, storedCheckIn(t, node.ID), int64(1))
+	})
+}
+
+// storedCheckIn - reads the last check in of a node as stored, reading the node sets its defaults and with them the check in
+func storedCheckIn(t *testing.T, id string) int64 {
+	record, err := database.FetchRecord(database.NODES_TABLE_NAME, id)
+	assert.Nil(t, err)
+	var node models.Node
+	assert.Nil(t, json.Unmarshal([]byte(record), &node))
+	return node.LastCheckIn
+}
@@ -23,7 +23,7 @@ func createRelay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	relay.NetID = params["network"]
-	relay.NodeID = params["macaddress"]
+	relay.NodeID = params["nodeid"]
 	node, err := CreateRelay(relay)
 	if err != nil {
 		returnErrorResponse(w, r, formatError(err, "internal"))
@@ -36,7 +36,7 @@ func createRelay(w http.ResponseWriter, r *http.Request) {
 
 // CreateRelay - creates a relay
 func CreateRelay(relay models.RelayRequest) (models.Node, error) {
-	node, err := logic.GetNodeByMacAddress(relay.NetID, relay.NodeID)
+	node, err := logic.GetNodeByID(relay.NetID, relay.NodeID)
 	if node.OS == "macos" { // add in darwin later
 		return models.Node{}, errors.New(node.OS + " is unsupported for relay")
 	}
@@ -77,14 +77,14 @@ func CreateRelay(relay models.RelayRequest) (models.Node, error) {
 func deleteRelay(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var params = mux.Vars(r)
-	nodeMac := params["macaddress"]
+	nodeid := params["nodeid"]
 	netid := params["network"]
-	node, err := DeleteRelay(netid, nodeMac)
+	node, err := DeleteRelay(netid, nodeid)
 	if err != nil {
 		returnErrorResponse(w, r, formatError(err, "internal"))
 		return
 	}
-	functions.PrintUserLog(r.Header.Get("user"), "deleted egress gateway "+nodeMac+" on network "+netid, 1)
+	functions.PrintUserLog(r.Header.Get("user"), "deleted egress gateway "+nodeid+" on network "+netid, 1)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(node)
 }
@@ -146,9 +146,9 @@ func UpdateRelay(network string, oldAddrs []string, newAddrs []string) {
 }
 
 // DeleteRelay - deletes a relay
-func DeleteRelay(network, macaddress string) (models.Node, error) {
+func DeleteRelay(network, nodeid string) (models.Node, error) {
 
-	node, err := logic.GetNodeByMacAddress(network, macaddress)
+	node, err := logic.GetNodeByID(network, nodeid)
 	if err != nil {
 		return models.Node{}, err
 	}
@@ -161,7 +161,7 @@ func DeleteRelay(network, macaddress string) (models.Node, error) {
 	node.RelayAddrs = []string{}
 	node.SetLastModified()
 	node.PullChanges = "yes"
-	key, err := logic.GetRecordKey(node.MacAddress, node.Network)
+	key, err := logic.GetRecordKey(node.UUID, node.Network)
 	if err != nil {
 		return models.Node{}, err
 	}
@@ -2,10 +2,14 @@ package controller
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/functions"
 	"github.com/gravitl/netmaker/logic"
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/servercfg"
@@ -16,6 +20,14 @@ func serverHandlers(r *mux.Router) {
 	r.HandleFunc("/api/server/addnetwork/{network}", securityCheckServer(true, http.HandlerFunc(addNetwork))).Methods("POST")
 	r.HandleFunc("/api/server/getconfig", securityCheckServer(false, http.HandlerFunc(getConfig))).Methods("GET")
 	r.HandleFunc("/api/server/removenetwork/{network}", securityCheckServer(true, http.HandlerFunc(removeNetwork))).Methods("DELETE")
+	r.HandleFunc("/api/operations/{operationid}", securityCheckServer(true, http.HandlerFunc(getOperation))).Methods("GET")
+	r.HandleFunc("/api/server/export", securityCheckServer(true, http.HandlerFunc(exportState))).Methods("GET")
+	r.HandleFunc("/api/server/import", securityCheckServer(true, http.HandlerFunc(importState))).Methods("POST")
+	r.HandleFunc("/api/server/backups", securityCheckServer(true, http.HandlerFunc(getBackups))).Methods("GET")
+	// no authentication, so load balancers can check the instances
+	r.HandleFunc("/api/server/health", http.HandlerFunc(getServerHealth)).Methods("GET")
+	r.HandleFunc("/api/server/instances", securityCheckServer(true, http.HandlerFunc(getServerInstances))).Methods("GET")
+	r.HandleFunc("/api/server/cache", securityCheckServer(true, http.HandlerFunc(getCacheStats))).Methods("GET")
 }
 
 //Security check is middleware for every function and just checks to make sure that its the master calling
@@ -110,3 +122,115 @@ func addNetwork(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode("Server added to network " + params["network"])
 }
+
+// getOperation - reports the progress of a long running operation
+func getOperation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var params = mux.Vars(r)
+	operation, err := logic.GetOperation(params["operationid"])
+	if err != nil {
+		errtype := "internal"
+		if database.IsEmptyRecord(err) {
+			errtype = "notfound"
+		}
+		returnErrorResponse(w, r, formatError(err, errtype))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(operation)
+}
+
+// ARCHIVE_PASSPHRASE_HEADER - the header the passphrase of an encrypted archive is sent in, so it stays out of the request logs
+const ARCHIVE_PASSPHRASE_HEADER = "X-Archive-Passphrase"
+
+// exportState - downloads an archive of the database, of the given networks only when networks=a,b is set
+func exportState(w http.ResponseWriter, r *http.Request) {
+	var networks []string
+	if param := r.URL.Query().Get("networks"); param != "" {
+		networks = strings.Split(param, ",")
+	}
+	archive, err := logic.ExportState(networks)
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, "badrequest"))
+		return
+	}
+	data, err := logic.EncodeArchive(&archive, r.Header.Get(ARCHIVE_PASSPHRASE_HEADER))
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, "internal"))
+		return
+	}
+	functions.PrintUserLog(r.Header.Get("user"), "exported the server state", 1)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"netmaker-"+time.Now().Format("20060102-150405")+".archive\"")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// importState - restores an archive sent as the body, replace=true removes what the archive replaces first
+func importState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, "badrequest"))
+		return
+	}
+	archive, err := logic.DecodeArchive(data, r.Header.Get(ARCHIVE_PASSPHRASE_HEADER))
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, "badrequest"))
+		return
+	}
+	summary, err := logic.ImportState(&archive, r.URL.Query().Get("replace") == "true")
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, "internal"))
+		return
+	}
+	if servercfg.IsDNSMode() {
+		if err = logic.SetDNS(); err != nil {
+			functions.PrintUserLog(r.Header.Get("user"), "could not update dns after an import: "+err.Error(), 1)
+		}
+	}
+	functions.PrintUserLog(r.Header.Get("user"), "imported a server state archive", 1)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(summary)
+}
+
+// getBackups - lists the scheduled backups of the database, newest first
+func getBackups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	backups, err := logic.GetBackups()
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, "internal"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(backups)
+}
+
+// getServerHealth - answers with the role of this server instance, with a 503 when it can not reach the database
+func getServerHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	health := logic.GetServerHealth()
+	if health.DatabaseOK {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(health)
+}
+
+func getServerInstances(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	instances, err := logic.GetServerInstances()
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, "internal"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(instances)
+}
+
+func getCacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(logic.GetCacheStats())
+}
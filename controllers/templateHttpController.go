@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/functions"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+)
+
+func templateHandlers(r *mux.Router) {
+	r.HandleFunc("/api/templates", securityCheck(true, http.HandlerFunc(getTemplates))).Methods("GET")
+	r.HandleFunc("/api/templates", securityCheck(true, http.HandlerFunc(saveTemplate))).Methods("POST")
+	r.HandleFunc("/api/templates/{templatename}", securityCheck(true, http.HandlerFunc(getTemplate))).Methods("GET")
+	r.HandleFunc("/api/templates/{templatename}", securityCheck(true, http.HandlerFunc(deleteTemplate))).Methods("DELETE")
+	r.HandleFunc("/api/networks/{networkname}/template", securityCheck(true, http.HandlerFunc(saveNetworkTemplate))).Methods("POST")
+	r.HandleFunc("/api/networks/{networkname}/clone", securityCheck(true, http.HandlerFunc(cloneNetwork))).Methods("POST")
+}
+
+func getTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	templates, err := logic.GetTemplates()
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, "internal"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(templates)
+}
+
+func getTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var params = mux.Vars(r)
+	template, err := logic.GetTemplate(params["templatename"])
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, templateErrorType(err)))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(template)
+}
+
+func saveTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var template models.NetworkTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		returnErrorResponse(w, r, formatError(err, "badrequest"))
+		return
+	}
+	if err := logic.SaveTemplate(&template); err != nil {
+		returnErrorResponse(w, r, formatError(err, "badrequest"))
+		return
+	}
+	functions.PrintUserLog(r.Header.Get("user"), "saved network template "+template.Name, 1)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(template)
+}
+
+func deleteTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var params = mux.Vars(r)
+	name := params["templatename"]
+	if err := logic.DeleteTemplate(name); err != nil {
+		returnErrorResponse(w, r, formatError(err, templateErrorType(err)))
+		return
+	}
+	functions.PrintUserLog(r.Header.Get("user"), "deleted network template "+name, 1)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(name + " deleted.")
+}
+
+// saveNetworkTemplate - saves a network as a template under the name in the body
+func saveNetworkTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var params = mux.Vars(r)
+	var request models.NetworkTemplate
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		returnErrorResponse(w, r, formatError(err, "badrequest"))
+		return
+	}
+	template, err := NetworkTemplate(params["networkname"], request.Name)
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, templateErrorType(err)))
+		return
+	}
+	if err = logic.SaveTemplate(&template); err != nil {
+		returnErrorResponse(w, r, formatError(err, "badrequest"))
+		return
+	}
+	functions.PrintUserLog(r.Header.Get("user"), "saved network "+params["networkname"]+" as template "+template.Name, 1)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(template)
+}
+
+// cloneNetwork - creates a network from a network, or from a template when template=true is given
+func cloneNetwork(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var params = mux.Vars(r)
+	source := params["networkname"]
+	var request models.CloneNetworkRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		returnErrorResponse(w, r, formatError(err, "badrequest"))
+		return
+	}
+	var template models.NetworkTemplate
+	var err error
+	if r.URL.Query().Get("template") == "true" {
+		template, err = logic.GetTemplate(source)
+	} else {
+		template, err = NetworkTemplate(source, source)
+	}
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, templateErrorType(err)))
+		return
+	}
+	response, err := CloneNetwork(&template, &request)
+	if err != nil {
+		returnErrorResponse(w, r, formatError(err, "badrequest"))
+		return
+	}
+	functions.PrintUserLog(r.Header.Get("user"), "cloned "+source+" into network "+request.NetID, 1)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// NetworkTemplate - makes a template of an existing network
+func NetworkTemplate(networkName string, templateName string) (models.NetworkTemplate, error) {
+	network, err := logic.GetParentNetwork(networkName)
+	if err != nil {
+		return models.NetworkTemplate{}, err
+	}
+	entries, err := logic.GetCustomDNS(networkName)
+	if err != nil && !database.IsEmptyRecord(err) {
+		return models.NetworkTemplate{}, err
+	}
+	servers, err := logic.GetSortedNetworkServerNodes(networkName)
+	if err != nil {
+		return models.NetworkTemplate{}, err
+	}
+	return logic.TemplateFromNetwork(templateName, network, entries, servers), nil
+}
+
+// CloneNetwork - creates a network from a template with its access keys, dns entries and the gateways of its server node,
+// the network is removed again when it can not be set up
+func CloneNetwork(template *models.NetworkTemplate, request *models.CloneNetworkRequest) (models.CloneNetworkResponse, error) {
+	response := models.CloneNetworkResponse{
+		AccessKeys: []models.AccessKey{},
+		DNSEntries: []models.DNSEntry{},
+		Gateways:   []string{},
+		Skipped:    []string{},
+	}
+	network, err := logic.NetworkFromTemplate(template, request)
+	if err != nil {
+		return response, err
+	}
+	if err = CreateNetwork(network); err != nil {
+		return response, err
+	}
+	network, err = logic.GetParentNetwork(network.NetID)
+	if err != nil {
+		return response, err
+	}
+	rollback := func() {
+		for _, entry := range response.DNSEntries {
+			DeleteDNS(entry.Name, entry.Network)
+		}
+		if err := DeleteNetwork(network.NetID); err != nil {
+			functions.PrintUserLog("", "could not remove network "+network.NetID+" after a failed clone: "+err.Error(), 1)
+		}
+	}
+
+	for _, keyTemplate := range template.AccessKeys {
+		key, err := CreateAccessKey(models.AccessKey{Name: keyTemplate.Name, Uses: keyTemplate.Uses}, network)
+		if err != nil {
+			rollback()
+			return response, err
+		}
+		response.AccessKeys = append(response.AccessKeys, key)
+	}
+
+	for _, templateEntry := range template.DNSEntries {
+		entry, ok := logic.CloneDNSEntry(templateEntry, template, &network)
+		if !ok {
+			response.Skipped = append(response.Skipped, "dns entry "+templateEntry.Name+": "+templateEntry.Address+" does not fit in the address range")
+			continue
+		}
+		if err = ValidateDNSCreate(entry); err != nil {
+			rollback()
+			return response, err
+		}
+		if entry, err = CreateDNS(entry); err != nil {
+			rollback()
+			return response, err
+		}
+		response.DNSEntries = append(response.DNSEntries, entry)
+	}
+	if len(response.DNSEntries) > 0 && servercfg.IsDNSMode() {
+		if err = logic.SetDNS(); err != nil {
+			functions.PrintUserLog("", "could not update dns after cloning network "+network.NetID+": "+err.Error(), 1)
+		}
+	}
+
+	if len(template.Gateways) > 0 {
+		servers, err := logic.GetSortedNetworkServerNodes(network.NetID)
+		if err != nil || len(servers) == 0 {
+			response.Skipped = append(response.Skipped, "gateways: the network has no server node, client mode is off")
+		} else {
+			response.Gateways, response.Skipped = createTemplateGateways(template.Gateways, &servers[0], response.Skipped)
+		}
+	}
+	response.Network, err = logic.GetParentNetwork(network.NetID)
+	return response, err
+}
+
+// createTemplateGateways - makes the gateways of a template on a server node, a gateway that can not be made is skipped
+func createTemplateGateways(gateways []models.GatewayTemplate, server *models.Node, skipped []string) ([]string, []string) {
+	created := []string{}
+	for _, gateway := range gateways {
+		if gateway.IsEgressGateway {
+			_, err := CreateEgressGateway(models.EgressGatewayRequest{
+				NodeID:    server.GetNodeID(),
+				NetID:     server.Network,
+				Ranges:    gateway.Ranges,
+				Interface: gateway.Interface,
+				PostUp:    gateway.PostUp,
+				PostDown:  gateway.PostDown,
+			})
+			if err != nil {
+				skipped = append(skipped, "egress gateway: "+err.Error())
+			} else {
+				created = append(created, "egress gateway on "+server.Name)
+			}
+		}
+		if gateway.IsIngressGateway {
+			if _, err := CreateIngressGateway(server.Network, server.GetNodeID()); err != nil {
+				skipped = append(skipped, "ingress gateway: "+err.Error())
+			} else {
+				created = append(created, "ingress gateway on "+server.Name)
+			}
+		}
+	}
+	return created, skipped
+}
+
+// templateErrorType - a missing template or network is not found, anything else a bad request
+func templateErrorType(err error) string {
+	if database.IsEmptyRecord(err) {
+		return "notfound"
+	}
+	return "badrequest"
+}
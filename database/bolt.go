@@ -0,0 +1,175 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltStore - a bbolt key value file with a bucket for each table, pure go so the server builds without cgo
+type boltStore struct {
+	path string
+	db   *bolt.DB
+}
+
+// NewBoltStore - makes the store of a bbolt database file, the file is created on init when it does not exist
+func NewBoltStore(path string) SnapshotStore {
+	return &boltStore{path: path}
+}
+
+func (store *boltStore) Name() string {
+	return "bbolt"
+}
+
+func (store *boltStore) Init() error {
+	if err := os.MkdirAll(filepath.Dir(store.path), 0744); err != nil {
+		return err
+	}
+	// the file is locked while open, so a second server on the same file fails instead of hanging
+	db, err := bolt.Open(store.path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	store.db = db
+	return nil
+}
+
+func (store *boltStore) CreateTable(tableName string) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(tableName))
+		return err
+	})
+}
+
+func (store *boltStore) Insert(key string, value string, tableName string) error {
+	if key == "" || value == "" || !IsJSONString(value) {
+		return errors.New("invalid insert " + key + " : " + value)
+	}
+	return store.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := boltBucket(tx, tableName)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), []byte(value))
+	})
+}
+
+func (store *boltStore) CompareAndSwap(key string, oldValue string, newValue string, tableName string) (bool, error) {
+	if key == "" || newValue == "" || !IsJSONString(newValue) {
+		return false, errors.New("invalid insert " + key + " : " + newValue)
+	}
+	swapped := false
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := boltBucket(tx, tableName)
+		if err != nil {
+			return err
+		}
+		if string(bucket.Get([]byte(key))) != oldValue {
+			return nil
+		}
+		swapped = true
+		return bucket.Put([]byte(key), []byte(newValue))
+	})
+	return swapped && err == nil, err
+}
+
+func (store *boltStore) DeleteRecord(tableName string, key string) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := boltBucket(tx, tableName)
+		if err != nil {
+			return err
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
+
+func (store *boltStore) DeleteAllRecords(tableName string) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(tableName)); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket([]byte(tableName))
+		return err
+	})
+}
+
+func (store *boltStore) FetchRecords(tableName string) (map[string]string, error) {
+	records := make(map[string]string)
+	err := store.db.View(func(tx *bolt.Tx) error {
+		bucket, err := boltBucket(tx, tableName)
+		if err != nil {
+			return err
+		}
+		return bucket.ForEach(func(key, value []byte) error {
+			records[string(key)] = string(value)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New(NO_RECORDS)
+	}
+	return records, nil
+}
+
+func (store *boltStore) Close() {
+	store.db.Close()
+}
+
+// boltStore.Backup - copies the file in a read transaction, so the copy is consistent while the server writes
+func (store *boltStore) Backup(path string) error {
+	return store.db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(path, 0600)
+	})
+}
+
+// boltStore.Restore - replaces every bucket with the buckets of a snapshot in one transaction
+func (store *boltStore) Restore(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	snapshot, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer snapshot.Close()
+	return snapshot.View(func(source *bolt.Tx) error {
+		return store.db.Update(func(dest *bolt.Tx) error {
+			var names [][]byte
+			if err := dest.ForEach(func(name []byte, _ *bolt.Bucket) error {
+				names = append(names, append([]byte{}, name...))
+				return nil
+			}); err != nil {
+				return err
+			}
+			for _, name := range names {
+				if err := dest.DeleteBucket(name); err != nil {
+					return err
+				}
+			}
+			return source.ForEach(func(name []byte, sourceBucket *bolt.Bucket) error {
+				bucket, err := dest.CreateBucket(name)
+				if err != nil {
+					return err
+				}
+				return sourceBucket.ForEach(func(key, value []byte) error {
+					return bucket.Put(key, value)
+				})
+			})
+		})
+	})
+}
+
+// boltBucket - the bucket of a table, with the same error as the sql databases when the table does not exist
+func boltBucket(tx *bolt.Tx, tableName string) (*bolt.Bucket, error) {
+	bucket := tx.Bucket([]byte(tableName))
+	if bucket == nil {
+		return nil, errors.New("no such table: " + tableName)
+	}
+	return bucket, nil
+}
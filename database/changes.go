@@ -0,0 +1,29 @@
+package database
+
+import "sync"
+
+// ChangeHandler - is told about a record written to or deleted from a table, the value is empty for a deleted record
+// and the key is empty when every record of the table was deleted
+type ChangeHandler func(tableName string, key string, value string)
+
+var changeHandlers struct {
+	mutex    sync.RWMutex
+	handlers []ChangeHandler
+}
+
+// OnChange - adds a handler that is told about every change made through this package, after the change is stored,
+// the handlers run on the goroutine making the change so they should be quick
+func OnChange(handler ChangeHandler) {
+	changeHandlers.mutex.Lock()
+	defer changeHandlers.mutex.Unlock()
+	changeHandlers.handlers = append(changeHandlers.handlers, handler)
+}
+
+func notifyChange(tableName string, key string, value string) {
+	changeHandlers.mutex.RLock()
+	handlers := changeHandlers.handlers
+	changeHandlers.mutex.RUnlock()
+	for _, handler := range handlers {
+		handler(tableName, key, value)
+	}
+}
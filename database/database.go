@@ -39,9 +39,24 @@ const SERVERCONF_TABLE_NAME = "serverconf"
 // DATABASE_FILENAME - database file name
 const DATABASE_FILENAME = "netmaker.db"
 
+// BOLT_FILENAME - bbolt database file name
+const BOLT_FILENAME = "netmaker.bolt"
+
 // GENERATED_TABLE_NAME - stores server generated k/v
 const GENERATED_TABLE_NAME = "generated"
 
+// OPERATIONS_TABLE_NAME - long running operations of the server
+const OPERATIONS_TABLE_NAME = "operations"
+
+// TEMPLATES_TABLE_NAME - network templates
+const TEMPLATES_TABLE_NAME = "templates"
+
+// LEASES_TABLE_NAME - leases the server instances hold, for leader election
+const LEASES_TABLE_NAME = "leases"
+
+// EVENT_LOG_TABLE_NAME - the log of changes read by the other server instances, kept by the stores that can be shared
+const EVENT_LOG_TABLE_NAME = "eventlog"
+
 // == ERROR CONSTS ==
 
 // NO_RECORD - no singular result found
@@ -50,50 +65,32 @@ const NO_RECORD = "no result found"
 // NO_RECORDS - no results found
 const NO_RECORDS = "could not find any records"
 
-// == Constants ==
-
-// INIT_DB - initialize db
-const INIT_DB = "init"
+// store - the database backend of the server, picked by the database setting on first use
+var store Store
 
-// CREATE_TABLE - create table const
-const CREATE_TABLE = "createtable"
-
-// INSERT - insert into db const
-const INSERT = "insert"
-
-// INSERT_PEER - insert peer into db const
-const INSERT_PEER = "insertpeer"
-
-// DELETE - delete db record const
-const DELETE = "delete"
-
-// DELETE_ALL - delete a table const
-const DELETE_ALL = "deleteall"
-
-// FETCH_ALL - fetch table contents const
-const FETCH_ALL = "fetchall"
+// SetStore - makes the server use a store instead of the configured database, unit tests use it to run on an in-memory store
+func SetStore(newStore Store) {
+	store = newStore
+}
 
-// CLOSE_DB - graceful close of db const
-const CLOSE_DB = "closedb"
+// GetStore - gets the store the server uses
+func GetStore() Store {
+	return getCurrentDB()
+}
 
-func getCurrentDB() map[string]interface{} {
-	switch servercfg.GetDB() {
-	case "rqlite":
-		return RQLITE_FUNCTIONS
-	case "sqlite":
-		return SQLITE_FUNCTIONS
-	case "postgres":
-		return PG_FUNCTIONS
-	default:
-		return SQLITE_FUNCTIONS
+func getCurrentDB() Store {
+	if store == nil {
+		store = NewStore(servercfg.GetDB())
 	}
+	return store
 }
 
+// InitializeDatabase - connects to the database and creates the tables
 func InitializeDatabase() error {
-	log.Println("[netmaker] connecting to", servercfg.GetDB())
+	log.Println("[netmaker] connecting to", getCurrentDB().Name())
 	tperiod := time.Now().Add(10 * time.Second)
 	for {
-		if err := getCurrentDB()[INIT_DB].(func() error)(); err != nil {
+		if err := getCurrentDB().Init(); err != nil {
 			log.Println("[netmaker] unable to connect to db, retrying . . .")
 			if time.Now().After(tperiod) {
 				return err
@@ -107,21 +104,36 @@ func InitializeDatabase() error {
 	return nil
 }
 
+// Tables - the tables of the server, in the order they are created
+func Tables() []string {
+	return []string{
+		NETWORKS_TABLE_NAME,
+		NODES_TABLE_NAME,
+		DELETED_NODES_TABLE_NAME,
+		USERS_TABLE_NAME,
+		DNS_TABLE_NAME,
+		EXT_CLIENT_TABLE_NAME,
+		INT_CLIENTS_TABLE_NAME,
+		PEERS_TABLE_NAME,
+		SERVERCONF_TABLE_NAME,
+		GENERATED_TABLE_NAME,
+		OPERATIONS_TABLE_NAME,
+		TEMPLATES_TABLE_NAME,
+		LEASES_TABLE_NAME,
+	}
+}
+
 func createTables() {
-	createTable(NETWORKS_TABLE_NAME)
-	createTable(NODES_TABLE_NAME)
-	createTable(DELETED_NODES_TABLE_NAME)
-	createTable(USERS_TABLE_NAME)
-	createTable(DNS_TABLE_NAME)
-	createTable(EXT_CLIENT_TABLE_NAME)
-	createTable(INT_CLIENTS_TABLE_NAME)
-	createTable(PEERS_TABLE_NAME)
-	createTable(SERVERCONF_TABLE_NAME)
-	createTable(GENERATED_TABLE_NAME)
+	for _, table := range Tables() {
+		createTable(table)
+	}
+	if eventLogStore, ok := getCurrentDB().(EventLogStore); ok {
+		eventLogStore.CreateEventLog()
+	}
 }
 
 func createTable(tableName string) error {
-	return getCurrentDB()[CREATE_TABLE].(func(string) error)(tableName)
+	return getCurrentDB().CreateTable(tableName)
 }
 
 // IsJSONString - checks if valid json
@@ -133,7 +145,11 @@ func IsJSONString(value string) bool {
 // Insert - inserts object into db
 func Insert(key string, value string, tableName string) error {
 	if key != "" && value != "" && IsJSONString(value) {
-		return getCurrentDB()[INSERT].(func(string, string, string) error)(key, value, tableName)
+		if err := getCurrentDB().Insert(key, value, tableName); err != nil {
+			return err
+		}
+		notifyChange(tableName, key, value)
+		return nil
 	} else {
 		return errors.New("invalid insert " + key + " : " + value)
 	}
@@ -142,20 +158,38 @@ func Insert(key string, value string, tableName string) error {
 // InsertPeer - inserts peer into db
 func InsertPeer(key string, value string) error {
 	if key != "" && value != "" && IsJSONString(value) {
-		return getCurrentDB()[INSERT_PEER].(func(string, string) error)(key, value)
+		if err := getCurrentDB().Insert(key, value, PEERS_TABLE_NAME); err != nil {
+			return err
+		}
+		notifyChange(PEERS_TABLE_NAME, key, value)
+		return nil
 	} else {
 		return errors.New("invalid peer insert " + key + " : " + value)
 	}
 }
 
+// CompareAndSwap - replaces a record only while it still has the old value, an empty old value inserts a record that does not exist yet,
+// returns false when the record was changed in between
+func CompareAndSwap(key string, oldValue string, newValue string, tableName string) (bool, error) {
+	swapped, err := getCurrentDB().CompareAndSwap(key, oldValue, newValue, tableName)
+	if swapped {
+		notifyChange(tableName, key, newValue)
+	}
+	return swapped, err
+}
+
 // DeleteRecord - deletes a record from db
 func DeleteRecord(tableName string, key string) error {
-	return getCurrentDB()[DELETE].(func(string, string) error)(tableName, key)
+	if err := getCurrentDB().DeleteRecord(tableName, key); err != nil {
+		return err
+	}
+	notifyChange(tableName, key, "")
+	return nil
 }
 
 // DeleteAllRecords - removes a table and remakes
 func DeleteAllRecords(tableName string) error {
-	err := getCurrentDB()[DELETE_ALL].(func(string) error)(tableName)
+	err := getCurrentDB().DeleteAllRecords(tableName)
 	if err != nil {
 		return err
 	}
@@ -163,6 +197,7 @@ func DeleteAllRecords(tableName string) error {
 	if err != nil {
 		return err
 	}
+	notifyChange(tableName, "", "")
 	return nil
 }
 
@@ -180,10 +215,76 @@ func FetchRecord(tableName string, key string) (string, error) {
 
 // FetchRecords - fetches all records in given table
 func FetchRecords(tableName string) (map[string]string, error) {
-	return getCurrentDB()[FETCH_ALL].(func(string) (map[string]string, error))(tableName)
+	return getCurrentDB().FetchRecords(tableName)
+}
+
+// CanBackup - checks if the current db can take snapshots of itself into a file
+func CanBackup() bool {
+	_, ok := getCurrentDB().(SnapshotStore)
+	return ok
+}
+
+// Backup - takes a consistent snapshot of the db into a file
+func Backup(path string) error {
+	snapshotStore, ok := getCurrentDB().(SnapshotStore)
+	if !ok {
+		return errors.New("the " + getCurrentDB().Name() + " database can not take snapshots")
+	}
+	return snapshotStore.Backup(path)
+}
+
+// Restore - replaces the contents of the db with a snapshot taken by Backup
+func Restore(path string) error {
+	snapshotStore, ok := getCurrentDB().(SnapshotStore)
+	if !ok {
+		return errors.New("the " + getCurrentDB().Name() + " database can not restore snapshots")
+	}
+	return snapshotStore.Restore(path)
+}
+
+// HasEventLog - checks if the current db keeps a log of events for the server instances sharing it
+func HasEventLog() bool {
+	_, ok := getCurrentDB().(EventLogStore)
+	return ok
+}
+
+// AppendEvent - appends an entry to the event log of the db
+func AppendEvent(value string, time int64) error {
+	eventLogStore, ok := getCurrentDB().(EventLogStore)
+	if !ok {
+		return errors.New("the " + getCurrentDB().Name() + " database has no event log")
+	}
+	return eventLogStore.AppendEvent(value, time)
+}
+
+// FetchEventsSince - fetches the entries of the event log numbered after a sequence, lowest first
+func FetchEventsSince(sequence int64) ([]EventEntry, error) {
+	eventLogStore, ok := getCurrentDB().(EventLogStore)
+	if !ok {
+		return nil, errors.New("the " + getCurrentDB().Name() + " database has no event log")
+	}
+	return eventLogStore.FetchEventsSince(sequence)
+}
+
+// LastEventSequence - the number of the newest entry of the event log, 0 for an empty log
+func LastEventSequence() (int64, error) {
+	eventLogStore, ok := getCurrentDB().(EventLogStore)
+	if !ok {
+		return 0, errors.New("the " + getCurrentDB().Name() + " database has no event log")
+	}
+	return eventLogStore.LastEventSequence()
+}
+
+// PruneEventLog - removes the entries of the event log appended before a time
+func PruneEventLog(before int64) error {
+	eventLogStore, ok := getCurrentDB().(EventLogStore)
+	if !ok {
+		return errors.New("the " + getCurrentDB().Name() + " database has no event log")
+	}
+	return eventLogStore.PruneEventLog(before)
 }
 
 // CloseDB - closes a database gracefully
 func CloseDB() {
-	getCurrentDB()[CLOSE_DB].(func())()
+	getCurrentDB().Close()
 }
@@ -9,19 +9,13 @@ import (
 	_ "github.com/lib/pq"
 )
 
-// PGDB - database object for PostGreSQL
-var PGDB *sql.DB
+// pgStore - a PostGreSQL database
+type pgStore struct {
+	db *sql.DB
+}
 
-// PG_FUNCTIONS - map of db functions for PostGreSQL
-var PG_FUNCTIONS = map[string]interface{}{
-	INIT_DB:      initPGDB,
-	CREATE_TABLE: pgCreateTable,
-	INSERT:       pgInsert,
-	INSERT_PEER:  pgInsertPeer,
-	DELETE:       pgDeleteRecord,
-	DELETE_ALL:   pgDeleteAllRecords,
-	FETCH_ALL:    pgFetchRecords,
-	CLOSE_DB:     pgCloseDB,
+func (store *pgStore) Name() string {
+	return "postgres"
 }
 
 func getPGConnString() string {
@@ -32,20 +26,20 @@ func getPGConnString() string {
 	return pgConn
 }
 
-func initPGDB() error {
+func (store *pgStore) Init() error {
 	connString := getPGConnString()
 	var dbOpenErr error
-	PGDB, dbOpenErr = sql.Open("postgres", connString)
+	store.db, dbOpenErr = sql.Open("postgres", connString)
 	if dbOpenErr != nil {
 		return dbOpenErr
 	}
-	dbOpenErr = PGDB.Ping()
+	dbOpenErr = store.db.Ping()
 
 	return dbOpenErr
 }
 
-func pgCreateTable(tableName string) error {
-	statement, err := PGDB.Prepare("CREATE TABLE IF NOT EXISTS " + tableName + " (key TEXT NOT NULL UNIQUE PRIMARY KEY, value TEXT)")
+func (store *pgStore) CreateTable(tableName string) error {
+	statement, err := store.db.Prepare("CREATE TABLE IF NOT EXISTS " + tableName + " (key TEXT NOT NULL UNIQUE PRIMARY KEY, value TEXT)")
 	if err != nil {
 		return err
 	}
@@ -56,10 +50,10 @@ func pgCreateTable(tableName string) error {
 	return nil
 }
 
-func pgInsert(key string, value string, tableName string) error {
+func (store *pgStore) Insert(key string, value string, tableName string) error {
 	if key != "" && value != "" && IsJSONString(value) {
 		insertSQL := "INSERT INTO " + tableName + " (key, value) VALUES ($1, $2) ON CONFLICT (key) DO UPDATE SET value = $3;"
-		statement, err := PGDB.Prepare(insertSQL)
+		statement, err := store.db.Prepare(insertSQL)
 		if err != nil {
 			return err
 		}
@@ -73,21 +67,27 @@ func pgInsert(key string, value string, tableName string) error {
 	}
 }
 
-func pgInsertPeer(key string, value string) error {
-	if key != "" && value != "" && IsJSONString(value) {
-		err := pgInsert(key, value, PEERS_TABLE_NAME)
-		if err != nil {
-			return err
-		}
-		return nil
+func (store *pgStore) CompareAndSwap(key string, oldValue string, newValue string, tableName string) (bool, error) {
+	if key == "" || newValue == "" || !IsJSONString(newValue) {
+		return false, errors.New("invalid insert " + key + " : " + newValue)
+	}
+	var result sql.Result
+	var err error
+	if oldValue == "" {
+		result, err = store.db.Exec("INSERT INTO "+tableName+" (key, value) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING;", key, newValue)
 	} else {
-		return errors.New("invalid peer insert " + key + " : " + value)
+		result, err = store.db.Exec("UPDATE "+tableName+" SET value = $1 WHERE key = $2 AND value = $3;", newValue, key, oldValue)
+	}
+	if err != nil {
+		return false, err
 	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
 }
 
-func pgDeleteRecord(tableName string, key string) error {
+func (store *pgStore) DeleteRecord(tableName string, key string) error {
 	deleteSQL := "DELETE FROM " + tableName + " WHERE key = $1;"
-	statement, err := PGDB.Prepare(deleteSQL)
+	statement, err := store.db.Prepare(deleteSQL)
 	if err != nil {
 		return err
 	}
@@ -97,9 +97,9 @@ func pgDeleteRecord(tableName string, key string) error {
 	return nil
 }
 
-func pgDeleteAllRecords(tableName string) error {
+func (store *pgStore) DeleteAllRecords(tableName string) error {
 	deleteSQL := "DELETE FROM " + tableName
-	statement, err := PGDB.Prepare(deleteSQL)
+	statement, err := store.db.Prepare(deleteSQL)
 	if err != nil {
 		return err
 	}
@@ -109,8 +109,8 @@ func pgDeleteAllRecords(tableName string) error {
 	return nil
 }
 
-func pgFetchRecords(tableName string) (map[string]string, error) {
-	row, err := PGDB.Query("SELECT * FROM " + tableName + " ORDER BY key")
+func (store *pgStore) FetchRecords(tableName string) (map[string]string, error) {
+	row, err := store.db.Query("SELECT * FROM " + tableName + " ORDER BY key")
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +128,46 @@ func pgFetchRecords(tableName string) (map[string]string, error) {
 	return records, nil
 }
 
-func pgCloseDB() {
-	PGDB.Close()
+func (store *pgStore) Close() {
+	store.db.Close()
+}
+
+// pgStore.CreateEventLog - the log numbers its entries with a sequence, an entry whose transaction commits late can show up
+// after entries with higher numbers, readers wait a while for the numbers they skipped
+func (store *pgStore) CreateEventLog() error {
+	_, err := store.db.Exec("CREATE TABLE IF NOT EXISTS " + EVENT_LOG_TABLE_NAME + " (sequence BIGSERIAL PRIMARY KEY, time BIGINT NOT NULL, value TEXT NOT NULL)")
+	return err
+}
+
+func (store *pgStore) AppendEvent(value string, time int64) error {
+	_, err := store.db.Exec("INSERT INTO "+EVENT_LOG_TABLE_NAME+" (time, value) VALUES ($1, $2)", time, value)
+	return err
+}
+
+func (store *pgStore) FetchEventsSince(sequence int64) ([]EventEntry, error) {
+	rows, err := store.db.Query("SELECT sequence, time, value FROM "+EVENT_LOG_TABLE_NAME+" WHERE sequence > $1 ORDER BY sequence", sequence)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []EventEntry
+	for rows.Next() {
+		var entry EventEntry
+		if err = rows.Scan(&entry.Sequence, &entry.Time, &entry.Value); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (store *pgStore) LastEventSequence() (int64, error) {
+	var sequence int64
+	err := store.db.QueryRow("SELECT COALESCE(MAX(sequence), 0) FROM " + EVENT_LOG_TABLE_NAME).Scan(&sequence)
+	return sequence, err
+}
+
+func (store *pgStore) PruneEventLog(before int64) error {
+	_, err := store.db.Exec("DELETE FROM "+EVENT_LOG_TABLE_NAME+" WHERE time < $1", before)
+	return err
 }
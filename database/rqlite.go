@@ -1,49 +1,49 @@
 package database
 
 import (
+	"bytes"
 	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/gravitl/netmaker/servercfg"
 	"github.com/rqlite/gorqlite"
 )
 
-// RQliteDatabase - the rqlite db connection
-var RQliteDatabase gorqlite.Connection
+// rqliteStore - an rqlite cluster
+type rqliteStore struct {
+	conn gorqlite.Connection
+}
 
-// RQLITE_FUNCTIONS - all the functions to run with rqlite
-var RQLITE_FUNCTIONS = map[string]interface{}{
-	INIT_DB:      initRqliteDatabase,
-	CREATE_TABLE: rqliteCreateTable,
-	INSERT:       rqliteInsert,
-	INSERT_PEER:  rqliteInsertPeer,
-	DELETE:       rqliteDeleteRecord,
-	DELETE_ALL:   rqliteDeleteAllRecords,
-	FETCH_ALL:    rqliteFetchRecords,
-	CLOSE_DB:     rqliteCloseDB,
+func (store *rqliteStore) Name() string {
+	return "rqlite"
 }
 
-func initRqliteDatabase() error {
+func (store *rqliteStore) Init() error {
 
 	conn, err := gorqlite.Open(servercfg.GetSQLConn())
 	if err != nil {
 		return err
 	}
-	RQliteDatabase = conn
-	RQliteDatabase.SetConsistencyLevel("strong")
+	store.conn = conn
+	store.conn.SetConsistencyLevel("strong")
 	return nil
 }
 
-func rqliteCreateTable(tableName string) error {
-	_, err := RQliteDatabase.WriteOne("CREATE TABLE IF NOT EXISTS " + tableName + " (key TEXT NOT NULL UNIQUE PRIMARY KEY, value TEXT)")
+func (store *rqliteStore) CreateTable(tableName string) error {
+	_, err := store.conn.WriteOne("CREATE TABLE IF NOT EXISTS " + tableName + " (key TEXT NOT NULL UNIQUE PRIMARY KEY, value TEXT)")
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func rqliteInsert(key string, value string, tableName string) error {
+func (store *rqliteStore) Insert(key string, value string, tableName string) error {
 	if key != "" && value != "" && IsJSONString(value) {
-		_, err := RQliteDatabase.WriteOne("INSERT OR REPLACE INTO " + tableName + " (key, value) VALUES ('" + key + "', '" + value + "')")
+		_, err := store.conn.WriteOne("INSERT OR REPLACE INTO " + tableName + " (key, value) VALUES ('" + key + "', '" + value + "')")
 		if err != nil {
 			return err
 		}
@@ -52,50 +52,39 @@ func rqliteInsert(key string, value string, tableName string) error {
 	return errors.New("invalid insert " + key + " : " + value)
 }
 
-func rqliteInsertPeer(key string, value string) error {
-	if key != "" && value != "" && IsJSONString(value) {
-		_, err := RQliteDatabase.WriteOne("INSERT OR REPLACE INTO " + PEERS_TABLE_NAME + " (key, value) VALUES ('" + key + "', '" + value + "')")
-		if err != nil {
-			return err
-		}
-		return nil
+func (store *rqliteStore) CompareAndSwap(key string, oldValue string, newValue string, tableName string) (bool, error) {
+	if key == "" || newValue == "" || !IsJSONString(newValue) {
+		return false, errors.New("invalid insert " + key + " : " + newValue)
 	}
-	return errors.New("invalid peer insert " + key + " : " + value)
-}
-
-func rqliteDeleteRecord(tableName string, key string) error {
-	_, err := RQliteDatabase.WriteOne("DELETE FROM " + tableName + " WHERE key = \"" + key + "\"")
+	statement := "INSERT OR IGNORE INTO " + tableName + " (key, value) VALUES (" + rqliteQuote(key) + ", " + rqliteQuote(newValue) + ")"
+	if oldValue != "" {
+		statement = "UPDATE " + tableName + " SET value = " + rqliteQuote(newValue) + " WHERE key = " + rqliteQuote(key) + " AND value = " + rqliteQuote(oldValue)
+	}
+	result, err := store.conn.WriteOne(statement)
 	if err != nil {
-		return err
+		return false, err
 	}
-	return nil
+	return result.RowsAffected == 1, nil
 }
 
-func rqliteDeleteAllRecords(tableName string) error {
-	_, err := RQliteDatabase.WriteOne("DELETE TABLE " + tableName)
-	if err != nil {
-		return err
-	}
-	err = rqliteCreateTable(tableName)
+func (store *rqliteStore) DeleteRecord(tableName string, key string) error {
+	_, err := store.conn.WriteOne("DELETE FROM " + tableName + " WHERE key = \"" + key + "\"")
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func rqliteFetchRecord(tableName string, key string) (string, error) {
-	results, err := FetchRecords(tableName)
+func (store *rqliteStore) DeleteAllRecords(tableName string) error {
+	_, err := store.conn.WriteOne("DELETE FROM " + tableName)
 	if err != nil {
-		return "", err
-	}
-	if results[key] == "" {
-		return "", errors.New(NO_RECORD)
+		return err
 	}
-	return results[key], nil
+	return nil
 }
 
-func rqliteFetchRecords(tableName string) (map[string]string, error) {
-	row, err := RQliteDatabase.QueryOne("SELECT * FROM " + tableName + " ORDER BY key")
+func (store *rqliteStore) FetchRecords(tableName string) (map[string]string, error) {
+	row, err := store.conn.QueryOne("SELECT * FROM " + tableName + " ORDER BY key")
 	if err != nil {
 		return nil, err
 	}
@@ -112,6 +101,110 @@ func rqliteFetchRecords(tableName string) (map[string]string, error) {
 	return records, nil
 }
 
-func rqliteCloseDB() {
-	RQliteDatabase.Close()
+func (store *rqliteStore) Close() {
+	store.conn.Close()
+}
+
+// rqliteStore.CreateEventLog - the writes of the cluster go through its leader one at a time, so the entries are numbered in the order
+// they commit, AUTOINCREMENT keeps the numbers of pruned entries from being used again
+func (store *rqliteStore) CreateEventLog() error {
+	_, err := store.conn.WriteOne("CREATE TABLE IF NOT EXISTS " + EVENT_LOG_TABLE_NAME + " (sequence INTEGER PRIMARY KEY AUTOINCREMENT, time INTEGER NOT NULL, value TEXT NOT NULL)")
+	return err
+}
+
+func (store *rqliteStore) AppendEvent(value string, time int64) error {
+	_, err := store.conn.WriteOne("INSERT INTO " + EVENT_LOG_TABLE_NAME + " (time, value) VALUES (" + strconv.FormatInt(time, 10) + ", " + rqliteQuote(value) + ")")
+	return err
+}
+
+func (store *rqliteStore) FetchEventsSince(sequence int64) ([]EventEntry, error) {
+	rows, err := store.conn.QueryOne("SELECT sequence, time, value FROM " + EVENT_LOG_TABLE_NAME + " WHERE sequence > " + strconv.FormatInt(sequence, 10) + " ORDER BY sequence")
+	if err != nil {
+		return nil, err
+	}
+	var entries []EventEntry
+	for rows.Next() {
+		var entry EventEntry
+		if err = rows.Scan(&entry.Sequence, &entry.Time, &entry.Value); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (store *rqliteStore) LastEventSequence() (int64, error) {
+	rows, err := store.conn.QueryOne("SELECT COALESCE(MAX(sequence), 0) FROM " + EVENT_LOG_TABLE_NAME)
+	if err != nil {
+		return 0, err
+	}
+	var sequence int64
+	if rows.Next() {
+		err = rows.Scan(&sequence)
+	}
+	return sequence, err
+}
+
+func (store *rqliteStore) PruneEventLog(before int64) error {
+	_, err := store.conn.WriteOne("DELETE FROM " + EVENT_LOG_TABLE_NAME + " WHERE time < " + strconv.FormatInt(before, 10))
+	return err
+}
+
+// rqliteStore.Backup - downloads a sqlite snapshot of the cluster from the backup endpoint of rqlite
+func (store *rqliteStore) Backup(path string) error {
+	endpoint, err := rqliteEndpoint("/db/backup")
+	if err != nil {
+		return err
+	}
+	response, err := http.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return errors.New("rqlite backup failed with status " + response.Status + ": " + string(data))
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// rqliteStore.Restore - loads a sqlite snapshot into the cluster with the load endpoint of rqlite
+func (store *rqliteStore) Restore(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	endpoint, err := rqliteEndpoint("/db/load")
+	if err != nil {
+		return err
+	}
+	response, err := http.Post(endpoint, "application/octet-stream", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(response.Body)
+		return errors.New("rqlite restore failed with status " + response.Status + ": " + string(body))
+	}
+	return nil
+}
+
+// rqliteEndpoint - an endpoint of the http api of the rqlite node the server is connected to, credentials in the connection string are kept
+func rqliteEndpoint(path string) (string, error) {
+	endpoint, err := url.Parse(servercfg.GetSQLConn())
+	if err != nil {
+		return "", err
+	}
+	endpoint.Path = path
+	endpoint.RawQuery = ""
+	return endpoint.String(), nil
+}
+
+// rqliteQuote - quotes a value for a statement, rqlite takes statements as text
+func rqliteQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
 }
@@ -9,44 +9,40 @@ import (
 	_ "github.com/mattn/go-sqlite3" // need to blank import this package
 )
 
-// == sqlite ==
-const dbFilename = "netmaker.db"
+// sqliteStore - a sqlite database in a file
+type sqliteStore struct {
+	path string
+	db   *sql.DB
+}
 
-// SqliteDB is the db object fro sqlite database connections
-var SqliteDB *sql.DB
+// NewSqliteStore - makes the store of a sqlite database file, the file is created on init when it does not exist
+func NewSqliteStore(path string) SnapshotStore {
+	return &sqliteStore{path: path}
+}
 
-// SQLITE_FUNCTIONS - contains a map of the functions for sqlite
-var SQLITE_FUNCTIONS = map[string]interface{}{
-	INIT_DB:      initSqliteDB,
-	CREATE_TABLE: sqliteCreateTable,
-	INSERT:       sqliteInsert,
-	INSERT_PEER:  sqliteInsertPeer,
-	DELETE:       sqliteDeleteRecord,
-	DELETE_ALL:   sqliteDeleteAllRecords,
-	FETCH_ALL:    sqliteFetchRecords,
-	CLOSE_DB:     sqliteCloseDB,
+func (store *sqliteStore) Name() string {
+	return "sqlite"
 }
 
-func initSqliteDB() error {
+func (store *sqliteStore) Init() error {
 	// == create db file if not present ==
-	if _, err := os.Stat("data"); os.IsNotExist(err) {
-		os.Mkdir("data", 0744)
+	if _, err := os.Stat(filepath.Dir(store.path)); os.IsNotExist(err) {
+		os.MkdirAll(filepath.Dir(store.path), 0744)
 	}
-	dbFilePath := filepath.Join("data", dbFilename)
-	if _, err := os.Stat(dbFilePath); os.IsNotExist(err) {
-		os.Create(dbFilePath)
+	if _, err := os.Stat(store.path); os.IsNotExist(err) {
+		os.Create(store.path)
 	}
 	// == "connect" the database ==
 	var dbOpenErr error
-	SqliteDB, dbOpenErr = sql.Open("sqlite3", dbFilePath)
+	store.db, dbOpenErr = sql.Open("sqlite3", store.path)
 	if dbOpenErr != nil {
 		return dbOpenErr
 	}
 	return nil
 }
 
-func sqliteCreateTable(tableName string) error {
-	statement, err := SqliteDB.Prepare("CREATE TABLE IF NOT EXISTS " + tableName + " (key TEXT NOT NULL UNIQUE PRIMARY KEY, value TEXT)")
+func (store *sqliteStore) CreateTable(tableName string) error {
+	statement, err := store.db.Prepare("CREATE TABLE IF NOT EXISTS " + tableName + " (key TEXT NOT NULL UNIQUE PRIMARY KEY, value TEXT)")
 	if err != nil {
 		return err
 	}
@@ -57,10 +53,10 @@ func sqliteCreateTable(tableName string) error {
 	return nil
 }
 
-func sqliteInsert(key string, value string, tableName string) error {
+func (store *sqliteStore) Insert(key string, value string, tableName string) error {
 	if key != "" && value != "" && IsJSONString(value) {
 		insertSQL := "INSERT OR REPLACE INTO " + tableName + " (key, value) VALUES (?, ?)"
-		statement, err := SqliteDB.Prepare(insertSQL)
+		statement, err := store.db.Prepare(insertSQL)
 		if err != nil {
 			return err
 		}
@@ -73,20 +69,27 @@ func sqliteInsert(key string, value string, tableName string) error {
 	return errors.New("invalid insert " + key + " : " + value)
 }
 
-func sqliteInsertPeer(key string, value string) error {
-	if key != "" && value != "" && IsJSONString(value) {
-		err := sqliteInsert(key, value, PEERS_TABLE_NAME)
-		if err != nil {
-			return err
-		}
-		return nil
+func (store *sqliteStore) CompareAndSwap(key string, oldValue string, newValue string, tableName string) (bool, error) {
+	if key == "" || newValue == "" || !IsJSONString(newValue) {
+		return false, errors.New("invalid insert " + key + " : " + newValue)
+	}
+	var result sql.Result
+	var err error
+	if oldValue == "" {
+		result, err = store.db.Exec("INSERT OR IGNORE INTO "+tableName+" (key, value) VALUES (?, ?)", key, newValue)
+	} else {
+		result, err = store.db.Exec("UPDATE "+tableName+" SET value = ? WHERE key = ? AND value = ?", newValue, key, oldValue)
 	}
-	return errors.New("invalid peer insert " + key + " : " + value)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
 }
 
-func sqliteDeleteRecord(tableName string, key string) error {
+func (store *sqliteStore) DeleteRecord(tableName string, key string) error {
 	deleteSQL := "DELETE FROM " + tableName + " WHERE key = \"" + key + "\""
-	statement, err := SqliteDB.Prepare(deleteSQL)
+	statement, err := store.db.Prepare(deleteSQL)
 	if err != nil {
 		return err
 	}
@@ -96,9 +99,9 @@ func sqliteDeleteRecord(tableName string, key string) error {
 	return nil
 }
 
-func sqliteDeleteAllRecords(tableName string) error {
+func (store *sqliteStore) DeleteAllRecords(tableName string) error {
 	deleteSQL := "DELETE FROM " + tableName
-	statement, err := SqliteDB.Prepare(deleteSQL)
+	statement, err := store.db.Prepare(deleteSQL)
 	if err != nil {
 		return err
 	}
@@ -108,8 +111,8 @@ func sqliteDeleteAllRecords(tableName string) error {
 	return nil
 }
 
-func sqliteFetchRecords(tableName string) (map[string]string, error) {
-	row, err := SqliteDB.Query("SELECT * FROM " + tableName + " ORDER BY key")
+func (store *sqliteStore) FetchRecords(tableName string) (map[string]string, error) {
+	row, err := store.db.Query("SELECT * FROM " + tableName + " ORDER BY key")
 	if err != nil {
 		return nil, err
 	}
@@ -127,6 +130,27 @@ func sqliteFetchRecords(tableName string) (map[string]string, error) {
 	return records, nil
 }
 
-func sqliteCloseDB() {
-	SqliteDB.Close()
+func (store *sqliteStore) Close() {
+	store.db.Close()
+}
+
+func (store *sqliteStore) Backup(path string) error {
+	dest, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+	return sqliteCopy(store.db, dest)
+}
+
+func (store *sqliteStore) Restore(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	source, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	return sqliteCopy(source, store.db)
 }
@@ -0,0 +1,45 @@
+//go:build cgo
+// +build cgo
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteCopy - copies a whole database with the online backup api of sqlite, the copy is consistent while the source is in use
+func sqliteCopy(source *sql.DB, dest *sql.DB) error {
+	ctx := context.Background()
+	sourceConn, err := source.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer sourceConn.Close()
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	return destConn.Raw(func(destDriver interface{}) error {
+		return sourceConn.Raw(func(sourceDriver interface{}) error {
+			destSqlite, ok := destDriver.(*sqlite3.SQLiteConn)
+			sourceSqlite, sourceOk := sourceDriver.(*sqlite3.SQLiteConn)
+			if !ok || !sourceOk {
+				return errors.New("not a sqlite connection")
+			}
+			backup, err := destSqlite.Backup("main", sourceSqlite, "main")
+			if err != nil {
+				return err
+			}
+			if _, err = backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
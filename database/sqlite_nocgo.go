@@ -0,0 +1,14 @@
+//go:build !cgo
+// +build !cgo
+
+package database
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// sqliteCopy - the online backup api of sqlite needs cgo, like the rest of sqlite
+func sqliteCopy(source *sql.DB, dest *sql.DB) error {
+	return errors.New("sqlite snapshots need a server built with cgo, use bbolt for builds without cgo")
+}
@@ -0,0 +1,246 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+)
+
+// Store - a database backend, every table keeps json values under string keys
+type Store interface {
+	// Name - the name of the database, as set in the database setting
+	Name() string
+	// Init - connects to the database
+	Init() error
+	// CreateTable - creates a table when it does not exist yet
+	CreateTable(tableName string) error
+	// Insert - inserts a record or replaces the record with the same key
+	Insert(key string, value string, tableName string) error
+	// CompareAndSwap - replaces the value of a record only while it still has the old value, an empty old value only inserts
+	// a record that does not exist yet, returns false when the record was changed by someone else
+	CompareAndSwap(key string, oldValue string, newValue string, tableName string) (bool, error)
+	// DeleteRecord - deletes a record of a table
+	DeleteRecord(tableName string, key string) error
+	// DeleteAllRecords - deletes every record of a table
+	DeleteAllRecords(tableName string) error
+	// FetchRecords - fetches every record of a table, an empty table gives a NO_RECORDS error
+	FetchRecords(tableName string) (map[string]string, error)
+	// Close - closes the connection to the database
+	Close()
+}
+
+// SnapshotStore - a store that can take consistent snapshots of itself into a file of its own format and restore them
+type SnapshotStore interface {
+	Store
+	// Backup - writes a snapshot of the database to a file
+	Backup(path string) error
+	// Restore - replaces the contents of the database with a snapshot
+	Restore(path string) error
+}
+
+// EventLogStore - a store that keeps a log of events for the server instances sharing it, the database numbers the entries
+// in the order they are appended so an instance only reads the entries after the last one it has read
+type EventLogStore interface {
+	Store
+	// CreateEventLog - creates the log when it does not exist yet
+	CreateEventLog() error
+	// AppendEvent - appends an entry to the log, the time is used for pruning
+	AppendEvent(value string, time int64) error
+	// FetchEventsSince - fetches the entries numbered after a sequence, lowest first
+	FetchEventsSince(sequence int64) ([]EventEntry, error)
+	// LastEventSequence - the number of the newest entry, 0 for an empty log
+	LastEventSequence() (int64, error)
+	// PruneEventLog - removes the entries appended before a time
+	PruneEventLog(before int64) error
+}
+
+// EventEntry - an entry of the event log
+type EventEntry struct {
+	Sequence int64
+	Time     int64
+	Value    string
+}
+
+// NewStore - makes the store of a database type, sqlite for an unknown type
+func NewStore(database string) Store {
+	switch database {
+	case "rqlite":
+		return &rqliteStore{}
+	case "postgres":
+		return &pgStore{}
+	case "bbolt":
+		return NewBoltStore(filepath.Join("data", BOLT_FILENAME))
+	default:
+		return NewSqliteStore(filepath.Join("data", DATABASE_FILENAME))
+	}
+}
+
+// CopyStore - copies the records of the tables of the server from one store into another, records with the same key are overwritten,
+// returns how many records of each table were copied
+func CopyStore(source Store, dest Store) (map[string]int, error) {
+	copied := make(map[string]int)
+	for _, table := range Tables() {
+		if err := dest.CreateTable(table); err != nil {
+			return copied, err
+		}
+		records, err := source.FetchRecords(table)
+		if err != nil {
+			if IsEmptyRecord(err) {
+				continue
+			}
+			return copied, errors.New("could not read table " + table + ": " + err.Error())
+		}
+		for key, value := range records {
+			if err = dest.Insert(key, value, table); err != nil {
+				return copied, errors.New("could not copy " + key + " of table " + table + ": " + err.Error())
+			}
+			copied[table]++
+		}
+	}
+	return copied, nil
+}
+
+// memoryStore - keeps the tables in memory, nothing is written to disk
+type memoryStore struct {
+	mutex        sync.RWMutex
+	tables       map[string]map[string]string
+	events       []EventEntry
+	lastSequence int64
+}
+
+// NewMemoryStore - makes an empty store that keeps its tables in memory, for unit tests
+func NewMemoryStore() Store {
+	return &memoryStore{tables: make(map[string]map[string]string)}
+}
+
+func (store *memoryStore) Name() string {
+	return "memory"
+}
+
+func (store *memoryStore) Init() error {
+	return nil
+}
+
+func (store *memoryStore) CreateTable(tableName string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if _, ok := store.tables[tableName]; !ok {
+		store.tables[tableName] = make(map[string]string)
+	}
+	return nil
+}
+
+func (store *memoryStore) Insert(key string, value string, tableName string) error {
+	if key == "" || value == "" || !IsJSONString(value) {
+		return errors.New("invalid insert " + key + " : " + value)
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	table, ok := store.tables[tableName]
+	if !ok {
+		return errors.New("no such table: " + tableName)
+	}
+	table[key] = value
+	return nil
+}
+
+func (store *memoryStore) CompareAndSwap(key string, oldValue string, newValue string, tableName string) (bool, error) {
+	if key == "" || newValue == "" || !IsJSONString(newValue) {
+		return false, errors.New("invalid insert " + key + " : " + newValue)
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	table, ok := store.tables[tableName]
+	if !ok {
+		return false, errors.New("no such table: " + tableName)
+	}
+	if table[key] != oldValue {
+		return false, nil
+	}
+	table[key] = newValue
+	return true, nil
+}
+
+func (store *memoryStore) DeleteRecord(tableName string, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	table, ok := store.tables[tableName]
+	if !ok {
+		return errors.New("no such table: " + tableName)
+	}
+	delete(table, key)
+	return nil
+}
+
+func (store *memoryStore) DeleteAllRecords(tableName string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if _, ok := store.tables[tableName]; !ok {
+		return errors.New("no such table: " + tableName)
+	}
+	store.tables[tableName] = make(map[string]string)
+	return nil
+}
+
+func (store *memoryStore) FetchRecords(tableName string) (map[string]string, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	table, ok := store.tables[tableName]
+	if !ok {
+		return nil, errors.New("no such table: " + tableName)
+	}
+	if len(table) == 0 {
+		return nil, errors.New(NO_RECORDS)
+	}
+	// a copy, so the caller can not change the table
+	records := make(map[string]string, len(table))
+	for key, value := range table {
+		records[key] = value
+	}
+	return records, nil
+}
+
+func (store *memoryStore) Close() {}
+
+func (store *memoryStore) CreateEventLog() error {
+	return nil
+}
+
+func (store *memoryStore) AppendEvent(value string, time int64) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.lastSequence++
+	store.events = append(store.events, EventEntry{Sequence: store.lastSequence, Time: time, Value: value})
+	return nil
+}
+
+func (store *memoryStore) FetchEventsSince(sequence int64) ([]EventEntry, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	var entries []EventEntry
+	for _, entry := range store.events {
+		if entry.Sequence > sequence {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (store *memoryStore) LastEventSequence() (int64, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.lastSequence, nil
+}
+
+func (store *memoryStore) PruneEventLog(before int64) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	var kept []EventEntry
+	for _, entry := range store.events {
+		if entry.Time >= before {
+			kept = append(kept, entry)
+		}
+	}
+	store.events = kept
+	return nil
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netmaker-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	for _, store := range []Store{
+		NewMemoryStore(),
+		NewSqliteStore(filepath.Join(dir, "data", DATABASE_FILENAME)),
+		NewBoltStore(filepath.Join(dir, "data", BOLT_FILENAME)),
+	} {
+		t.Run(store.Name(), func(t *testing.T) {
+			testStore(t, store)
+		})
+	}
+}
+
+func testStore(t *testing.T, store Store) {
+	assert.Nil(t, store.Init())
+	defer store.Close()
+	assert.Nil(t, store.CreateTable(NETWORKS_TABLE_NAME))
+	assert.Nil(t, store.CreateTable(NETWORKS_TABLE_NAME))
+
+	_, err := store.FetchRecords(NETWORKS_TABLE_NAME)
+	assert.True(t, IsEmptyRecord(err))
+	_, err = store.FetchRecords("missing")
+	assert.NotNil(t, err)
+	assert.False(t, IsEmptyRecord(err))
+
+	assert.Nil(t, store.Insert("skynet", `{"netid":"skynet"}`, NETWORKS_TABLE_NAME))
+	assert.Nil(t, store.Insert("othernet", `{"netid":"othernet"}`, NETWORKS_TABLE_NAME))
+	assert.Nil(t, store.Insert("skynet", `{"netid":"skynet","displayname":"sky"}`, NETWORKS_TABLE_NAME))
+	assert.NotNil(t, store.Insert("badnet", "not json", NETWORKS_TABLE_NAME))
+	records, err := store.FetchRecords(NETWORKS_TABLE_NAME)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"skynet":   `{"netid":"skynet","displayname":"sky"}`,
+		"othernet": `{"netid":"othernet"}`,
+	}, records)
+
+	assert.Nil(t, store.DeleteRecord(NETWORKS_TABLE_NAME, "othernet"))
+	records, err = store.FetchRecords(NETWORKS_TABLE_NAME)
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+
+	swapped, err := store.CompareAndSwap("lease", "", `{"holder":"a"}`, NETWORKS_TABLE_NAME)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	swapped, err = store.CompareAndSwap("lease", "", `{"holder":"b"}`, NETWORKS_TABLE_NAME)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+	swapped, err = store.CompareAndSwap("lease", `{"holder":"b"}`, `{"holder":"c"}`, NETWORKS_TABLE_NAME)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+	swapped, err = store.CompareAndSwap("lease", `{"holder":"a"}`, `{"holder":"it's b"}`, NETWORKS_TABLE_NAME)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+	records, err = store.FetchRecords(NETWORKS_TABLE_NAME)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"holder":"it's b"}`, records["lease"])
+
+	assert.Nil(t, store.DeleteAllRecords(NETWORKS_TABLE_NAME))
+	_, err = store.FetchRecords(NETWORKS_TABLE_NAME)
+	assert.True(t, IsEmptyRecord(err))
+}
+
+func TestSnapshots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netmaker-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	for _, store := range []SnapshotStore{
+		NewSqliteStore(filepath.Join(dir, DATABASE_FILENAME)),
+		NewBoltStore(filepath.Join(dir, BOLT_FILENAME)),
+	} {
+		t.Run(store.Name(), func(t *testing.T) {
+			testSnapshot(t, store, filepath.Join(dir, store.Name()+"-snapshot.db"))
+		})
+	}
+}
+
+func testSnapshot(t *testing.T, store SnapshotStore, snapshot string) {
+	assert.Nil(t, store.Init())
+	defer store.Close()
+	assert.Nil(t, store.CreateTable(NETWORKS_TABLE_NAME))
+	assert.Nil(t, store.Insert("skynet", `{"netid":"skynet"}`, NETWORKS_TABLE_NAME))
+
+	assert.Nil(t, store.Backup(snapshot))
+	assert.Nil(t, store.Insert("othernet", `{"netid":"othernet"}`, NETWORKS_TABLE_NAME))
+	assert.Nil(t, store.Restore(snapshot))
+	records, err := store.FetchRecords(NETWORKS_TABLE_NAME)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"skynet": `{"netid":"skynet"}`}, records)
+
+	assert.NotNil(t, store.Restore(snapshot+".missing"))
+}
+
+func TestCopyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netmaker-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	source := NewSqliteStore(filepath.Join(dir, DATABASE_FILENAME))
+	assert.Nil(t, source.Init())
+	defer source.Close()
+	for _, table := range Tables() {
+		assert.Nil(t, source.CreateTable(table))
+	}
+	assert.Nil(t, source.Insert("skynet", `{"netid":"skynet"}`, NETWORKS_TABLE_NAME))
+	assert.Nil(t, source.Insert("skynet", `{"node":"peer"}`, PEERS_TABLE_NAME))
+	assert.Nil(t, source.Insert("admin", `{"username":"admin"}`, USERS_TABLE_NAME))
+
+	dest := NewBoltStore(filepath.Join(dir, BOLT_FILENAME))
+	assert.Nil(t, dest.Init())
+	defer dest.Close()
+	copied, err := CopyStore(source, dest)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{NETWORKS_TABLE_NAME: 1, PEERS_TABLE_NAME: 1, USERS_TABLE_NAME: 1}, copied)
+	for _, table := range Tables() {
+		sourceRecords, sourceErr := source.FetchRecords(table)
+		destRecords, destErr := dest.FetchRecords(table)
+		assert.Equal(t, sourceRecords, destRecords)
+		assert.Equal(t, IsEmptyRecord(sourceErr), IsEmptyRecord(destErr))
+	}
+}
+
+func TestEventLog(t *testing.T) {
+	store := NewMemoryStore().(EventLogStore)
+	assert.Nil(t, store.CreateEventLog())
+	sequence, err := store.LastEventSequence()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), sequence)
+	assert.Nil(t, store.AppendEvent(`{"id":"1"}`, 100))
+	assert.Nil(t, store.AppendEvent(`{"id":"2"}`, 200))
+	entries, err := store.FetchEventsSince(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []EventEntry{{Sequence: 2, Time: 200, Value: `{"id":"2"}`}}, entries)
+
+	assert.Nil(t, store.PruneEventLog(200))
+	assert.Nil(t, store.AppendEvent(`{"id":"3"}`, 300))
+	entries, err = store.FetchEventsSince(0)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{2, 3}, []int64{entries[0].Sequence, entries[1].Sequence})
+	sequence, err = store.LastEventSequence()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), sequence)
+}
+
+func TestSetStore(t *testing.T) {
+	defer SetStore(nil)
+	SetStore(NewMemoryStore())
+	assert.Nil(t, InitializeDatabase())
+	assert.False(t, CanBackup())
+	assert.NotNil(t, Backup("snapshot.db"))
+	for _, table := range Tables() {
+		_, err := FetchRecords(table)
+		assert.True(t, IsEmptyRecord(err))
+	}
+	assert.Nil(t, Insert("skynet", `{"netid":"skynet"}`, NETWORKS_TABLE_NAME))
+	record, err := FetchRecord(NETWORKS_TABLE_NAME, "skynet")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"netid":"skynet"}`, record)
+}
@@ -4,3 +4,6 @@ const STRING_TYPE = "string"
 const NODE_TYPE = "node"
 const EXT_PEER = "extpeer"
 const ACCESS_TOKEN = "accesstoken"
+
+// READ_ONLY - the metadata of a node read that must not count as a check in, as the reads of netclient status
+const READ_ONLY = "readonly"
@@ -0,0 +1,262 @@
+package logic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ARCHIVE_VERSION - the version of the archives written by an export, newer archives can not be imported
+const ARCHIVE_VERSION = 1
+
+// archiveEncryptedHeader - starts an encrypted archive, a plain archive starts with the gzip magic bytes
+const archiveEncryptedHeader = "NMARCHIVE-AES256GCM\n"
+
+const archiveSaltSize = 16
+
+// networkTables - the tables whose records belong to a network, with how to get the network of a record
+var networkTables = map[string]func(key string, value string) string{
+	database.NETWORKS_TABLE_NAME: func(key string, value string) string {
+		return key
+	},
+	database.NODES_TABLE_NAME:         networkField,
+	database.DELETED_NODES_TABLE_NAME: networkField,
+	database.DNS_TABLE_NAME:           networkField,
+	database.EXT_CLIENT_TABLE_NAME:    networkField,
+	database.INT_CLIENTS_TABLE_NAME:   networkField,
+	database.PEERS_TABLE_NAME: func(key string, value string) string {
+		return key
+	},
+}
+
+// networkField - the network of a node, dns entry or client record
+func networkField(key string, value string) string {
+	var record struct {
+		Network string `json:"network"`
+	}
+	if json.Unmarshal([]byte(value), &record) != nil {
+		return ""
+	}
+	return record.Network
+}
+
+// archiveTables - the tables that make up the state of the server, the leases only mean something to the running instances
+func archiveTables() []string {
+	var tables []string
+	for _, table := range database.Tables() {
+		if table != database.LEASES_TABLE_NAME {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// ExportState - makes an archive of every table of the database, or of the records of the given networks only,
+// a network export leaves out the users, server config and everything else that is not part of a network
+func ExportState(networks []string) (models.ServerArchive, error) {
+	archive := models.ServerArchive{
+		Version:       ARCHIVE_VERSION,
+		ServerVersion: servercfg.GetVersion(),
+		Database:      servercfg.GetDB(),
+		CreatedAt:     time.Now().Unix(),
+		Networks:      networks,
+		Tables:        make(map[string]map[string]string),
+	}
+	wanted := make(map[string]bool)
+	for _, network := range networks {
+		if _, err := GetParentNetwork(network); err != nil {
+			return archive, errors.New("could not find network " + network)
+		}
+		wanted[network] = true
+	}
+	for _, table := range archiveTables() {
+		networkOf, isNetworkTable := networkTables[table]
+		if len(networks) > 0 && !isNetworkTable {
+			continue
+		}
+		records, err := database.FetchRecords(table)
+		if err != nil && !database.IsEmptyRecord(err) {
+			return archive, err
+		}
+		exported := make(map[string]string)
+		for key, value := range records {
+			if len(networks) > 0 && !wanted[networkOf(key, value)] {
+				continue
+			}
+			exported[key] = value
+		}
+		archive.Tables[table] = exported
+	}
+	return archive, nil
+}
+
+// ImportState - restores the records of an archive into the configured database, records with the same key are overwritten,
+// with replace the tables of a full archive are emptied first and the records of the networks of a network archive are removed first
+func ImportState(archive *models.ServerArchive, replace bool) (models.ImportSummary, error) {
+	summary := models.ImportSummary{
+		Version:  archive.Version,
+		Networks: archive.Networks,
+		Records:  make(map[string]int),
+		Skipped:  []string{},
+	}
+	if archive.Version < 1 || archive.Version > ARCHIVE_VERSION {
+		return summary, errors.New("archive version " + strconv.Itoa(archive.Version) + " is not supported, the newest supported is " + strconv.Itoa(ARCHIVE_VERSION))
+	}
+	known := make(map[string]bool)
+	for _, table := range archiveTables() {
+		known[table] = true
+	}
+	var tables []string
+	for table := range archive.Tables {
+		_, isNetworkTable := networkTables[table]
+		if !known[table] || (len(archive.Networks) > 0 && !isNetworkTable) {
+			summary.Skipped = append(summary.Skipped, "table "+table)
+			continue
+		}
+		tables = append(tables, table)
+	}
+	// the networks go in first, so nothing of a network is stored before the network itself
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i] == database.NETWORKS_TABLE_NAME || (tables[j] != database.NETWORKS_TABLE_NAME && tables[i] < tables[j])
+	})
+
+	if replace {
+		for _, table := range tables {
+			if err := clearArchiveTable(table, archive.Networks); err != nil {
+				return summary, err
+			}
+		}
+	}
+	for _, table := range tables {
+		for key, value := range archive.Tables[table] {
+			if err := database.Insert(key, value, table); err != nil {
+				return summary, errors.New("could not restore " + key + " in table " + table + ": " + err.Error())
+			}
+			summary.Records[table]++
+		}
+	}
+	return summary, nil
+}
+
+// clearArchiveTable - empties a table before an import, or removes the records of the networks when networks are given
+func clearArchiveTable(table string, networks []string) error {
+	if len(networks) == 0 {
+		return database.DeleteAllRecords(table)
+	}
+	records, err := database.FetchRecords(table)
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return nil
+		}
+		return err
+	}
+	networkOf := networkTables[table]
+	for key, value := range records {
+		network := networkOf(key, value)
+		for _, wanted := range networks {
+			if network != wanted {
+				continue
+			}
+			if err = database.DeleteRecord(table, key); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// EncodeArchive - writes an archive as gzipped json, encrypted with aes-gcm under a key derived from the passphrase when one is given
+func EncodeArchive(archive *models.ServerArchive, passphrase string) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if err := json.NewEncoder(writer).Encode(archive); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return buffer.Bytes(), nil
+	}
+
+	salt := make([]byte, archiveSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	aead, err := archiveCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	encrypted := append([]byte(archiveEncryptedHeader), salt...)
+	encrypted = append(encrypted, nonce...)
+	return aead.Seal(encrypted, nonce, buffer.Bytes(), []byte(archiveEncryptedHeader)), nil
+}
+
+// DecodeArchive - reads an archive written by EncodeArchive, the passphrase is needed for an encrypted archive
+func DecodeArchive(data []byte, passphrase string) (models.ServerArchive, error) {
+	var archive models.ServerArchive
+	if bytes.HasPrefix(data, []byte(archiveEncryptedHeader)) {
+		if passphrase == "" {
+			return archive, errors.New("the archive is encrypted, a passphrase is needed")
+		}
+		sealed := data[len(archiveEncryptedHeader):]
+		if len(sealed) < archiveSaltSize {
+			return archive, errors.New("the archive is too short")
+		}
+		aead, err := archiveCipher(passphrase, sealed[:archiveSaltSize])
+		if err != nil {
+			return archive, err
+		}
+		sealed = sealed[archiveSaltSize:]
+		if len(sealed) < aead.NonceSize() {
+			return archive, errors.New("the archive is too short")
+		}
+		data, err = aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(archiveEncryptedHeader))
+		if err != nil {
+			return archive, errors.New("could not decrypt the archive, the passphrase is wrong or the archive was changed")
+		}
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return archive, errors.New("not a netmaker archive: " + err.Error())
+	}
+	defer reader.Close()
+	contents, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return archive, err
+	}
+	err = json.Unmarshal(contents, &archive)
+	return archive, err
+}
+
+// archiveCipher - the aes-gcm cipher of an encrypted archive, the key is derived from the passphrase with scrypt
+func archiveCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
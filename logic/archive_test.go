@@ -0,0 +1,111 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveEncoding(t *testing.T) {
+	archive := models.ServerArchive{
+		Version:  ARCHIVE_VERSION,
+		Networks: []string{"skynet"},
+		Tables:   map[string]map[string]string{database.NETWORKS_TABLE_NAME: {"skynet": `{"netid":"skynet"}`}},
+	}
+	t.Run("Plain", func(t *testing.T) {
+		data, err := EncodeArchive(&archive, "")
+		assert.Nil(t, err)
+		decoded, err := DecodeArchive(data, "")
+		assert.Nil(t, err)
+		assert.Equal(t, archive, decoded)
+	})
+	t.Run("Encrypted", func(t *testing.T) {
+		data, err := EncodeArchive(&archive, "secret")
+		assert.Nil(t, err)
+		assert.NotContains(t, string(data), "skynet")
+		_, err = DecodeArchive(data, "")
+		assert.NotNil(t, err)
+		_, err = DecodeArchive(data, "wrong")
+		assert.NotNil(t, err)
+		decoded, err := DecodeArchive(data, "secret")
+		assert.Nil(t, err)
+		assert.Equal(t, archive, decoded)
+	})
+	t.Run("NotAnArchive", func(t *testing.T) {
+		_, err := DecodeArchive([]byte("{}"), "")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestExportImportState(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	for _, table := range database.Tables() {
+		database.DeleteAllRecords(table)
+	}
+	insertTestRecord(t, "skynet", models.Network{NetID: "skynet"}, database.NETWORKS_TABLE_NAME)
+	insertTestRecord(t, "othernet", models.Network{NetID: "othernet"}, database.NETWORKS_TABLE_NAME)
+	insertTestRecord(t, "node-1###skynet", models.Node{ID: "node-1###skynet", Network: "skynet"}, database.NODES_TABLE_NAME)
+	insertTestRecord(t, "node-2###othernet", models.Node{ID: "node-2###othernet", Network: "othernet"}, database.NODES_TABLE_NAME)
+	insertTestRecord(t, "www###skynet", models.DNSEntry{Name: "www", Network: "skynet", Address: "10.0.0.5"}, database.DNS_TABLE_NAME)
+	insertTestRecord(t, "admin", models.User{UserName: "admin", IsAdmin: true}, database.USERS_TABLE_NAME)
+	assert.Nil(t, database.InsertPeer("skynet", `{"key":"value"}`))
+
+	t.Run("Full", func(t *testing.T) {
+		archive, err := ExportState(nil)
+		assert.Nil(t, err)
+		assert.Equal(t, ARCHIVE_VERSION, archive.Version)
+		assert.Len(t, archive.Tables, len(archiveTables()))
+		assert.NotContains(t, archive.Tables, database.LEASES_TABLE_NAME)
+		assert.Len(t, archive.Tables[database.NODES_TABLE_NAME], 2)
+		assert.Contains(t, archive.Tables[database.USERS_TABLE_NAME], "admin")
+
+		for _, table := range database.Tables() {
+			database.DeleteAllRecords(table)
+		}
+		summary, err := ImportState(&archive, true)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, summary.Records[database.NETWORKS_TABLE_NAME])
+		assert.Equal(t, 1, summary.Records[database.USERS_TABLE_NAME])
+		nodes, err := database.FetchRecords(database.NODES_TABLE_NAME)
+		assert.Nil(t, err)
+		assert.Len(t, nodes, 2)
+	})
+	t.Run("Network", func(t *testing.T) {
+		archive, err := ExportState([]string{"skynet"})
+		assert.Nil(t, err)
+		assert.NotContains(t, archive.Tables, database.USERS_TABLE_NAME)
+		assert.Equal(t, []string{"skynet"}, keys(archive.Tables[database.NETWORKS_TABLE_NAME]))
+		assert.Equal(t, []string{"node-1###skynet"}, keys(archive.Tables[database.NODES_TABLE_NAME]))
+		assert.Equal(t, []string{"skynet"}, keys(archive.Tables[database.PEERS_TABLE_NAME]))
+
+		// a node added to skynet after the export is removed by a replacing import, othernet is left alone
+		insertTestRecord(t, "node-3###skynet", models.Node{ID: "node-3###skynet", Network: "skynet"}, database.NODES_TABLE_NAME)
+		archive.Tables[database.USERS_TABLE_NAME] = map[string]string{"intruder": `{"username":"intruder","isadmin":true}`}
+		summary, err := ImportState(&archive, true)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"table " + database.USERS_TABLE_NAME}, summary.Skipped)
+		nodes, err := database.FetchRecords(database.NODES_TABLE_NAME)
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{"node-1###skynet", "node-2###othernet"}, keys(nodes))
+		_, err = database.FetchRecord(database.USERS_TABLE_NAME, "intruder")
+		assert.True(t, database.IsEmptyRecord(err))
+	})
+	t.Run("MissingNetwork", func(t *testing.T) {
+		_, err := ExportState([]string{"nonet"})
+		assert.NotNil(t, err)
+	})
+	t.Run("NewerVersion", func(t *testing.T) {
+		_, err := ImportState(&models.ServerArchive{Version: ARCHIVE_VERSION + 1}, false)
+		assert.NotNil(t, err)
+	})
+}
+
+func keys(records map[string]string) []string {
+	var result []string
+	for key := range records {
+		result = append(result, key)
+	}
+	return result
+}
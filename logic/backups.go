@@ -0,0 +1,194 @@
+package logic
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+)
+
+// backupPrefix - the names of the backups taken by the server start with it, other files in the backup directory are left alone
+const backupPrefix = "netmaker-"
+
+// backupTimeFormat - the time in the name of a backup, so the names sort from oldest to newest
+const backupTimeFormat = "20060102-150405.000"
+
+// snapshotExtension - the file extension of a snapshot the database took of itself
+const snapshotExtension = ".db"
+
+// archiveExtension - the file extension of an archive of the tables
+const archiveExtension = ".archive"
+
+// backupMutex - keeps two backups from being taken or pruned together
+var backupMutex sync.Mutex
+
+// GetBackups - gets the backups in the backup directory, newest first
+func GetBackups() ([]models.Backup, error) {
+	backups := []models.Backup{}
+	files, err := ioutil.ReadDir(servercfg.GetBackupDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return backups, nil
+		}
+		return backups, err
+	}
+	for _, file := range files {
+		backupType := getBackupType(file.Name())
+		if file.IsDir() || backupType == "" || !strings.HasPrefix(file.Name(), backupPrefix) {
+			continue
+		}
+		backups = append(backups, models.Backup{
+			Name:      file.Name(),
+			Type:      backupType,
+			Size:      file.Size(),
+			CreatedAt: file.ModTime().Unix(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Name > backups[j].Name
+	})
+	return backups, nil
+}
+
+// CreateBackup - takes a backup of the database into the backup directory, a snapshot when the database can take one
+// and an archive of the tables when it can not
+func CreateBackup() (models.Backup, error) {
+	backupMutex.Lock()
+	defer backupMutex.Unlock()
+	dir := servercfg.GetBackupDir()
+	// the backups hold the private keys of the server and its nodes
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return models.Backup{}, err
+	}
+	name := backupPrefix + time.Now().UTC().Format(backupTimeFormat) + archiveExtension
+	backupType := models.BACKUP_ARCHIVE
+	if database.CanBackup() {
+		name = strings.TrimSuffix(name, archiveExtension) + snapshotExtension
+		backupType = models.BACKUP_SNAPSHOT
+	}
+	path := filepath.Join(dir, name)
+	// written under another name first, so a failed backup never counts as one
+	partial := path + ".partial"
+	defer os.Remove(partial)
+	if backupType == models.BACKUP_SNAPSHOT {
+		if err := database.Backup(partial); err != nil {
+			return models.Backup{}, err
+		}
+	} else {
+		archive, err := ExportState(nil)
+		if err != nil {
+			return models.Backup{}, err
+		}
+		data, err := EncodeArchive(&archive, "")
+		if err != nil {
+			return models.Backup{}, err
+		}
+		if err = ioutil.WriteFile(partial, data, 0600); err != nil {
+			return models.Backup{}, err
+		}
+	}
+	if err := os.Chmod(partial, 0600); err != nil {
+		return models.Backup{}, err
+	}
+	if err := os.Rename(partial, path); err != nil {
+		return models.Backup{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return models.Backup{}, err
+	}
+	return models.Backup{Name: name, Type: backupType, Size: info.Size(), CreatedAt: info.ModTime().Unix()}, nil
+}
+
+// PruneBackups - removes the oldest backups until only the retention count is left, returns the removed backups
+func PruneBackups(retention int) ([]string, error) {
+	backupMutex.Lock()
+	defer backupMutex.Unlock()
+	var removed []string
+	backups, err := GetBackups()
+	if err != nil || len(backups) <= retention {
+		return removed, err
+	}
+	for _, backup := range backups[retention:] {
+		if err = os.Remove(filepath.Join(servercfg.GetBackupDir(), backup.Name)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, backup.Name)
+	}
+	return removed, nil
+}
+
+// RunScheduledBackup - takes a backup when the newest one is older than the interval and prunes the old ones,
+// returns true when a backup was taken
+func RunScheduledBackup(interval time.Duration, retention int) (bool, error) {
+	backups, err := GetBackups()
+	if err != nil {
+		return false, err
+	}
+	if len(backups) > 0 && time.Since(time.Unix(backups[0].CreatedAt, 0)) < interval {
+		return false, nil
+	}
+	backup, err := CreateBackup()
+	if err != nil {
+		return false, err
+	}
+	Log("took backup "+backup.Name, 1)
+	removed, err := PruneBackups(retention)
+	for _, name := range removed {
+		Log("removed old backup "+name, 2)
+	}
+	return true, err
+}
+
+// GetBackupPath - the path of a backup, a name is looked up in the backup directory and a path is used as it is
+func GetBackupPath(nameOrPath string) (string, error) {
+	path := nameOrPath
+	if filepath.Base(nameOrPath) == nameOrPath {
+		path = filepath.Join(servercfg.GetBackupDir(), nameOrPath)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", errors.New("could not find backup " + nameOrPath)
+	}
+	return path, nil
+}
+
+// RestoreBackup - replaces the database with a backup, a snapshot can only be restored into a database that takes snapshots,
+// an archive into any database
+func RestoreBackup(path string) error {
+	switch getBackupType(path) {
+	case models.BACKUP_SNAPSHOT:
+		return database.Restore(path)
+	case models.BACKUP_ARCHIVE:
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		archive, err := DecodeArchive(data, "")
+		if err != nil {
+			return err
+		}
+		_, err = ImportState(&archive, true)
+		return err
+	default:
+		return errors.New(path + " is not a backup, backups end in .db or .archive")
+	}
+}
+
+// getBackupType - the type of a backup by its file extension, empty for a file that is no backup
+func getBackupType(name string) string {
+	switch filepath.Ext(name) {
+	case snapshotExtension:
+		return models.BACKUP_SNAPSHOT
+	case archiveExtension:
+		return models.BACKUP_ARCHIVE
+	}
+	return ""
+}
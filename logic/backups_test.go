@@ -0,0 +1,69 @@
+package logic
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackups(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	dir, err := ioutil.TempDir("", "netmaker-backups")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	os.Setenv("BACKUP_DIR", dir)
+	defer os.Unsetenv("BACKUP_DIR")
+	database.DeleteAllRecords(database.NETWORKS_TABLE_NAME)
+	insertTestRecord(t, "skynet", models.Network{NetID: "skynet"}, database.NETWORKS_TABLE_NAME)
+
+	var backup models.Backup
+	t.Run("Create", func(t *testing.T) {
+		backup, err = CreateBackup()
+		assert.Nil(t, err)
+		// the in-memory database can not take snapshots, so the tables are archived
+		assert.Equal(t, models.BACKUP_ARCHIVE, backup.Type)
+		info, err := os.Stat(filepath.Join(dir, backup.Name))
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		backups, err := GetBackups()
+		assert.Nil(t, err)
+		assert.Equal(t, []models.Backup{backup}, backups)
+	})
+	t.Run("Restore", func(t *testing.T) {
+		insertTestRecord(t, "othernet", models.Network{NetID: "othernet"}, database.NETWORKS_TABLE_NAME)
+		path, err := GetBackupPath(backup.Name)
+		assert.Nil(t, err)
+		assert.Nil(t, RestoreBackup(path))
+		networks, err := database.FetchRecords(database.NETWORKS_TABLE_NAME)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"skynet"}, keys(networks))
+
+		_, err = GetBackupPath("netmaker-missing.db")
+		assert.NotNil(t, err)
+		assert.NotNil(t, RestoreBackup(filepath.Join(dir, "notes.txt")))
+	})
+	t.Run("Schedule", func(t *testing.T) {
+		taken, err := RunScheduledBackup(time.Hour, 2)
+		assert.Nil(t, err)
+		assert.False(t, taken)
+		// older backups, and a file that is not a backup and is left alone
+		for _, name := range []string{"netmaker-20200101-000000.000.db", "netmaker-20200102-000000.000.archive", "notes.txt"} {
+			assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("old"), 0600))
+		}
+		taken, err = RunScheduledBackup(0, 2)
+		assert.Nil(t, err)
+		assert.True(t, taken)
+		backups, err := GetBackups()
+		assert.Nil(t, err)
+		assert.Len(t, backups, 2)
+		assert.Equal(t, backup.Name, backups[1].Name)
+		_, err = os.Stat(filepath.Join(dir, "notes.txt"))
+		assert.Nil(t, err)
+	})
+}
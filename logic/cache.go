@@ -0,0 +1,295 @@
+package logic
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+)
+
+// recordCache - a least recently used cache of values read from the database, entries expire after the ttl
+// and are removed on the change events of their records
+type recordCache struct {
+	name    string
+	mutex   sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+	// generation - counts the invalidations, a value read from the database is only stored when none happened during the read
+	generation    uint64
+	hits          uint64
+	misses        uint64
+	evictions     uint64
+	invalidations uint64
+}
+
+type cacheEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+var networkCache = newRecordCache("networks")
+var nodeCache = newRecordCache("nodes")
+var peersCache = newRecordCache("peers")
+
+// cachedPeers - a cached peer list, with a hash of each node and endpoint record of the network as the list was built from it
+type cachedPeers struct {
+	peers        []models.Node
+	fingerprints map[string][sha256.Size]byte
+}
+
+func init() {
+	SubscribeEvents(invalidateCaches)
+}
+
+func newRecordCache(name string) *recordCache {
+	cache := &recordCache{name: name}
+	cache.configure(int(servercfg.GetCacheSize()), time.Duration(servercfg.GetCacheTTL())*time.Second)
+	return cache
+}
+
+// resetCaches - empties the caches and applies the cache settings, the hit counts start over
+func resetCaches() {
+	for _, cache := range []*recordCache{networkCache, nodeCache, peersCache} {
+		cache.configure(int(servercfg.GetCacheSize()), time.Duration(servercfg.GetCacheTTL())*time.Second)
+	}
+}
+
+// GetCacheStats - gets the size and hit rate of each cache
+func GetCacheStats() []models.CacheStats {
+	return []models.CacheStats{networkCache.stats(), nodeCache.stats(), peersCache.stats()}
+}
+
+// fetchNetworkRecord - fetches the record of a network through the networks cache
+func fetchNetworkRecord(networkname string) (string, error) {
+	value, err := networkCache.load(networkname, func() (interface{}, error) {
+		return database.FetchRecord(database.NETWORKS_TABLE_NAME, networkname)
+	})
+	record, _ := value.(string)
+	return record, err
+}
+
+// fetchNodeRecord - fetches the record of a node through the nodes cache
+func fetchNodeRecord(key string) (string, error) {
+	value, err := nodeCache.load(key, func() (interface{}, error) {
+		return database.FetchRecord(database.NODES_TABLE_NAME, key)
+	})
+	record, _ := value.(string)
+	return record, err
+}
+
+// copyPeers - copies a cached peer list, with the slices of each peer, so the caller can change it
+func copyPeers(peers []models.Node) []models.Node {
+	if peers == nil {
+		return nil
+	}
+	copied := make([]models.Node, len(peers))
+	for i, peer := range peers {
+		peer.AllowedIPs = append([]string(nil), peer.AllowedIPs...)
+		peer.RelayAddrs = append([]string(nil), peer.RelayAddrs...)
+		peer.EgressGatewayRanges = append([]string(nil), peer.EgressGatewayRanges...)
+		copied[i] = peer
+	}
+	return copied
+}
+
+// recordCache.load - gets a value from the cache, or reads it with load and stores it when the read did not fail
+func (cache *recordCache) load(key string, load func() (interface{}, error)) (interface{}, error) {
+	if !servercfg.IsCacheMode() {
+		return load()
+	}
+	cache.mutex.Lock()
+	if element, ok := cache.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		if time.Now().Before(entry.expiresAt) {
+			cache.order.MoveToFront(element)
+			cache.hits++
+			cache.mutex.Unlock()
+			return entry.value, nil
+		}
+		cache.remove(element)
+	}
+	cache.misses++
+	generation := cache.generation
+	cache.mutex.Unlock()
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if generation == cache.generation {
+		cache.set(key, value)
+	}
+	return value, nil
+}
+
+// recordCache.invalidate - removes the entries whose key starts with a prefix, every entry for an empty prefix
+func (cache *recordCache) invalidate(prefix string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.generation++
+	cache.invalidations++
+	if prefix == "" {
+		cache.entries = make(map[string]*list.Element)
+		cache.order.Init()
+		return
+	}
+	for key, element := range cache.entries {
+		if strings.HasPrefix(key, prefix) {
+			cache.remove(element)
+		}
+	}
+}
+
+// recordCache.delete - removes the entry of a key, every entry for an empty key
+func (cache *recordCache) delete(key string) {
+	if key == "" {
+		cache.invalidate("")
+		return
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.generation++
+	cache.invalidations++
+	if element, ok := cache.entries[key]; ok {
+		cache.remove(element)
+	}
+}
+
+// recordCache.invalidateIf - removes the entries whose key starts with a prefix and whose value is stale,
+// values being read at the same time are not stored either way
+func (cache *recordCache) invalidateIf(prefix string, stale func(value interface{}) bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.generation++
+	cache.invalidations++
+	for key, element := range cache.entries {
+		if strings.HasPrefix(key, prefix) && stale(element.Value.(*cacheEntry).value) {
+			cache.remove(element)
+		}
+	}
+}
+
+func (cache *recordCache) configure(size int, ttl time.Duration) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.size = size
+	cache.ttl = ttl
+	cache.entries = make(map[string]*list.Element)
+	cache.order = list.New()
+	cache.generation++
+	cache.hits, cache.misses, cache.evictions, cache.invalidations = 0, 0, 0, 0
+}
+
+func (cache *recordCache) set(key string, value interface{}) {
+	if element, ok := cache.entries[key]; ok {
+		cache.remove(element)
+	}
+	cache.entries[key] = cache.order.PushFront(&cacheEntry{key: key, value: value, expiresAt: time.Now().Add(cache.ttl)})
+	for cache.order.Len() > cache.size {
+		cache.remove(cache.order.Back())
+		cache.evictions++
+	}
+}
+
+func (cache *recordCache) remove(element *list.Element) {
+	delete(cache.entries, element.Value.(*cacheEntry).key)
+	cache.order.Remove(element)
+}
+
+func (cache *recordCache) stats() models.CacheStats {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	stats := models.CacheStats{
+		Name:          cache.name,
+		Size:          cache.order.Len(),
+		Capacity:      cache.size,
+		TTL:           int64(cache.ttl.Seconds()),
+		Hits:          cache.hits,
+		Misses:        cache.misses,
+		Evictions:     cache.evictions,
+		Invalidations: cache.invalidations,
+	}
+	if cache.hits+cache.misses > 0 {
+		stats.HitRate = float64(cache.hits) / float64(cache.hits+cache.misses)
+	}
+	return stats
+}
+
+// invalidateCaches - removes the cached values a change event makes stale, cached records are never replaced with the value
+// of an event, as the events of writes made at the same time may come in another order than the writes
+func invalidateCaches(event models.Event) {
+	switch event.Type {
+	case models.EVENT_NETWORK:
+		networkCache.delete(event.Key)
+		peersCache.invalidate(cachePrefix(event.Key))
+	case models.EVENT_NODE:
+		nodeCache.delete(event.Key)
+		invalidatePeers(event)
+	case models.EVENT_PEERS:
+		invalidatePeers(event)
+	}
+}
+
+// invalidatePeers - removes the cached peer lists of a network that were not built from the new value of a changed node
+// or endpoint record, most node changes are checkins which leave what the peers see as it was
+func invalidatePeers(event models.Event) {
+	var fingerprint [sha256.Size]byte
+	known := event.Action == models.EVENT_UPDATED && event.Key != "" && event.Value != ""
+	if known && event.Type == models.EVENT_NODE {
+		var node models.Node
+		if err := json.Unmarshal([]byte(event.Value), &node); err != nil {
+			known = false
+		}
+		fingerprint = peerFingerprint(&node)
+	} else if known {
+		endpoints := make(map[string]string)
+		if err := json.Unmarshal([]byte(event.Value), &endpoints); err != nil {
+			known = false
+		}
+		fingerprint = endpointsFingerprint(endpoints)
+	}
+	key := event.Type + "/" + event.Key
+	peersCache.invalidateIf(cachePrefix(event.Network), func(value interface{}) bool {
+		// the changes of other instances come without their value, so they always make the lists stale
+		if !known {
+			return true
+		}
+		cached, ok := value.(cachedPeers)
+		if !ok {
+			return true
+		}
+		previous, ok := cached.fingerprints[key]
+		return !ok || previous != fingerprint
+	})
+}
+
+// cachePrefix - the prefix of the cache keys of a network, an empty network stands for every network
+func cachePrefix(network string) string {
+	if network == "" {
+		return ""
+	}
+	return network + "/"
+}
+
+// peerFingerprint - a hash of what the peers of a network see of a node
+func peerFingerprint(node *models.Node) [sha256.Size]byte {
+	data, _ := json.Marshal(setPeerInfo(*node))
+	return sha256.Sum256(append([]byte(node.Network+"/"), data...))
+}
+
+// endpointsFingerprint - a hash of the endpoints found by udp hole punching in a network
+func endpointsFingerprint(endpoints map[string]string) [sha256.Size]byte {
+	data, _ := json.Marshal(endpoints)
+	return sha256.Sum256(data)
+}
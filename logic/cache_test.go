@@ -0,0 +1,280 @@
+package logic
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordCache(t *testing.T) {
+	cache := newRecordCache("test")
+	cache.configure(2, time.Minute)
+	reads := 0
+	read := func(value string) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			reads++
+			return value, nil
+		}
+	}
+
+	t.Run("ReadThrough", func(t *testing.T) {
+		value, err := cache.load("a", read("1"))
+		assert.Nil(t, err)
+		assert.Equal(t, "1", value)
+		value, err = cache.load("a", read("2"))
+		assert.Nil(t, err)
+		assert.Equal(t, "1", value)
+		assert.Equal(t, 1, reads)
+		_, err = cache.load("missing", func() (interface{}, error) {
+			return "", errors.New(database.NO_RECORD)
+		})
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, cache.stats().Size)
+	})
+	t.Run("Evict", func(t *testing.T) {
+		cache.load("b", read("1"))
+		cache.load("a", read("1"))
+		cache.load("c", read("1"))
+		// b was used least recently
+		assert.Equal(t, uint64(1), cache.stats().Evictions)
+		reads = 0
+		cache.load("a", read("1"))
+		cache.load("b", read("1"))
+		assert.Equal(t, 1, reads)
+	})
+	t.Run("Invalidate", func(t *testing.T) {
+		cache.load("skynet/a", read("1"))
+		cache.invalidate("skynet/")
+		value, _ := cache.load("skynet/a", read("2"))
+		assert.Equal(t, "2", value)
+		// an invalidation during the read keeps the value read out of the cache
+		value, _ = cache.load("skynet/b", func() (interface{}, error) {
+			cache.delete("skynet/b")
+			return "stale", nil
+		})
+		assert.Equal(t, "stale", value)
+		value, _ = cache.load("skynet/b", read("fresh"))
+		assert.Equal(t, "fresh", value)
+	})
+	t.Run("Expire", func(t *testing.T) {
+		cache.configure(2, time.Millisecond)
+		cache.load("a", read("1"))
+		time.Sleep(2 * time.Millisecond)
+		value, _ := cache.load("a", read("2"))
+		assert.Equal(t, "2", value)
+		stats := cache.stats()
+		assert.Equal(t, uint64(0), stats.Hits)
+		assert.Equal(t, uint64(2), stats.Misses)
+	})
+	t.Run("Off", func(t *testing.T) {
+		os.Setenv("CACHE_MODE", "off")
+		defer os.Unsetenv("CACHE_MODE")
+		cache.configure(2, time.Minute)
+		cache.load("a", read("1"))
+		value, _ := cache.load("a", read("2"))
+		assert.Equal(t, "2", value)
+		assert.Equal(t, 0, cache.stats().Size)
+	})
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	for _, table := range []string{database.NODES_TABLE_NAME, database.NETWORKS_TABLE_NAME, database.PEERS_TABLE_NAME} {
+		database.DeleteAllRecords(table)
+	}
+	resetCaches()
+	defer resetCaches()
+	insertTestRecord(t, "skynet", models.Network{NetID: "skynet", AddressRange: "10.0.0.0/24"}, database.NETWORKS_TABLE_NAME)
+	first := models.Node{UUID: "node-1", Name: "first", Network: "skynet", Address: "10.0.0.1", PublicKey: "key-1", Endpoint: "1.1.1.1"}
+	second := models.Node{UUID: "node-2", Name: "second", Network: "skynet", Address: "10.0.0.2", PublicKey: "key-2", Endpoint: "2.2.2.2"}
+	for _, node := range []*models.Node{&first, &second} {
+		node.SetID()
+		insertTestRecord(t, node.ID, node, database.NODES_TABLE_NAME)
+	}
+
+	t.Run("Network", func(t *testing.T) {
+		network, err := GetParentNetwork("skynet")
+		assert.Nil(t, err)
+		assert.Equal(t, "10.0.0.0/24", network.AddressRange)
+		network.AddressRange = "10.0.1.0/24"
+		insertTestRecord(t, "skynet", network, database.NETWORKS_TABLE_NAME)
+		network, err = GetParentNetwork("skynet")
+		assert.Nil(t, err)
+		assert.Equal(t, "10.0.1.0/24", network.AddressRange)
+	})
+	t.Run("Node", func(t *testing.T) {
+		node, err := GetNodeByID("skynet", "node-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "first", node.Name)
+		first.Name = "renamed"
+		insertTestRecord(t, first.ID, first, database.NODES_TABLE_NAME)
+		node, err = GetNodeByID("skynet", "node-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "renamed", node.Name)
+	})
+	t.Run("Peers", func(t *testing.T) {
+		peers, err := GetPeersList("skynet", false, "")
+		assert.Nil(t, err)
+		assert.Len(t, peers, 2)
+		// a checkin only changes the last check in time, so the peer list stays cached
+		first.LastCheckIn = time.Now().Unix()
+		insertTestRecord(t, first.ID, first, database.NODES_TABLE_NAME)
+		hits := peersCache.stats().Hits
+		_, err = GetPeersList("skynet", false, "")
+		assert.Nil(t, err)
+		assert.Equal(t, hits+1, peersCache.stats().Hits)
+
+		first.Endpoint = "3.3.3.3"
+		insertTestRecord(t, first.ID, first, database.NODES_TABLE_NAME)
+		peers, err = GetPeersList("skynet", false, "")
+		assert.Nil(t, err)
+		assert.Contains(t, []string{peers[0].Endpoint, peers[1].Endpoint}, "3.3.3.3")
+
+		assert.Nil(t, database.DeleteRecord(database.NODES_TABLE_NAME, second.ID))
+		peers, err = GetPeersList("skynet", false, "")
+		assert.Nil(t, err)
+		assert.Len(t, peers, 1)
+		// callers get a copy of the cached list
+		peers[0].Name = "changed"
+		peers, _ = GetPeersList("skynet", false, "")
+		assert.Equal(t, "renamed", peers[0].Name)
+	})
+	t.Run("OutOfOrder", func(t *testing.T) {
+		_, err := GetNodeByID("skynet", "node-1")
+		assert.Nil(t, err)
+		_, err = GetPeersList("skynet", false, "")
+		assert.Nil(t, err)
+		// the event of an earlier write that comes after the current value was cached
+		stale := first
+		stale.Endpoint = "9.9.9.9"
+		data, err := json.Marshal(&stale)
+		assert.Nil(t, err)
+		dispatchEvent(models.Event{Type: models.EVENT_NODE, Action: models.EVENT_UPDATED, Key: first.ID, Network: "skynet", Server: servercfg.GetServerID(), Value: string(data)})
+		_, cached := nodeCache.entries[first.ID]
+		assert.False(t, cached)
+		assert.Equal(t, 0, peersCache.stats().Size)
+		node, err := GetNodeByID("skynet", "node-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "3.3.3.3", node.Endpoint)
+		peers, err := GetPeersList("skynet", false, "")
+		assert.Nil(t, err)
+		assert.Equal(t, "3.3.3.3", peers[0].Endpoint)
+	})
+	t.Run("Remote", func(t *testing.T) {
+		_, err := GetNodeByID("skynet", "node-1")
+		assert.Nil(t, err)
+		_, err = GetPeersList("skynet", false, "")
+		assert.Nil(t, err)
+		// the changes of other instances come without the new value
+		dispatchEvent(models.Event{Type: models.EVENT_NODE, Action: models.EVENT_UPDATED, Key: first.ID, Network: "skynet", Server: "server-2"})
+		_, cached := nodeCache.entries[first.ID]
+		assert.False(t, cached)
+		assert.Equal(t, 0, peersCache.stats().Size)
+	})
+	t.Run("Stats", func(t *testing.T) {
+		stats := GetCacheStats()
+		assert.Len(t, stats, 3)
+		for _, cache := range stats {
+			assert.Greater(t, cache.Hits+cache.Misses, uint64(0))
+			assert.Equal(t, float64(cache.Hits)/float64(cache.Hits+cache.Misses), cache.HitRate)
+		}
+	})
+}
+
+// BenchmarkCheckin - the reads and writes of the checkins of the nodes of a network of 5000 nodes on a sqlite database,
+// with and without the caches, each checkin reads the node and the network, stores the check in time, then gets the peers
+func BenchmarkCheckin(b *testing.B) {
+	dir, err := ioutil.TempDir("", "netmaker-benchmark")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	previous := database.GetStore()
+	store := database.NewSqliteStore(filepath.Join(dir, database.DATABASE_FILENAME))
+	database.SetStore(store)
+	defer func() {
+		store.Close()
+		database.SetStore(previous)
+	}()
+	if err := database.InitializeDatabase(); err != nil {
+		b.Fatal(err)
+	}
+	network := models.Network{NetID: "skynet", AddressRange: "10.0.0.0/16"}
+	if err := database.Insert(network.NetID, `{"netid":"skynet","addressrange":"10.0.0.0/16"}`, database.NETWORKS_TABLE_NAME); err != nil {
+		b.Fatal(err)
+	}
+	const nodeCount = 5000
+	// the nodes checking in during the benchmark, each one checks in again and again
+	const activeCount = 100
+	var ids []string
+	for i := 0; i < nodeCount; i++ {
+		key := make([]byte, 32)
+		binary.BigEndian.PutUint32(key, uint32(i))
+		node := models.Node{
+			UUID:      ServerNodeUUID(strconv.Itoa(i)),
+			Name:      "node" + strconv.Itoa(i),
+			Network:   network.NetID,
+			Address:   "10.0." + strconv.Itoa(i/250) + "." + strconv.Itoa(i%250+1),
+			PublicKey: base64.StdEncoding.EncodeToString(key),
+			Endpoint:  "192.168." + strconv.Itoa(i/250) + "." + strconv.Itoa(i%250+1),
+			Password:  "password",
+		}
+		SetNodeDefaults(&node)
+		node.SetID()
+		data, err := json.Marshal(&node)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err = database.Insert(node.ID, string(data), database.NODES_TABLE_NAME); err != nil {
+			b.Fatal(err)
+		}
+		ids = append(ids, node.UUID)
+	}
+	checkin := func(b *testing.B) {
+		resetCaches()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			id := ids[i%activeCount]
+			node, err := GetNodeByID(network.NetID, id)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err = GetNetworkSettings(node.Network); err != nil {
+				b.Fatal(err)
+			}
+			current := node
+			node.SetLastCheckIn()
+			if err = UpdateNode(&current, &node); err != nil {
+				b.Fatal(err)
+			}
+			if _, err = GetNodeByID(network.NetID, id); err != nil {
+				b.Fatal(err)
+			}
+			if _, err = GetPeersList(node.Network, true, ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.StopTimer()
+		for _, stats := range GetCacheStats() {
+			b.ReportMetric(stats.HitRate, stats.Name+"-hitrate")
+		}
+	}
+	b.Run("Cached", checkin)
+	b.Run("Uncached", func(b *testing.B) {
+		os.Setenv("CACHE_MODE", "off")
+		defer os.Unsetenv("CACHE_MODE")
+		checkin(b)
+	})
+}
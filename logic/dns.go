@@ -4,44 +4,63 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"strings"
 
 	"github.com/gravitl/netmaker/database"
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/servercfg"
+	"github.com/miekg/dns"
 	"github.com/txn2/txeh"
 )
 
-// SetDNS - sets the dns on file
+// SetDNS - sets the dns on file, or reloads the zones when the embedded dns server is used
+// the files are shared by the coredns of every server instance, so only the leader writes them
 func SetDNS() error {
+	if servercfg.IsEmbeddedDNS() {
+		return LoadDNSZones()
+	}
+	if !IsServerLeader() {
+		return nil
+	}
 	hostfile := txeh.Hosts{}
-	var corefilestring string
-	networks, err := GetNetworks()
-	if err != nil && !database.IsEmptyRecord(err) {
+	networks, zones, err := buildDNSZones()
+	if err != nil {
 		return err
 	}
-
-	for _, net := range networks {
-		corefilestring = corefilestring + net.NetID + " "
-		dns, err := GetDNS(net.NetID)
-		if err != nil && !database.IsEmptyRecord(err) {
-			return err
-		}
-		for _, entry := range dns {
-			hostfile.AddHost(entry.Address, entry.Name+"."+entry.Network)
+	for _, zone := range zones {
+		for _, name := range zone.names() {
+			for _, rr := range zone.records[name] {
+				switch record := rr.(type) {
+				case *dns.A:
+					hostfile.AddHost(record.A.String(), strings.TrimSuffix(name, "."))
+				case *dns.AAAA:
+					hostfile.AddHost(record.AAAA.String(), strings.TrimSuffix(name, "."))
+				}
+			}
 		}
 	}
-	if corefilestring == "" {
-		corefilestring = "example.com"
-	}
 
-	err = hostfile.SaveAs("./config/dnsconfig/netmaker.hosts")
+	err = writeFileIfChanged("./config/dnsconfig/netmaker.hosts", hostfile.RenderHostsFile(), nil)
 	if err != nil {
 		return err
 	}
-	if servercfg.IsSplitDNS() {
-		err = SetCorefile(corefilestring)
+	for _, zone := range zones {
+		err = writeFileIfChanged("./config/dnsconfig/"+strings.TrimSuffix(zone.origin, ".")+".db", zone.String(), zoneSerial)
+		if err != nil {
+			return err
+		}
 	}
-	return err
+	// the hosts file only holds the A and AAAA records, so the zones are always served from their zone files
+	return SetCorefile(networks)
+}
+
+// DNSSettingsChanged - checks if an update of a network changes the dns settings of its nodes
+func DNSSettingsChanged(currentNetwork *models.Network, newNetwork *models.Network) bool {
+	return currentNetwork.GetDNSZone() != newNetwork.GetDNSZone() ||
+		currentNetwork.DNSSearchDomain != newNetwork.DNSSearchDomain ||
+		currentNetwork.DNSForward != newNetwork.DNSForward ||
+		strings.Join(currentNetwork.DNSUpstreams, ",") != strings.Join(newNetwork.DNSUpstreams, ",")
 }
 
 // GetDNS - gets the DNS of a current network
@@ -72,13 +91,15 @@ func GetNodeDNS(network string) ([]models.DNSEntry, error) {
 	}
 
 	for _, value := range collection {
-		var entry models.DNSEntry
 		var node models.Node
-		if err = json.Unmarshal([]byte(value), &node); err != nil {
+		if err = json.Unmarshal([]byte(value), &node); err != nil || node.Network != network {
 			continue
 		}
-		if err = json.Unmarshal([]byte(value), &entry); node.Network == network && err == nil {
-			dns = append(dns, entry)
+		if node.Address != "" {
+			dns = append(dns, models.DNSEntry{Address: node.Address, Name: node.Name, Network: node.Network})
+		}
+		if node.Address6 != "" {
+			dns = append(dns, models.DNSEntry{Address: node.Address6, Name: node.Name, Network: node.Network, Type: models.DNS_RECORD_AAAA})
 		}
 	}
 
@@ -109,7 +130,9 @@ func GetCustomDNS(network string) ([]models.DNSEntry, error) {
 }
 
 // SetCorefile - sets the core file of the system
-func SetCorefile(domains string) error {
+// every network zone is served from its zone file, networks which forward get a view on their address ranges
+// without any networks the hosts file is served and everything else forwarded
+func SetCorefile(networks []models.Network) error {
 	dir, err := os.Getwd()
 	if err != nil {
 		return err
@@ -122,20 +145,75 @@ func SetCorefile(domains string) error {
 		return err
 	}
 
-	corefile := domains + ` {
+	var corefile string
+	if len(networks) == 0 {
+		corefile = `. {
     reload 15s
     hosts /root/dnsconfig/netmaker.hosts {
 	fallthrough	
     }
-    forward . 8.8.8.8 8.8.4.4
+`
+		if upstreams := servercfg.GetDNSUpstreams(); len(upstreams) > 0 {
+			corefile = corefile + "    forward . " + strings.Join(upstreams, " ") + "\n"
+		}
+		corefile = corefile + `    log
+}
+`
+	}
+	for _, network := range networks {
+		zone := network.GetDNSZone()
+		corefile = corefile + zone + ` {
+    reload 15s
+    file /root/dnsconfig/` + zone + `.db {
+	reload 15s
+    }
+    log
+}
+`
+	}
+	for _, network := range networks {
+		if network.DNSForward != "yes" {
+			continue
+		}
+		upstreams := servercfg.GetDNSUpstreams()
+		if len(network.DNSUpstreams) > 0 {
+			upstreams = dnsUpstreams(network.DNSUpstreams)
+		}
+		if len(upstreams) == 0 {
+			continue
+		}
+		var ranges []string
+		for _, addressRange := range []string{network.AddressRange, network.AddressRange6} {
+			if addressRange != "" {
+				ranges = append(ranges, "incidr(client_ip(), '"+addressRange+"')")
+			}
+		}
+		corefile = corefile + `. {
+    view ` + network.NetID + ` {
+	expr ` + strings.Join(ranges, " || ") + `
+    }
+    forward . ` + strings.Join(upstreams, " ") + `
     log
 }
 `
-	corebytes := []byte(corefile)
+	}
+	return writeFileIfChanged(dir+"/config/dnsconfig/Corefile", corefile, nil)
+}
 
-	err = ioutil.WriteFile(dir+"/config/dnsconfig/Corefile", corebytes, 0644)
-	if err != nil {
-		return err
+// zoneSerial - the serial of the soa record of a zone file, it is new on every build of the zone
+var zoneSerial = regexp.MustCompile(`(\tSOA\t\S+ \S+ )\d+`)
+
+// writeFileIfChanged - writes a dns file only when its contents changed, so coredns does not reload it on every run of the leader,
+// the parts matching ignore are left out of the comparison
+func writeFileIfChanged(path string, contents string, ignore *regexp.Regexp) error {
+	if current, err := ioutil.ReadFile(path); err == nil {
+		unchanged := string(current) == contents
+		if ignore != nil {
+			unchanged = ignore.ReplaceAllString(string(current), "$1") == ignore.ReplaceAllString(contents, "$1")
+		}
+		if unchanged {
+			return nil
+		}
 	}
-	return err
+	return ioutil.WriteFile(path, []byte(contents), 0644)
 }
@@ -0,0 +1,58 @@
+package logic
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDNS(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	for _, table := range []string{database.NETWORKS_TABLE_NAME, database.NODES_TABLE_NAME, database.DNS_TABLE_NAME} {
+		database.DeleteAllRecords(table)
+	}
+	dir, err := ioutil.TempDir("", "netmaker-dns")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	workdir, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(dir))
+	defer os.Chdir(workdir)
+	assert.Nil(t, os.MkdirAll(filepath.Join("config", "dnsconfig"), 0755))
+	os.Unsetenv("IS_SPLIT_DNS")
+	leaderState.mutex.Lock()
+	leaderState.isLeader, leaderState.expiresAt = true, time.Now().Add(time.Minute)
+	leaderState.mutex.Unlock()
+	defer func() {
+		leaderState.mutex.Lock()
+		leaderState.isLeader = false
+		leaderState.mutex.Unlock()
+	}()
+
+	insertTestRecord(t, "skynet", models.Network{NetID: "skynet", AddressRange: "10.0.0.0/24"}, database.NETWORKS_TABLE_NAME)
+	insertTestRecord(t, "node1###skynet", models.DNSEntry{Name: "node1", Network: "skynet", Address: "10.0.0.1"}, database.DNS_TABLE_NAME)
+	insertTestRecord(t, "www###skynet", models.DNSEntry{Name: "www", Network: "skynet", Type: models.DNS_RECORD_CNAME, Target: "node1.skynet"}, database.DNS_TABLE_NAME)
+	insertTestRecord(t, "_sip._udp###skynet", models.DNSEntry{Name: "_sip._udp", Network: "skynet", Type: models.DNS_RECORD_SRV, Target: "node1.skynet", Priority: 10, Weight: 5, Port: 5060}, database.DNS_TABLE_NAME)
+	insertTestRecord(t, "txt###skynet", models.DNSEntry{Name: "txt", Network: "skynet", Type: models.DNS_RECORD_TXT, Text: "hello"}, database.DNS_TABLE_NAME)
+	assert.Nil(t, SetDNS())
+
+	// without split dns the zones are served from their zone files too, the hosts file can not hold the other records
+	corefile, err := ioutil.ReadFile(filepath.Join("config", "dnsconfig", "Corefile"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(corefile), "skynet {")
+	assert.Contains(t, string(corefile), "file /root/dnsconfig/skynet.db")
+	zonefile, err := ioutil.ReadFile(filepath.Join("config", "dnsconfig", "skynet.db"))
+	assert.Nil(t, err)
+	for _, record := range []string{"\tCNAME\t", "\tSRV\t", "\tTXT\t"} {
+		assert.Contains(t, string(zonefile), record)
+	}
+	hosts, err := ioutil.ReadFile(filepath.Join("config", "dnsconfig", "netmaker.hosts"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(hosts), "node1.skynet")
+}
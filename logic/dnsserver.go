@@ -0,0 +1,428 @@
+package logic
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/miekg/dns"
+)
+
+// DNS_DEFAULT_TTL - ttl of the records served by the embedded dns server
+const DNS_DEFAULT_TTL = 300
+
+// DNS_ZONE_REFRESH - how often the embedded dns server reloads its zones from the database
+const DNS_ZONE_REFRESH = 15 * time.Second
+
+// DNS_UPSTREAM_TIMEOUT - how long to wait on an upstream resolver before trying the next one
+const DNS_UPSTREAM_TIMEOUT = 2 * time.Second
+
+// max number of CNAMEs followed inside the served zones for a single answer
+const maxCNAMEChain = 8
+
+// dnsZone - the records of one network, keyed by lower case fqdn
+type dnsZone struct {
+	origin  string
+	records map[string][]dns.RR
+}
+
+// currently served zones keyed by origin, and the networks they belong to. zones are never modified once published
+var dnsZones = struct {
+	sync.RWMutex
+	zones    map[string]*dnsZone
+	networks []models.Network
+}{zones: make(map[string]*dnsZone)}
+
+// DNSServer - embedded authoritative dns server for the network zones
+type DNSServer struct {
+	servers []*dns.Server
+	stop    chan struct{}
+}
+
+// StartDNSServer - loads the zones and serves them over udp and tcp on addr
+// queries outside of the network zones are forwarded to upstreams
+func StartDNSServer(addr string, upstreams []string) (*DNSServer, error) {
+	if err := LoadDNSZones(); err != nil {
+		Log("error loading dns zones: "+err.Error(), 0)
+	}
+	packetConn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		packetConn.Close()
+		return nil, err
+	}
+	handler := &dnsHandler{upstreams: upstreams}
+	server := &DNSServer{
+		servers: []*dns.Server{
+			{PacketConn: packetConn, Handler: handler},
+			{Listener: listener, Handler: handler},
+		},
+		stop: make(chan struct{}),
+	}
+	for _, s := range server.servers {
+		go func(s *dns.Server) {
+			if err := s.ActivateAndServe(); err != nil {
+				Log("dns server stopped: "+err.Error(), 1)
+			}
+		}(s)
+	}
+	go server.refreshZones()
+	return server, nil
+}
+
+// DNSServer.Shutdown - stops serving and reloading zones
+func (server *DNSServer) Shutdown() {
+	close(server.stop)
+	for _, s := range server.servers {
+		if err := s.Shutdown(); err != nil {
+			Log("error stopping dns server: "+err.Error(), 1)
+		}
+	}
+}
+
+// node changes pushed over gRPC do not all go through SetDNS, so reload on an interval as well
+func (server *DNSServer) refreshZones() {
+	ticker := time.NewTicker(DNS_ZONE_REFRESH)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-server.stop:
+			return
+		case <-ticker.C:
+			if err := LoadDNSZones(); err != nil {
+				Log("error reloading dns zones: "+err.Error(), 1)
+			}
+		}
+	}
+}
+
+// LoadDNSZones - rebuilds the zones served by the embedded dns server from the database
+func LoadDNSZones() error {
+	networks, zones, err := buildDNSZones()
+	if err != nil {
+		return err
+	}
+	zonemap := make(map[string]*dnsZone, len(zones))
+	for _, zone := range zones {
+		zonemap[zone.origin] = zone
+	}
+	dnsZones.Lock()
+	dnsZones.zones = zonemap
+	dnsZones.networks = networks
+	dnsZones.Unlock()
+	return nil
+}
+
+// buildDNSZones - builds the zone of every network, in the order of the returned networks
+func buildDNSZones() ([]models.Network, []*dnsZone, error) {
+	networks, err := GetNetworks()
+	if err != nil && !database.IsEmptyRecord(err) {
+		return nil, nil, err
+	}
+	zones := make([]*dnsZone, 0, len(networks))
+	for _, network := range networks {
+		entries, err := GetDNS(network.NetID)
+		if err != nil && !database.IsEmptyRecord(err) {
+			return nil, nil, err
+		}
+		zone := newDNSZone(network.GetDNSZone())
+		for _, entry := range entries {
+			if err := zone.add(entry); err != nil {
+				Log("skipping dns entry "+entry.Name+"."+entry.Network+": "+err.Error(), 2)
+			}
+		}
+		zones = append(zones, zone)
+	}
+	return networks, zones, nil
+}
+
+func newDNSZone(name string) *dnsZone {
+	origin := dns.Fqdn(strings.ToLower(name))
+	zone := &dnsZone{
+		origin:  origin,
+		records: make(map[string][]dns.RR),
+	}
+	zone.records[origin] = []dns.RR{&dns.SOA{
+		Hdr:     dnsHeader(origin, dns.TypeSOA),
+		Ns:      "ns." + origin,
+		Mbox:    "hostmaster." + origin,
+		Serial:  uint32(time.Now().Unix()),
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  86400,
+		Minttl:  DNS_DEFAULT_TTL,
+	}}
+	return zone
+}
+
+func (zone *dnsZone) add(entry models.DNSEntry) error {
+	name := dns.Fqdn(strings.ToLower(entry.Name)) + zone.origin
+	if _, ok := dns.IsDomainName(name); !ok {
+		return errors.New("invalid domain name " + name)
+	}
+	recordType := entry.RecordType()
+	header := dnsHeader(name, dns.StringToType[recordType])
+	if entry.TTL > 0 {
+		header.Ttl = entry.TTL
+	}
+	var rr dns.RR
+	switch recordType {
+	case models.DNS_RECORD_A, models.DNS_RECORD_AAAA:
+		ip := net.ParseIP(entry.Address)
+		if ip == nil {
+			return errors.New("invalid address " + entry.Address)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			header.Rrtype = dns.TypeA
+			rr = &dns.A{Hdr: header, A: ip4}
+		} else {
+			header.Rrtype = dns.TypeAAAA
+			rr = &dns.AAAA{Hdr: header, AAAA: ip}
+		}
+	case models.DNS_RECORD_CNAME:
+		target, err := dnsTarget(entry.Target)
+		if err != nil {
+			return err
+		}
+		if len(zone.records[name]) > 0 {
+			return errors.New("a CNAME can not share its name with other records")
+		}
+		rr = &dns.CNAME{Hdr: header, Target: target}
+	case models.DNS_RECORD_SRV:
+		target, err := dnsTarget(entry.Target)
+		if err != nil {
+			return err
+		}
+		rr = &dns.SRV{Hdr: header, Priority: entry.Priority, Weight: entry.Weight, Port: entry.Port, Target: target}
+	case models.DNS_RECORD_TXT:
+		rr = &dns.TXT{Hdr: header, Txt: splitTXT(entry.Text)}
+	default:
+		return errors.New("unsupported record type " + recordType)
+	}
+	for _, existing := range zone.records[name] {
+		if existing.Header().Rrtype == dns.TypeCNAME {
+			return errors.New("a CNAME can not share its name with other records")
+		}
+	}
+	zone.records[name] = append(zone.records[name], rr)
+	return nil
+}
+
+// zone file representation of the zone, soa first and the other names sorted
+func (zone *dnsZone) String() string {
+	var zonefile strings.Builder
+	zonefile.WriteString("$ORIGIN " + zone.origin + "\n")
+	for _, name := range zone.names() {
+		for _, rr := range zone.records[name] {
+			zonefile.WriteString(rr.String() + "\n")
+		}
+	}
+	return zonefile.String()
+}
+
+// names - the names in the zone, origin first and the others sorted
+func (zone *dnsZone) names() []string {
+	names := make([]string, 0, len(zone.records))
+	for name := range zone.records {
+		if name != zone.origin {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{zone.origin}, names...)
+}
+
+func dnsTarget(target string) (string, error) {
+	target = dns.Fqdn(strings.ToLower(target))
+	if _, ok := dns.IsDomainName(target); !ok || target == "." {
+		return "", errors.New("invalid target " + target)
+	}
+	return target, nil
+}
+
+// TXT character strings are limited to 255 bytes, longer text is split over several strings
+func splitTXT(text string) []string {
+	var txt []string
+	for len(text) > 255 {
+		txt = append(txt, text[:255])
+		text = text[255:]
+	}
+	return append(txt, text)
+}
+
+func (zone *dnsZone) soa() dns.RR {
+	return zone.records[zone.origin][0]
+}
+
+func dnsHeader(name string, rrtype uint16) dns.RR_Header {
+	return dns.RR_Header{Name: name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: DNS_DEFAULT_TTL}
+}
+
+// findDNSZone - returns the most specific served zone containing name, nil if none
+func findDNSZone(name string) *dnsZone {
+	name = strings.ToLower(dns.Fqdn(name))
+	dnsZones.RLock()
+	defer dnsZones.RUnlock()
+	for offset, end := 0, false; !end; offset, end = dns.NextLabel(name, offset) {
+		if zone, ok := dnsZones.zones[name[offset:]]; ok {
+			return zone
+		}
+	}
+	return nil
+}
+
+type dnsHandler struct {
+	upstreams []string
+}
+
+// dnsHandler.ServeDNS - answers authoritatively for the network zones and forwards everything else
+func (handler *dnsHandler) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	reply := new(dns.Msg)
+	if len(req.Question) != 1 {
+		reply.SetRcode(req, dns.RcodeFormatError)
+		w.WriteMsg(reply)
+		return
+	}
+	question := req.Question[0]
+	zone := findDNSZone(question.Name)
+	if zone == nil {
+		handler.forward(w, req)
+		return
+	}
+	reply.SetReply(req)
+	reply.Authoritative = true
+	reply.RecursionAvailable = len(handler.clientUpstreams(w.RemoteAddr())) > 0
+	answer(reply, zone, question)
+	if _, isUDP := w.RemoteAddr().(*net.UDPAddr); isUDP {
+		size := dns.MinMsgSize
+		if opt := req.IsEdns0(); opt != nil {
+			size = int(opt.UDPSize())
+		}
+		reply.Truncate(size)
+	}
+	w.WriteMsg(reply)
+}
+
+// answer - fills the reply from the served zones, following CNAMEs that stay inside them
+func answer(reply *dns.Msg, zone *dnsZone, question dns.Question) {
+	name := strings.ToLower(question.Name)
+	for depth := 0; depth < maxCNAMEChain; depth++ {
+		records, found := zone.records[name]
+		if !found {
+			if len(reply.Answer) == 0 {
+				reply.Rcode = dns.RcodeNameError
+			}
+			break
+		}
+		var cname *dns.CNAME
+		matched := false
+		for _, rr := range records {
+			if question.Qtype == dns.TypeANY || rr.Header().Rrtype == question.Qtype {
+				reply.Answer = append(reply.Answer, rr)
+				matched = true
+			} else if alias, ok := rr.(*dns.CNAME); ok {
+				cname = alias
+			}
+		}
+		if matched || cname == nil {
+			break
+		}
+		reply.Answer = append(reply.Answer, cname)
+		name = strings.ToLower(cname.Target)
+		if zone = findDNSZone(name); zone == nil {
+			return
+		}
+	}
+	if len(reply.Answer) == 0 {
+		reply.Ns = append(reply.Ns, zone.soa())
+	}
+}
+
+func (handler *dnsHandler) forward(w dns.ResponseWriter, req *dns.Msg) {
+	client := &dns.Client{Net: "udp", Timeout: DNS_UPSTREAM_TIMEOUT}
+	if _, isTCP := w.RemoteAddr().(*net.TCPAddr); isTCP {
+		client.Net = "tcp"
+	}
+	upstreams := handler.clientUpstreams(w.RemoteAddr())
+	for _, upstream := range upstreams {
+		response, _, err := client.Exchange(req, upstream)
+		if err == nil {
+			w.WriteMsg(response)
+			return
+		}
+		Log("dns upstream "+upstream+" failed: "+err.Error(), 3)
+	}
+	reply := new(dns.Msg)
+	if len(upstreams) == 0 {
+		reply.SetRcode(req, dns.RcodeRefused)
+	} else {
+		reply.SetRcode(req, dns.RcodeServerFailure)
+	}
+	w.WriteMsg(reply)
+}
+
+// dnsHandler.clientUpstreams - the upstreams the queries of a client are forwarded to, only clients inside a network
+// or on this host get any, so the server does not resolve names for whoever can reach it
+func (handler *dnsHandler) clientUpstreams(addr net.Addr) []string {
+	if network := findDNSNetwork(addr); network != nil {
+		if network.DNSForward != "yes" {
+			return nil
+		}
+		if len(network.DNSUpstreams) > 0 {
+			return dnsUpstreams(network.DNSUpstreams)
+		}
+		return handler.upstreams
+	}
+	if ip := remoteIP(addr); ip != nil && ip.IsLoopback() {
+		return handler.upstreams
+	}
+	return nil
+}
+
+// findDNSNetwork - returns the network whose address range contains the client, nil if none
+func findDNSNetwork(addr net.Addr) *models.Network {
+	ip := remoteIP(addr)
+	if ip == nil {
+		return nil
+	}
+	dnsZones.RLock()
+	defer dnsZones.RUnlock()
+	for i := range dnsZones.networks {
+		network := &dnsZones.networks[i]
+		for _, addressRange := range []string{network.AddressRange, network.AddressRange6} {
+			if _, cidr, err := net.ParseCIDR(addressRange); err == nil && cidr.Contains(ip) {
+				return network
+			}
+		}
+	}
+	return nil
+}
+
+// remoteIP - the ip of a client address, nil if it has none
+func remoteIP(addr net.Addr) net.IP {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+// dnsUpstreams - adds the default dns port to upstreams without one
+func dnsUpstreams(upstreams []string) []string {
+	servers := make([]string, 0, len(upstreams))
+	for _, upstream := range upstreams {
+		if _, _, err := net.SplitHostPort(upstream); err != nil {
+			upstream = net.JoinHostPort(upstream, "53")
+		}
+		servers = append(servers, upstream)
+	}
+	return servers
+}
@@ -0,0 +1,215 @@
+package logic
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/gravitl/netmaker/models"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func setTestZone(t *testing.T, entries ...models.DNSEntry) *dnsZone {
+	zone := newDNSZone("skynet")
+	for _, entry := range entries {
+		assert.Nil(t, zone.add(entry))
+	}
+	dnsZones.Lock()
+	dnsZones.zones = map[string]*dnsZone{zone.origin: zone}
+	dnsZones.Unlock()
+	return zone
+}
+
+func query(name string, qtype uint16) *dns.Msg {
+	req := new(dns.Msg)
+	req.SetQuestion(name, qtype)
+	reply := new(dns.Msg)
+	reply.SetReply(req)
+	if zone := findDNSZone(name); zone != nil {
+		answer(reply, zone, req.Question[0])
+	}
+	return reply
+}
+
+func TestFindDNSZone(t *testing.T) {
+	setTestZone(t)
+	t.Run("InZone", func(t *testing.T) {
+		zone := findDNSZone("Node1.SkyNet.")
+		assert.NotNil(t, zone)
+		assert.Equal(t, "skynet.", zone.origin)
+	})
+	t.Run("Apex", func(t *testing.T) {
+		assert.NotNil(t, findDNSZone("skynet"))
+	})
+	t.Run("OutOfZone", func(t *testing.T) {
+		assert.Nil(t, findDNSZone("example.com."))
+		assert.Nil(t, findDNSZone("notskynet."))
+	})
+}
+
+func TestDNSAnswer(t *testing.T) {
+	setTestZone(t,
+		models.DNSEntry{Address: "10.0.0.1", Name: "node1", Network: "skynet"},
+		models.DNSEntry{Address: "fd00::1", Name: "node1", Network: "skynet"},
+	)
+	t.Run("A", func(t *testing.T) {
+		reply := query("node1.skynet.", dns.TypeA)
+		assert.Equal(t, dns.RcodeSuccess, reply.Rcode)
+		assert.Equal(t, 1, len(reply.Answer))
+		assert.Equal(t, "10.0.0.1", reply.Answer[0].(*dns.A).A.String())
+	})
+	t.Run("AAAA", func(t *testing.T) {
+		reply := query("NODE1.skynet.", dns.TypeAAAA)
+		assert.Equal(t, 1, len(reply.Answer))
+		assert.Equal(t, "fd00::1", reply.Answer[0].(*dns.AAAA).AAAA.String())
+	})
+	t.Run("NoData", func(t *testing.T) {
+		reply := query("node1.skynet.", dns.TypeMX)
+		assert.Equal(t, dns.RcodeSuccess, reply.Rcode)
+		assert.Equal(t, 0, len(reply.Answer))
+		assert.Equal(t, 1, len(reply.Ns))
+	})
+	t.Run("NXDomain", func(t *testing.T) {
+		reply := query("missing.skynet.", dns.TypeA)
+		assert.Equal(t, dns.RcodeNameError, reply.Rcode)
+		assert.Equal(t, dns.TypeSOA, reply.Ns[0].Header().Rrtype)
+	})
+	t.Run("SOA", func(t *testing.T) {
+		reply := query("skynet.", dns.TypeSOA)
+		assert.Equal(t, 1, len(reply.Answer))
+	})
+}
+
+func TestDNSAnswerCNAME(t *testing.T) {
+	zone := setTestZone(t, models.DNSEntry{Address: "10.0.0.1", Name: "node1", Network: "skynet"})
+	alias := &dns.CNAME{Hdr: dnsHeader("www.skynet.", dns.TypeCNAME), Target: "node1.skynet."}
+	zone.records["www.skynet."] = []dns.RR{alias}
+	t.Run("Followed", func(t *testing.T) {
+		reply := query("www.skynet.", dns.TypeA)
+		assert.Equal(t, 2, len(reply.Answer))
+		assert.Equal(t, dns.TypeCNAME, reply.Answer[0].Header().Rrtype)
+		assert.Equal(t, dns.TypeA, reply.Answer[1].Header().Rrtype)
+	})
+	t.Run("Direct", func(t *testing.T) {
+		reply := query("www.skynet.", dns.TypeCNAME)
+		assert.Equal(t, 1, len(reply.Answer))
+	})
+	t.Run("Loop", func(t *testing.T) {
+		loop := &dns.CNAME{Hdr: dnsHeader("loop.skynet.", dns.TypeCNAME), Target: "loop.skynet."}
+		zone.records["loop.skynet."] = []dns.RR{loop}
+		reply := query("loop.skynet.", dns.TypeA)
+		assert.Equal(t, maxCNAMEChain, len(reply.Answer))
+	})
+}
+
+func TestDNSZoneAdd(t *testing.T) {
+	zone := newDNSZone("skynet")
+	t.Run("TTL", func(t *testing.T) {
+		err := zone.add(models.DNSEntry{Address: "10.0.0.1", Name: "node1", Network: "skynet", TTL: 60})
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(60), zone.records["node1.skynet."][0].Header().Ttl)
+	})
+	t.Run("CNAME", func(t *testing.T) {
+		err := zone.add(models.DNSEntry{Name: "www", Network: "skynet", Type: models.DNS_RECORD_CNAME, Target: "node1.skynet"})
+		assert.Nil(t, err)
+		assert.Equal(t, "node1.skynet.", zone.records["www.skynet."][0].(*dns.CNAME).Target)
+	})
+	t.Run("CNAMEConflict", func(t *testing.T) {
+		err := zone.add(models.DNSEntry{Name: "node1", Network: "skynet", Type: models.DNS_RECORD_CNAME, Target: "www.skynet"})
+		assert.NotNil(t, err)
+		err = zone.add(models.DNSEntry{Name: "www", Network: "skynet", Type: models.DNS_RECORD_TXT, Text: "hello"})
+		assert.NotNil(t, err)
+	})
+	t.Run("SRV", func(t *testing.T) {
+		err := zone.add(models.DNSEntry{Name: "_sip._udp", Network: "skynet", Type: models.DNS_RECORD_SRV, Target: "node1.skynet", Priority: 10, Weight: 5, Port: 5060})
+		assert.Nil(t, err)
+		srv := zone.records["_sip._udp.skynet."][0].(*dns.SRV)
+		assert.Equal(t, uint16(5060), srv.Port)
+		assert.Equal(t, "node1.skynet.", srv.Target)
+	})
+	t.Run("LongTXT", func(t *testing.T) {
+		text := ""
+		for i := 0; i < 300; i++ {
+			text = text + "a"
+		}
+		err := zone.add(models.DNSEntry{Name: "txt", Network: "skynet", Type: models.DNS_RECORD_TXT, Text: text})
+		assert.Nil(t, err)
+		txt := zone.records["txt.skynet."][0].(*dns.TXT)
+		assert.Equal(t, 2, len(txt.Txt))
+		assert.Equal(t, 255, len(txt.Txt[0]))
+	})
+	t.Run("ZoneFile", func(t *testing.T) {
+		zonefile := zone.String()
+		assert.Contains(t, zonefile, "$ORIGIN skynet.")
+		for _, line := range []string{"node1.skynet.\t60\tIN\tA\t10.0.0.1", "www.skynet.\t300\tIN\tCNAME\tnode1.skynet.", "_sip._udp.skynet.\t300\tIN\tSRV\t10 5 5060 node1.skynet."} {
+			assert.Contains(t, zonefile, line)
+		}
+		parser := dns.NewZoneParser(strings.NewReader(zonefile), "", "")
+		parsed := 0
+		for _, ok := parser.Next(); ok; _, ok = parser.Next() {
+			parsed++
+		}
+		assert.Nil(t, parser.Err())
+		assert.Equal(t, 5, parsed)
+	})
+}
+
+func TestFindDNSNetwork(t *testing.T) {
+	dnsZones.Lock()
+	dnsZones.networks = []models.Network{
+		{NetID: "skynet", AddressRange: "10.0.0.0/24", AddressRange6: "fd00::/64", DNSForward: "yes"},
+		{NetID: "wirecat", AddressRange: "10.10.0.0/24"},
+	}
+	dnsZones.Unlock()
+	t.Run("IPv4", func(t *testing.T) {
+		network := findDNSNetwork(&net.UDPAddr{IP: net.ParseIP("10.10.0.5"), Port: 5353})
+		assert.NotNil(t, network)
+		assert.Equal(t, "wirecat", network.NetID)
+	})
+	t.Run("IPv6", func(t *testing.T) {
+		network := findDNSNetwork(&net.TCPAddr{IP: net.ParseIP("fd00::5"), Port: 5353})
+		assert.NotNil(t, network)
+		assert.Equal(t, "skynet", network.NetID)
+	})
+	t.Run("NoNetwork", func(t *testing.T) {
+		assert.Nil(t, findDNSNetwork(&net.UDPAddr{IP: net.ParseIP("192.168.1.1"), Port: 5353}))
+	})
+}
+
+func TestDNSClientUpstreams(t *testing.T) {
+	dnsZones.Lock()
+	dnsZones.networks = []models.Network{
+		{NetID: "skynet", AddressRange: "10.0.0.0/24", DNSForward: "yes"},
+		{NetID: "wirecat", AddressRange: "10.10.0.0/24", DNSForward: "yes", DNSUpstreams: []string{"9.9.9.9"}},
+		{NetID: "closed", AddressRange: "10.20.0.0/24", DNSForward: "no"},
+	}
+	dnsZones.Unlock()
+	handler := &dnsHandler{upstreams: []string{"1.1.1.1:53"}}
+	for address, upstreams := range map[string][]string{
+		"10.0.0.5":    {"1.1.1.1:53"},
+		"10.10.0.5":   {"9.9.9.9:53"},
+		"10.20.0.5":   nil,
+		"127.0.0.1":   {"1.1.1.1:53"},
+		"::1":         {"1.1.1.1:53"},
+		"203.0.113.7": nil,
+	} {
+		assert.Equal(t, upstreams, handler.clientUpstreams(&net.UDPAddr{IP: net.ParseIP(address), Port: 5353}), address)
+	}
+}
+
+func TestDNSSettingsChanged(t *testing.T) {
+	current := models.Network{NetID: "skynet", DNSUpstreams: []string{"1.1.1.1"}}
+	t.Run("Unchanged", func(t *testing.T) {
+		update := models.Network{NetID: "skynet", DNSZoneSuffix: "skynet.", DNSUpstreams: []string{"1.1.1.1"}}
+		assert.False(t, DNSSettingsChanged(&current, &update))
+	})
+	t.Run("Suffix", func(t *testing.T) {
+		update := models.Network{NetID: "skynet", DNSZoneSuffix: "corp.internal", DNSUpstreams: []string{"1.1.1.1"}}
+		assert.True(t, DNSSettingsChanged(&current, &update))
+	})
+	t.Run("Upstreams", func(t *testing.T) {
+		update := models.Network{NetID: "skynet", DNSUpstreams: []string{"1.1.1.1", "9.9.9.9"}}
+		assert.True(t, DNSSettingsChanged(&current, &update))
+	})
+}
@@ -0,0 +1,263 @@
+package logic
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+)
+
+// EVENT_POLL_INTERVAL - how often the changes made by the other server instances are read from the database
+const EVENT_POLL_INTERVAL = 2 * time.Second
+
+// EVENT_RETENTION - how long the changes are kept in the event log of the database for the other instances to read
+const EVENT_RETENTION = 10 * time.Minute
+
+// EVENT_GAP_TIMEOUT - how long a poll waits for an entry of the event log whose number was skipped, the transaction storing it
+// may commit after entries with higher numbers, and the numbers of failed transactions are never used
+const EVENT_GAP_TIMEOUT = 5 * EVENT_POLL_INTERVAL
+
+// eventTypes - the tables whose changes are published, with the type of their events
+var eventTypes = map[string]string{
+	database.NODES_TABLE_NAME:      models.EVENT_NODE,
+	database.NETWORKS_TABLE_NAME:   models.EVENT_NETWORK,
+	database.EXT_CLIENT_TABLE_NAME: models.EVENT_EXT_CLIENT,
+	database.DNS_TABLE_NAME:        models.EVENT_DNS,
+	database.PEERS_TABLE_NAME:      models.EVENT_PEERS,
+}
+
+var eventSubscribers struct {
+	mutex    sync.RWMutex
+	next     int
+	handlers map[int]func(models.Event)
+}
+
+// eventCursor - how far the event log has been read, every entry up to the sequence was read, entries after it that were
+// read already are in read, and the numbers skipped in between are in missing with the time they were first missed
+var eventCursor struct {
+	mutex    sync.Mutex
+	started  bool
+	sequence int64
+	read     map[int64]bool
+	missing  map[int64]time.Time
+}
+
+// nodeStates - a hash of each node as this instance last stored it, without its check in times, to tell check ins apart from changes
+var nodeStates struct {
+	mutex  sync.Mutex
+	hashes map[string][sha256.Size]byte
+}
+
+func init() {
+	database.OnChange(publishChange)
+}
+
+// SubscribeEvents - calls a handler for every change event, made by this server instance or by the others sharing the database,
+// the handler runs on the goroutine that made or read the change so it should be quick, returns a function ending the subscription
+func SubscribeEvents(handler func(models.Event)) func() {
+	eventSubscribers.mutex.Lock()
+	defer eventSubscribers.mutex.Unlock()
+	if eventSubscribers.handlers == nil {
+		eventSubscribers.handlers = make(map[int]func(models.Event))
+	}
+	id := eventSubscribers.next
+	eventSubscribers.next++
+	eventSubscribers.handlers[id] = handler
+	return func() {
+		eventSubscribers.mutex.Lock()
+		defer eventSubscribers.mutex.Unlock()
+		delete(eventSubscribers.handlers, id)
+	}
+}
+
+// PublishEvent - sends an event to the subscribers of this instance, and appends it to the event log for the other instances
+// when the database is shared
+func PublishEvent(event models.Event) {
+	dispatchEvent(event)
+	if !servercfg.IsSharedDB() || !database.HasEventLog() {
+		return
+	}
+	data, err := json.Marshal(&event)
+	if err == nil {
+		err = database.AppendEvent(string(data), event.Time)
+	}
+	if err != nil {
+		Log("error storing the "+event.Type+" event of "+event.Key+": "+err.Error(), 1)
+	}
+}
+
+// PollEvents - sends the changes the other server instances appended to the event log since the last poll to the subscribers,
+// oldest first, the first poll only finds where the log ends
+func PollEvents() error {
+	eventCursor.mutex.Lock()
+	if !eventCursor.started {
+		sequence, err := database.LastEventSequence()
+		if err == nil {
+			eventCursor.started = true
+			eventCursor.sequence = sequence
+			eventCursor.read = make(map[int64]bool)
+			eventCursor.missing = make(map[int64]time.Time)
+		}
+		eventCursor.mutex.Unlock()
+		return err
+	}
+	entries, err := database.FetchEventsSince(eventCursor.sequence)
+	if err != nil {
+		eventCursor.mutex.Unlock()
+		return err
+	}
+	serverID := servercfg.GetServerID()
+	var events []models.Event
+	highest := eventCursor.sequence
+	for _, entry := range entries {
+		if entry.Sequence > highest {
+			highest = entry.Sequence
+		}
+		if eventCursor.read[entry.Sequence] {
+			continue
+		}
+		eventCursor.read[entry.Sequence] = true
+		delete(eventCursor.missing, entry.Sequence)
+		var event models.Event
+		if err := json.Unmarshal([]byte(entry.Value), &event); err == nil && event.Server != serverID {
+			events = append(events, event)
+		}
+	}
+	now := time.Now()
+	for sequence := eventCursor.sequence + 1; sequence < highest; sequence++ {
+		if eventCursor.read[sequence] {
+			continue
+		}
+		if missed, ok := eventCursor.missing[sequence]; !ok {
+			eventCursor.missing[sequence] = now
+		} else if now.Sub(missed) >= EVENT_GAP_TIMEOUT {
+			eventCursor.read[sequence] = true
+			delete(eventCursor.missing, sequence)
+		}
+	}
+	for eventCursor.read[eventCursor.sequence+1] {
+		eventCursor.sequence++
+		delete(eventCursor.read, eventCursor.sequence)
+	}
+	eventCursor.mutex.Unlock()
+
+	for _, event := range events {
+		if event.Type == models.EVENT_NODE {
+			forgetNodeState(event.Key)
+		}
+		dispatchEvent(event)
+	}
+	return nil
+}
+
+// PruneEvents - removes the changes older than an age from the event log, run by the leader
+func PruneEvents(age time.Duration) error {
+	if !database.HasEventLog() {
+		return nil
+	}
+	return database.PruneEventLog(time.Now().Add(-age).Unix())
+}
+
+// publishChange - publishes the change of a record of a table with events
+func publishChange(tableName string, key string, value string) {
+	eventType, ok := eventTypes[tableName]
+	if !ok {
+		return
+	}
+	event := models.Event{
+		ID:      uuid.NewString(),
+		Type:    eventType,
+		Action:  models.EVENT_UPDATED,
+		Key:     key,
+		Network: eventNetwork(tableName, key, value),
+		Server:  servercfg.GetServerID(),
+		Time:    time.Now().Unix(),
+		Value:   value,
+	}
+	if value == "" {
+		event.Action = models.EVENT_DELETED
+	}
+	if onlyCheckedIn(tableName, key, value) {
+		// the subscribers of this instance still get the new check in time, the other instances do not need it
+		dispatchEvent(event)
+		return
+	}
+	PublishEvent(event)
+}
+
+// eventNetwork - the network of a changed record, deleted records only have their key, which ends with the network
+func eventNetwork(tableName string, key string, value string) string {
+	if tableName == database.NETWORKS_TABLE_NAME || tableName == database.PEERS_TABLE_NAME {
+		return key
+	}
+	if network := networkField(key, value); network != "" {
+		return network
+	}
+	if i := strings.LastIndex(key, "###"); i >= 0 {
+		return key[i+3:]
+	}
+	return ""
+}
+
+// forgetNodeState - drops the hash of a node another instance changed, so the next write of the node by this instance is stored
+// even when it puts back a state this instance stored before
+func forgetNodeState(key string) {
+	nodeStates.mutex.Lock()
+	defer nodeStates.mutex.Unlock()
+	if key == "" {
+		nodeStates.hashes = nil
+		return
+	}
+	delete(nodeStates.hashes, key)
+}
+
+// onlyCheckedIn - checks if a node was stored with nothing changed but its check in and last modified times,
+// which every check in does, so the change is not worth a write to the event log
+func onlyCheckedIn(tableName string, key string, value string) bool {
+	if tableName != database.NODES_TABLE_NAME {
+		return false
+	}
+	nodeStates.mutex.Lock()
+	defer nodeStates.mutex.Unlock()
+	if key == "" {
+		nodeStates.hashes = nil
+		return false
+	}
+	var node models.Node
+	if value == "" || json.Unmarshal([]byte(value), &node) != nil {
+		delete(nodeStates.hashes, key)
+		return false
+	}
+	node.LastCheckIn = 0
+	node.LastModified = 0
+	data, err := json.Marshal(&node)
+	if err != nil {
+		delete(nodeStates.hashes, key)
+		return false
+	}
+	hash := sha256.Sum256(data)
+	if nodeStates.hashes == nil {
+		nodeStates.hashes = make(map[string][sha256.Size]byte)
+	}
+	previous, ok := nodeStates.hashes[key]
+	nodeStates.hashes[key] = hash
+	return ok && previous == hash
+}
+
+func dispatchEvent(event models.Event) {
+	eventSubscribers.mutex.RLock()
+	handlers := make([]func(models.Event), 0, len(eventSubscribers.handlers))
+	for _, handler := range eventSubscribers.handlers {
+		handlers = append(handlers, handler)
+	}
+	eventSubscribers.mutex.RUnlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
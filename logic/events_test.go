@@ -0,0 +1,159 @@
+package logic
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeEvents(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	var events []models.Event
+	unsubscribe := SubscribeEvents(func(event models.Event) {
+		events = append(events, event)
+	})
+
+	insertTestRecord(t, "node-1###skynet", models.Node{ID: "node-1###skynet", Network: "skynet"}, database.NODES_TABLE_NAME)
+	assert.Nil(t, database.DeleteRecord(database.NODES_TABLE_NAME, "node-1###skynet"))
+	insertTestRecord(t, "skynet", models.Network{NetID: "skynet"}, database.NETWORKS_TABLE_NAME)
+	insertTestRecord(t, "admin", models.User{UserName: "admin"}, database.USERS_TABLE_NAME)
+	assert.Equal(t, 3, len(events))
+	assert.Equal(t, models.EVENT_NODE, events[0].Type)
+	assert.Equal(t, models.EVENT_UPDATED, events[0].Action)
+	assert.Equal(t, "skynet", events[0].Network)
+	assert.Equal(t, servercfg.GetServerID(), events[0].Server)
+	assert.Equal(t, models.EVENT_DELETED, events[1].Action)
+	assert.Equal(t, "skynet", events[1].Network)
+	assert.Equal(t, models.EVENT_NETWORK, events[2].Type)
+	assert.Equal(t, "skynet", events[2].Network)
+
+	unsubscribe()
+	insertTestRecord(t, "node-2###skynet", models.Node{ID: "node-2###skynet", Network: "skynet"}, database.NODES_TABLE_NAME)
+	assert.Equal(t, 3, len(events))
+}
+
+func TestPollEvents(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	assert.Nil(t, PruneEvents(0))
+	eventCursor.started = false
+	os.Setenv("DATABASE", "postgres")
+	defer os.Unsetenv("DATABASE")
+	var events []models.Event
+	defer SubscribeEvents(func(event models.Event) {
+		events = append(events, event)
+	})()
+
+	older := models.Event{ID: "1", Type: models.EVENT_NODE, Key: "node-1###skynet", Server: "server-2", Time: time.Now().Unix()}
+	appendTestEvent(t, older)
+	assert.Nil(t, PollEvents())
+	assert.Nil(t, PollEvents())
+	assert.Empty(t, events)
+
+	t.Run("Other", func(t *testing.T) {
+		changed := models.Event{ID: "2", Type: models.EVENT_NODE, Key: "node-1###skynet", Server: "server-2", Time: time.Now().Unix()}
+		appendTestEvent(t, changed)
+		assert.Nil(t, PollEvents())
+		assert.Equal(t, []models.Event{changed}, events)
+		assert.Nil(t, PollEvents())
+		assert.Equal(t, 1, len(events))
+	})
+	t.Run("Own", func(t *testing.T) {
+		events = nil
+		// stored for the other instances, and only sent to the subscribers of this instance once
+		node := models.Node{ID: "node-1###skynet", Network: "skynet"}
+		insertTestRecord(t, node.ID, node, database.NODES_TABLE_NAME)
+		assert.Equal(t, 1, len(events))
+		sequence := eventCursor.sequence
+		entries, err := database.FetchEventsSince(sequence)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(entries))
+		assert.Contains(t, entries[0].Value, events[0].ID)
+		assert.Nil(t, PollEvents())
+		assert.Equal(t, 1, len(events))
+
+		// a check in reaches the subscribers of this instance but is not stored
+		node.LastCheckIn = time.Now().Unix()
+		node.LastModified = node.LastCheckIn
+		insertTestRecord(t, node.ID, node, database.NODES_TABLE_NAME)
+		assert.Equal(t, 2, len(events))
+		last, err := database.LastEventSequence()
+		assert.Nil(t, err)
+		assert.Equal(t, sequence+1, last)
+		node.Endpoint = "1.1.1.1"
+		insertTestRecord(t, node.ID, node, database.NODES_TABLE_NAME)
+		last, err = database.LastEventSequence()
+		assert.Nil(t, err)
+		assert.Equal(t, sequence+2, last)
+		assert.Nil(t, PollEvents())
+		assert.Equal(t, 3, len(events))
+	})
+	t.Run("OtherInstanceChanged", func(t *testing.T) {
+		events = nil
+		// this instance stores a node, another instance changes it, then this instance puts it back
+		node := models.Node{ID: "node-2###skynet", Network: "skynet", Endpoint: "1.1.1.1"}
+		insertTestRecord(t, node.ID, node, database.NODES_TABLE_NAME)
+		assert.Nil(t, PollEvents())
+		changed := node
+		changed.Endpoint = "2.2.2.2"
+		data, err := json.Marshal(&changed)
+		assert.Nil(t, err)
+		other := models.Event{ID: "6", Type: models.EVENT_NODE, Action: models.EVENT_UPDATED, Key: node.ID, Network: "skynet", Server: "server-2", Time: time.Now().Unix(), Value: string(data)}
+		appendTestEvent(t, other)
+		assert.Nil(t, PollEvents())
+		sequence, err := database.LastEventSequence()
+		assert.Nil(t, err)
+		node.LastCheckIn = time.Now().Unix()
+		insertTestRecord(t, node.ID, node, database.NODES_TABLE_NAME)
+		// the other instance has to read the node that is back to its earlier state
+		entries, err := database.FetchEventsSince(sequence)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(entries))
+		assert.Contains(t, entries[0].Value, node.ID)
+		assert.Nil(t, PollEvents())
+	})
+	t.Run("Gap", func(t *testing.T) {
+		events = nil
+		start := eventCursor.sequence
+		// the number of an entry that has not committed yet, or never will
+		skipped := models.Event{ID: "3", Type: models.EVENT_DNS, Key: "www###skynet", Server: "server-2", Time: time.Now().Add(-time.Hour).Unix()}
+		appendTestEvent(t, skipped)
+		assert.Nil(t, PruneEvents(EVENT_RETENTION))
+		late := models.Event{ID: "4", Type: models.EVENT_DNS, Key: "www###skynet", Server: "server-2", Time: time.Now().Unix()}
+		appendTestEvent(t, late)
+		assert.Nil(t, PollEvents())
+		assert.Equal(t, []models.Event{late}, events)
+		assert.Equal(t, start, eventCursor.sequence)
+		assert.Nil(t, PollEvents())
+		assert.Equal(t, 1, len(events))
+		// the skipped number is given up on after a while
+		eventCursor.missing[start+1] = time.Now().Add(-EVENT_GAP_TIMEOUT)
+		assert.Nil(t, PollEvents())
+		assert.Equal(t, 1, len(events))
+		assert.Equal(t, start+2, eventCursor.sequence)
+		assert.Empty(t, eventCursor.read)
+		assert.Empty(t, eventCursor.missing)
+	})
+	t.Run("Prune", func(t *testing.T) {
+		old := models.Event{ID: "5", Type: models.EVENT_DNS, Key: "www###skynet", Server: "server-2", Time: time.Now().Add(-time.Hour).Unix()}
+		appendTestEvent(t, old)
+		assert.Nil(t, PruneEvents(EVENT_RETENTION))
+		entries, err := database.FetchEventsSince(0)
+		assert.Nil(t, err)
+		for _, entry := range entries {
+			assert.NotContains(t, entry.Value, `"id":"5"`)
+		}
+		assert.NotEmpty(t, entries)
+	})
+}
+
+func appendTestEvent(t *testing.T, event models.Event) {
+	data, err := json.Marshal(&event)
+	assert.Nil(t, err)
+	assert.Nil(t, database.AppendEvent(string(data), event.Time))
+}
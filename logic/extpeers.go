@@ -8,7 +8,7 @@ import (
 )
 
 // GetExtPeersList - gets the ext peers lists
-func GetExtPeersList(macaddress string, networkName string) ([]models.ExtPeersResponse, error) {
+func GetExtPeersList(gatewayid string, networkName string) ([]models.ExtPeersResponse, error) {
 
 	var peers []models.ExtPeersResponse
 	records, err := database.FetchRecords(database.EXT_CLIENT_TABLE_NAME)
@@ -30,7 +30,7 @@ func GetExtPeersList(macaddress string, networkName string) ([]models.ExtPeersRe
 			Log("failed to unmarshal ext client", 2)
 			continue
 		}
-		if extClient.Network == networkName && extClient.IngressGatewayID == macaddress {
+		if extClient.Network == networkName && extClient.IngressGatewayID == gatewayid {
 			peers = append(peers, peer)
 		}
 	}
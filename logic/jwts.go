@@ -12,11 +12,11 @@ import (
 var jwtSecretKey = []byte("(BytesOverTheWire)")
 
 // CreateJWT func will used to create the JWT while signing in and signing out
-func CreateJWT(macaddress string, network string) (response string, err error) {
+func CreateJWT(nodeid string, network string) (response string, err error) {
 	expirationTime := time.Now().Add(5 * time.Minute)
 	claims := &models.Claims{
-		MacAddress: macaddress,
-		Network:    network,
+		ID:      nodeid,
+		Network: network,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expirationTime.Unix(),
 		},
@@ -73,7 +73,8 @@ func VerifyUserToken(tokenString string) (username string, networks []string, is
 }
 
 // VerifyToken - gRPC [nodes] Only
-func VerifyToken(tokenString string) (macaddress string, network string, err error) {
+// tokens issued before nodes had an id carry the mac address instead, GetNodeByID finds nodes by both
+func VerifyToken(tokenString string) (nodeid string, network string, err error) {
 	claims := &models.Claims{}
 
 	//this may be a stupid way of serving up a master key
@@ -87,7 +88,10 @@ func VerifyToken(tokenString string) (macaddress string, network string, err err
 	})
 
 	if token != nil {
-		return claims.MacAddress, claims.Network, nil
+		if claims.ID == "" {
+			return claims.MacAddress, claims.Network, nil
+		}
+		return claims.ID, claims.Network, nil
 	}
 	return "", "", err
 }
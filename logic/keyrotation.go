@@ -0,0 +1,156 @@
+package logic
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+// KeyRotationDue - the unix time the key of a node should be rotated at under the policy of its network,
+// 0 when the key is not rotated, the nodes are spread over the stagger hours of the network so they do not all rotate at once,
+// keys that were already due when the policy changed are spread from the time of the change
+func KeyRotationDue(network *models.Network, node *models.Node) int64 {
+	if network.KeyRotationDays <= 0 || node.IsStatic == "yes" || node.IsPending == "yes" {
+		return 0
+	}
+	due := node.KeyUpdateTimeStamp + int64(network.KeyRotationDays)*secondsPerDay
+	if due < network.KeyRotationChanged {
+		due = network.KeyRotationChanged
+	}
+	return due + keyRotationOffset(node.GetNodeID(), network.KeyRotationStaggerHours)
+}
+
+// keyRotationOffset - a delay from 0 up to the stagger hours that stays the same for a node
+func keyRotationOffset(nodeID string, staggerHours int32) int64 {
+	if staggerHours <= 0 {
+		return 0
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(nodeID))
+	return int64(hash.Sum32()) % (int64(staggerHours) * 60 * 60)
+}
+
+// SetKeyRotationPolicy - changes the key rotation policy of a network and keeps its other settings
+func SetKeyRotationPolicy(networkName string, policy models.KeyRotationPolicy) (models.Network, error) {
+	current, err := GetParentNetwork(networkName)
+	if err != nil {
+		return current, err
+	}
+	network := current
+	if policy.KeyRotationDays != nil {
+		network.KeyRotationDays = *policy.KeyRotationDays
+	}
+	if policy.KeyRotationStaggerHours != nil {
+		network.KeyRotationStaggerHours = *policy.KeyRotationStaggerHours
+	}
+	network.SetNetworkLastModified()
+	if _, _, err = UpdateNetwork(&current, &network); err != nil {
+		return current, err
+	}
+	return network, nil
+}
+
+// GetKeyRotationStatus - the key age of a node and when its rotation is due
+func GetKeyRotationStatus(network *models.Network, node *models.Node, now int64) models.KeyRotationStatus {
+	return models.KeyRotationStatus{
+		NodeID:             node.GetNodeID(),
+		Name:               node.Name,
+		Network:            node.Network,
+		PublicKey:          node.PublicKey,
+		KeyUpdateTimeStamp: node.KeyUpdateTimeStamp,
+		KeyAgeDays:         (now - node.KeyUpdateTimeStamp) / secondsPerDay,
+		DueTimeStamp:       KeyRotationDue(network, node),
+		RotationPending:    node.Action == models.NODE_UPDATE_KEY,
+	}
+}
+
+// GetOverdueKeys - the nodes of a network whose keys are past their rotation, oldest key first
+func GetOverdueKeys(networkName string, now int64) ([]models.KeyRotationStatus, error) {
+	network, err := GetParentNetwork(networkName)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := GetNetworkNodes(networkName)
+	if err != nil {
+		return nil, err
+	}
+	overdue := []models.KeyRotationStatus{}
+	for i := range nodes {
+		status := GetKeyRotationStatus(&network, &nodes[i], now)
+		if status.DueTimeStamp != 0 && status.DueTimeStamp <= now {
+			overdue = append(overdue, status)
+		}
+	}
+	sort.Slice(overdue, func(i, j int) bool {
+		return overdue[i].KeyUpdateTimeStamp < overdue[j].KeyUpdateTimeStamp
+	})
+	return overdue, nil
+}
+
+// RotateNetworkKeys - sets the update key action on the nodes of a network whose keys are due,
+// returns how many nodes were asked to rotate their keys
+func RotateNetworkKeys(network *models.Network, now int64) (int, error) {
+	nodes, err := GetNetworkNodes(network.NetID)
+	if err != nil {
+		return 0, err
+	}
+	rotated := 0
+	for _, node := range nodes {
+		due := KeyRotationDue(network, &node)
+		if due == 0 || due > now || node.Action == models.NODE_UPDATE_KEY || node.Action == models.NODE_DELETE {
+			continue
+		}
+		node.Action = models.NODE_UPDATE_KEY
+		data, err := json.Marshal(&node)
+		if err != nil {
+			return rotated, err
+		}
+		node.SetID()
+		if err = database.Insert(node.ID, string(data), database.NODES_TABLE_NAME); err != nil {
+			return rotated, err
+		}
+		rotated++
+	}
+	if rotated == 0 {
+		return 0, nil
+	}
+	network.KeyUpdateTimeStamp = now
+	data, err := json.Marshal(network)
+	if err != nil {
+		return rotated, err
+	}
+	return rotated, database.Insert(network.NetID, string(data), database.NETWORKS_TABLE_NAME)
+}
+
+// RotateKeys - runs the key rotation policy of every network, run by the server on the key rotation interval
+func RotateKeys() error {
+	networks, err := GetNetworks()
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return nil
+		}
+		return err
+	}
+	now := time.Now().Unix()
+	for i := range networks {
+		if networks[i].KeyRotationDays <= 0 {
+			continue
+		}
+		rotated, err := RotateNetworkKeys(&networks[i], now)
+		if err != nil {
+			Log("error rotating keys of network "+networks[i].NetID+": "+err.Error(), 1)
+			continue
+		}
+		if rotated > 0 {
+			Log("asked "+strconv.Itoa(rotated)+" nodes of network "+networks[i].NetID+" to rotate their keys", 1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package logic
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRotationDue(t *testing.T) {
+	network := models.Network{NetID: "skynet", KeyRotationDays: 30}
+	node := models.Node{UUID: "node-1", Network: "skynet", KeyUpdateTimeStamp: 1000}
+	t.Run("Disabled", func(t *testing.T) {
+		assert.Equal(t, int64(0), KeyRotationDue(&models.Network{NetID: "skynet"}, &node))
+	})
+	t.Run("Static", func(t *testing.T) {
+		static := node
+		static.IsStatic = "yes"
+		assert.Equal(t, int64(0), KeyRotationDue(&network, &static))
+	})
+	t.Run("NoStagger", func(t *testing.T) {
+		assert.Equal(t, int64(1000+30*secondsPerDay), KeyRotationDue(&network, &node))
+	})
+	t.Run("Staggered", func(t *testing.T) {
+		staggered := network
+		staggered.KeyRotationStaggerHours = 24
+		due := KeyRotationDue(&staggered, &node)
+		assert.GreaterOrEqual(t, due, int64(1000+30*secondsPerDay))
+		assert.Less(t, due, int64(1000+31*secondsPerDay))
+		assert.Equal(t, due, KeyRotationDue(&staggered, &node))
+		other := node
+		other.UUID = "node-2"
+		assert.NotEqual(t, due, KeyRotationDue(&staggered, &other))
+	})
+	t.Run("OverdueWhenChanged", func(t *testing.T) {
+		changed := network
+		changed.KeyRotationStaggerHours = 24
+		changed.KeyRotationChanged = 1000 + 40*secondsPerDay
+		due := KeyRotationDue(&changed, &node)
+		assert.GreaterOrEqual(t, due, changed.KeyRotationChanged)
+		assert.Less(t, due, changed.KeyRotationChanged+secondsPerDay)
+	})
+}
+
+func TestKeyRotationPolicyChanged(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	for _, table := range []string{database.NODES_TABLE_NAME, database.NETWORKS_TABLE_NAME} {
+		database.DeleteAllRecords(table)
+	}
+	network := models.Network{NetID: "skynet", AddressRange: "10.0.0.0/24"}
+	network.SetDefaults()
+	insertTestRecord(t, network.NetID, network, database.NETWORKS_TABLE_NAME)
+	// every key is far older than the policy about to be turned on
+	for i := 0; i < 20; i++ {
+		node := models.Node{UUID: "node-" + strconv.Itoa(i), Name: "node" + strconv.Itoa(i), Network: "skynet", KeyUpdateTimeStamp: 1000, Action: models.NODE_NOOP}
+		node.SetID()
+		insertTestRecord(t, node.ID, node, database.NODES_TABLE_NAME)
+	}
+	days, stagger := int32(30), int32(24)
+	before := time.Now().Unix()
+	updated, err := SetKeyRotationPolicy("skynet", models.KeyRotationPolicy{KeyRotationDays: &days, KeyRotationStaggerHours: &stagger})
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, updated.KeyRotationChanged, before)
+
+	// the overdue nodes rotate over the stagger hours, not all in the first run
+	rotated, err := RotateNetworkKeys(&updated, updated.KeyRotationChanged+12*60*60)
+	assert.Nil(t, err)
+	assert.Greater(t, rotated, 0)
+	assert.Less(t, rotated, 20)
+	more, err := RotateNetworkKeys(&updated, updated.KeyRotationChanged+int64(stagger)*60*60)
+	assert.Nil(t, err)
+	assert.Equal(t, 20, rotated+more)
+
+	// updates that leave the policy alone keep the time it changed
+	current, err := GetParentNetwork("skynet")
+	assert.Nil(t, err)
+	changed := current
+	changed.KeyRotationChanged = 0
+	changed.DisplayName = "renamed"
+	_, _, err = UpdateNetwork(&current, &changed)
+	assert.Nil(t, err)
+	assert.Equal(t, updated.KeyRotationChanged, changed.KeyRotationChanged)
+}
+
+func TestRotateNetworkKeys(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	for _, table := range []string{database.NODES_TABLE_NAME, database.NETWORKS_TABLE_NAME} {
+		database.DeleteAllRecords(table)
+	}
+	now := int64(100 * secondsPerDay)
+	network := models.Network{NetID: "skynet", KeyRotationDays: 30}
+	insertTestRecord(t, network.NetID, network, database.NETWORKS_TABLE_NAME)
+	old := models.Node{UUID: "old", Name: "old", Network: "skynet", KeyUpdateTimeStamp: now - 40*secondsPerDay, Action: models.NODE_NOOP}
+	older := models.Node{UUID: "older", Name: "older", Network: "skynet", KeyUpdateTimeStamp: now - 50*secondsPerDay, Action: models.NODE_NOOP}
+	fresh := models.Node{UUID: "fresh", Name: "fresh", Network: "skynet", KeyUpdateTimeStamp: now - 10*secondsPerDay, Action: models.NODE_NOOP}
+	static := models.Node{UUID: "static", Name: "static", Network: "skynet", IsStatic: "yes", Action: models.NODE_NOOP}
+	for _, node := range []models.Node{old, older, fresh, static} {
+		node.SetID()
+		insertTestRecord(t, node.ID, node, database.NODES_TABLE_NAME)
+	}
+
+	t.Run("Overdue", func(t *testing.T) {
+		overdue, err := GetOverdueKeys("skynet", now)
+		assert.Nil(t, err)
+		assert.Len(t, overdue, 2)
+		assert.Equal(t, "older", overdue[0].NodeID)
+		assert.Equal(t, int64(50), overdue[0].KeyAgeDays)
+		assert.Equal(t, "old", overdue[1].NodeID)
+		assert.False(t, overdue[1].RotationPending)
+	})
+	t.Run("Rotate", func(t *testing.T) {
+		rotated, err := RotateNetworkKeys(&network, now)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, rotated)
+		nodes, err := GetNetworkNodes("skynet")
+		assert.Nil(t, err)
+		for _, node := range nodes {
+			if node.UUID == "old" || node.UUID == "older" {
+				assert.Equal(t, models.NODE_UPDATE_KEY, node.Action)
+			} else {
+				assert.Equal(t, models.NODE_NOOP, node.Action)
+			}
+		}
+		stored, err := GetParentNetwork("skynet")
+		assert.Nil(t, err)
+		assert.Equal(t, now, stored.KeyUpdateTimeStamp)
+	})
+	t.Run("Repeated", func(t *testing.T) {
+		rotated, err := RotateNetworkKeys(&network, now)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, rotated)
+		overdue, err := GetOverdueKeys("skynet", now)
+		assert.Nil(t, err)
+		assert.Len(t, overdue, 2)
+		assert.True(t, overdue[0].RotationPending)
+	})
+}
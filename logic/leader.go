@@ -0,0 +1,225 @@
+package logic
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+)
+
+// LEADER_LEASE - the lease held by the server instance that runs the singleton tasks
+const LEADER_LEASE = "leader"
+
+// LEASE_DURATION - how long a lease is held without being renewed
+const LEASE_DURATION = 30 * time.Second
+
+// LEASE_RENEW_INTERVAL - how often the server instances renew their leases, well within the lease duration
+const LEASE_RENEW_INTERVAL = 10 * time.Second
+
+// instanceLeasePrefix - starts the lease each server instance keeps to show it is running
+const instanceLeasePrefix = "instance/"
+
+var serverStartTime = time.Now()
+
+// leaderState - the outcome of the last leader election of this server instance
+var leaderState struct {
+	mutex     sync.RWMutex
+	isLeader  bool
+	expiresAt time.Time
+	leader    string
+}
+
+// GetLease - gets a lease by its name
+func GetLease(name string) (models.Lease, error) {
+	var lease models.Lease
+	data, err := database.FetchRecord(database.LEASES_TABLE_NAME, name)
+	if err != nil {
+		return lease, err
+	}
+	err = json.Unmarshal([]byte(data), &lease)
+	return lease, err
+}
+
+// AcquireLease - takes a lease for a holder or renews it, a lease of another holder is only taken once it expired,
+// returns the lease as it is now and whether the holder has it
+func AcquireLease(name string, holder string, nodeID string, duration time.Duration) (models.Lease, bool, error) {
+	var lease models.Lease
+	current, err := database.FetchRecord(database.LEASES_TABLE_NAME, name)
+	if err != nil && !database.IsEmptyRecord(err) {
+		return lease, false, err
+	}
+	now := time.Now()
+	if current != "" {
+		if err = json.Unmarshal([]byte(current), &lease); err != nil {
+			return lease, false, err
+		}
+		if lease.Holder != holder && lease.ExpiresAt > now.Unix() {
+			return lease, false, nil
+		}
+	}
+	acquired := models.Lease{
+		Name:      name,
+		Holder:    holder,
+		NodeID:    nodeID,
+		Version:   servercfg.GetVersion(),
+		RenewedAt: now.Unix(),
+		ExpiresAt: now.Add(duration).Unix(),
+	}
+	data, err := json.Marshal(&acquired)
+	if err != nil {
+		return lease, false, err
+	}
+	// another instance may have taken the lease since it was read, then the swap fails and that instance keeps it
+	swapped, err := database.CompareAndSwap(name, current, string(data), database.LEASES_TABLE_NAME)
+	if err != nil || !swapped {
+		lease, _ = GetLease(name)
+		return lease, false, err
+	}
+	return acquired, true, nil
+}
+
+// ReleaseLease - lets a lease expire now when the holder has it, so another instance can take it without waiting
+func ReleaseLease(name string, holder string) error {
+	current, err := database.FetchRecord(database.LEASES_TABLE_NAME, name)
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return nil
+		}
+		return err
+	}
+	var lease models.Lease
+	if err = json.Unmarshal([]byte(current), &lease); err != nil {
+		return err
+	}
+	if lease.Holder != holder {
+		return nil
+	}
+	lease.ExpiresAt = time.Now().Unix()
+	data, err := json.Marshal(&lease)
+	if err != nil {
+		return err
+	}
+	_, err = database.CompareAndSwap(name, current, string(data), database.LEASES_TABLE_NAME)
+	return err
+}
+
+// GetServerInstances - gets the server instances whose lease has not expired, sorted by id
+func GetServerInstances() ([]models.ServerInstance, error) {
+	instances := []models.ServerInstance{}
+	records, err := database.FetchRecords(database.LEASES_TABLE_NAME)
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return instances, nil
+		}
+		return instances, err
+	}
+	now := time.Now().Unix()
+	leader := ""
+	var leaderLease models.Lease
+	if err = json.Unmarshal([]byte(records[LEADER_LEASE]), &leaderLease); err == nil && leaderLease.ExpiresAt > now {
+		leader = leaderLease.Holder
+	}
+	for name, value := range records {
+		if !strings.HasPrefix(name, instanceLeasePrefix) {
+			continue
+		}
+		var lease models.Lease
+		if err = json.Unmarshal([]byte(value), &lease); err != nil || lease.ExpiresAt <= now {
+			continue
+		}
+		role := models.SERVER_ROLE_FOLLOWER
+		if lease.Holder == leader {
+			role = models.SERVER_ROLE_LEADER
+		}
+		instances = append(instances, models.ServerInstance{
+			ID:       lease.Holder,
+			Role:     role,
+			Version:  lease.Version,
+			LastSeen: lease.RenewedAt,
+		})
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].ID < instances[j].ID
+	})
+	return instances, nil
+}
+
+// RunLeaderElection - renews the lease of this server instance, and takes or renews the leader lease when no other instance holds it,
+// returns whether this instance is the leader
+func RunLeaderElection() (bool, error) {
+	serverID := servercfg.GetServerID()
+	nodeID := servercfg.GetNodeID()
+	if _, _, err := AcquireLease(instanceLeasePrefix+serverID, serverID, nodeID, LEASE_DURATION); err != nil {
+		return IsServerLeader(), err
+	}
+	lease, acquired, err := AcquireLease(LEADER_LEASE, serverID, nodeID, LEASE_DURATION)
+	if err != nil {
+		// the lease held so far stays valid until it expires, IsServerLeader stops at that time
+		return IsServerLeader(), err
+	}
+
+	leaderState.mutex.Lock()
+	defer leaderState.mutex.Unlock()
+	if acquired != leaderState.isLeader || lease.Holder != leaderState.leader {
+		if acquired {
+			Log("this server instance, "+serverID+", is now the leader", 0)
+		} else {
+			Log("this server instance, "+serverID+", is a follower of "+lease.Holder, 0)
+		}
+	}
+	leaderState.isLeader = acquired
+	leaderState.leader = lease.Holder
+	leaderState.expiresAt = time.Unix(lease.ExpiresAt, 0)
+	return acquired, nil
+}
+
+// IsServerLeader - checks if this server instance holds the leader lease, it stops being the leader when the lease expires
+// without having been renewed
+func IsServerLeader() bool {
+	leaderState.mutex.RLock()
+	defer leaderState.mutex.RUnlock()
+	return leaderState.isLeader && time.Now().Before(leaderState.expiresAt)
+}
+
+// RunLeaderTasks - runs the tasks only the leader runs after each election, the dns files are rewritten as changes
+// made through the other instances are not written by them, and the events the instances have read are pruned
+func RunLeaderTasks() {
+	if err := FailOrphanedOperations(); err != nil {
+		Log("error cleaning up orphaned operations: "+err.Error(), 1)
+	}
+	if servercfg.IsDNSMode() && !servercfg.IsEmbeddedDNS() {
+		if err := SetDNS(); err != nil {
+			Log("error writing the dns files: "+err.Error(), 1)
+		}
+	}
+	if servercfg.IsSharedDB() {
+		if err := PruneEvents(EVENT_RETENTION); err != nil {
+			Log("error pruning the events: "+err.Error(), 1)
+		}
+	}
+}
+
+// GetServerHealth - gets the health and role of this server instance
+func GetServerHealth() models.ServerHealth {
+	health := models.ServerHealth{
+		ServerID: servercfg.GetServerID(),
+		Role:     models.SERVER_ROLE_FOLLOWER,
+		Version:  servercfg.GetVersion(),
+		Database: servercfg.GetDB(),
+		Uptime:   int64(time.Since(serverStartTime).Seconds()),
+	}
+	if IsServerLeader() {
+		health.Role = models.SERVER_ROLE_LEADER
+	}
+	leaderState.mutex.RLock()
+	health.Leader = leaderState.leader
+	leaderState.mutex.RUnlock()
+	_, err := database.FetchRecords(database.LEASES_TABLE_NAME)
+	health.DatabaseOK = err == nil || database.IsEmptyRecord(err)
+	return health
+}
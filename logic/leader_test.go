@@ -0,0 +1,146 @@
+package logic
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcquireLease(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	database.DeleteAllRecords(database.LEASES_TABLE_NAME)
+
+	lease, acquired, err := AcquireLease("task", "server-1", "node-1", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, "server-1", lease.Holder)
+
+	t.Run("Held", func(t *testing.T) {
+		lease, acquired, err := AcquireLease("task", "server-2", "node-2", time.Minute)
+		assert.Nil(t, err)
+		assert.False(t, acquired)
+		assert.Equal(t, "server-1", lease.Holder)
+	})
+	t.Run("Renew", func(t *testing.T) {
+		_, acquired, err := AcquireLease("task", "server-1", "node-1", time.Minute)
+		assert.Nil(t, err)
+		assert.True(t, acquired)
+	})
+	t.Run("Expired", func(t *testing.T) {
+		expired := models.Lease{Name: "task", Holder: "server-1", ExpiresAt: time.Now().Add(-time.Second).Unix()}
+		insertTestRecord(t, "task", expired, database.LEASES_TABLE_NAME)
+		lease, acquired, err := AcquireLease("task", "server-2", "node-2", time.Minute)
+		assert.Nil(t, err)
+		assert.True(t, acquired)
+		assert.Equal(t, "node-2", lease.NodeID)
+	})
+	t.Run("Release", func(t *testing.T) {
+		assert.Nil(t, ReleaseLease("task", "server-1"))
+		_, acquired, _ := AcquireLease("task", "server-1", "node-1", time.Minute)
+		assert.False(t, acquired)
+		assert.Nil(t, ReleaseLease("task", "server-2"))
+		_, acquired, err := AcquireLease("task", "server-1", "node-1", time.Minute)
+		assert.Nil(t, err)
+		assert.True(t, acquired)
+	})
+}
+
+func TestLeaderElection(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	database.DeleteAllRecords(database.LEASES_TABLE_NAME)
+	serverID := servercfg.GetServerID()
+
+	leader, err := RunLeaderElection()
+	assert.Nil(t, err)
+	assert.True(t, leader)
+	assert.True(t, IsServerLeader())
+	instances, err := GetServerInstances()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(instances))
+	assert.Equal(t, serverID, instances[0].ID)
+	assert.Equal(t, models.SERVER_ROLE_LEADER, instances[0].Role)
+	health := GetServerHealth()
+	assert.True(t, health.DatabaseOK)
+	assert.Equal(t, models.SERVER_ROLE_LEADER, health.Role)
+
+	t.Run("Follower", func(t *testing.T) {
+		other := models.Lease{Name: LEADER_LEASE, Holder: "server-2", NodeID: "node-2", ExpiresAt: time.Now().Add(time.Minute).Unix()}
+		insertTestRecord(t, LEADER_LEASE, other, database.LEASES_TABLE_NAME)
+		insertTestRecord(t, instanceLeasePrefix+"server-2", other, database.LEASES_TABLE_NAME)
+		leader, err := RunLeaderElection()
+		assert.Nil(t, err)
+		assert.False(t, leader)
+		assert.False(t, IsServerLeader())
+		assert.Equal(t, "server-2", GetServerHealth().Leader)
+		instances, err := GetServerInstances()
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(instances))
+		for _, instance := range instances {
+			assert.Equal(t, instance.ID == "server-2", instance.Role == models.SERVER_ROLE_LEADER)
+		}
+	})
+	t.Run("Takeover", func(t *testing.T) {
+		expired := models.Lease{Name: LEADER_LEASE, Holder: "server-2", NodeID: "node-2", ExpiresAt: time.Now().Add(-time.Second).Unix()}
+		insertTestRecord(t, LEADER_LEASE, expired, database.LEASES_TABLE_NAME)
+		insertTestRecord(t, instanceLeasePrefix+"server-2", expired, database.LEASES_TABLE_NAME)
+		leader, err := RunLeaderElection()
+		assert.Nil(t, err)
+		assert.True(t, leader)
+		instances, err := GetServerInstances()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(instances))
+	})
+}
+
+func TestIsLeader(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	database.DeleteAllRecords(database.NODES_TABLE_NAME)
+	database.DeleteAllRecords(database.LEASES_TABLE_NAME)
+	first := models.Node{UUID: ServerNodeUUID("node-1"), Network: "skynet", Address: "10.0.0.1", IsServer: "yes", LastModified: time.Now().Unix()}
+	second := models.Node{UUID: ServerNodeUUID("node-2"), Network: "skynet", Address: "10.0.0.2", IsServer: "yes", LastModified: time.Now().Unix() - 10}
+	for _, node := range []*models.Node{&first, &second} {
+		node.SetID()
+		insertTestRecord(t, node.ID, node, database.NODES_TABLE_NAME)
+	}
+
+	// without a leading instance the last modified server node leads
+	assert.True(t, IsLeader(&first))
+	assert.False(t, IsLeader(&second))
+
+	lease := models.Lease{Name: LEADER_LEASE, Holder: "server-2", NodeID: "node-2", ExpiresAt: time.Now().Add(time.Minute).Unix()}
+	insertTestRecord(t, LEADER_LEASE, lease, database.LEASES_TABLE_NAME)
+	assert.False(t, IsLeader(&first))
+	assert.True(t, IsLeader(&second))
+
+	// the leading instance has no server node in the network
+	lease.NodeID = "node-3"
+	insertTestRecord(t, LEADER_LEASE, lease, database.LEASES_TABLE_NAME)
+	assert.True(t, IsLeader(&first))
+}
+
+func TestFailOrphanedOperations(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	database.DeleteAllRecords(database.OPERATIONS_TABLE_NAME)
+	database.DeleteAllRecords(database.LEASES_TABLE_NAME)
+	_, err := RunLeaderElection()
+	assert.Nil(t, err)
+	own, err := CreateOperation(NETWORK_DELETE_OPERATION, "skynet")
+	assert.Nil(t, err)
+	orphaned := models.Operation{ID: "orphaned", Type: NETWORK_DELETE_OPERATION, Target: "othernet", Status: models.OPERATION_RUNNING, Server: "server-2"}
+	data, err := json.Marshal(&orphaned)
+	assert.Nil(t, err)
+	assert.Nil(t, database.Insert(orphaned.ID, string(data), database.OPERATIONS_TABLE_NAME))
+
+	assert.Nil(t, FailOrphanedOperations())
+	stored, err := GetOperation(own.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.OPERATION_RUNNING, stored.Status)
+	stored, err = GetOperation(orphaned.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.OPERATION_FAILED, stored.Status)
+}
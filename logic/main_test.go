@@ -0,0 +1,14 @@
+package logic
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gravitl/netmaker/database"
+)
+
+// TestMain - runs the tests on an in-memory database, so they leave no database file behind
+func TestMain(m *testing.M) {
+	database.SetStore(database.NewMemoryStore())
+	os.Exit(m.Run())
+}
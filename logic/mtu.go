@@ -0,0 +1,81 @@
+package logic
+
+import (
+	"strconv"
+
+	"github.com/gravitl/netmaker/models"
+)
+
+// MIN_DISCOVERED_MTU - lowest mtu pushed to the nodes of a network, the minimum ipv6 allows
+const MIN_DISCOVERED_MTU = 1280
+
+// MAX_DISCOVERED_MTU - highest mtu pushed to the nodes of a network, the wireguard default
+const MAX_DISCOVERED_MTU = 1420
+
+// ComputeNetworkMTU - gets the lowest mtu recommended by the nodes, 0 if none has reported one
+func ComputeNetworkMTU(nodes []models.Node) int32 {
+	var mtu int32
+	for _, node := range nodes {
+		if node.RecommendedMTU > 0 && (mtu == 0 || node.RecommendedMTU < mtu) {
+			mtu = node.RecommendedMTU
+		}
+	}
+	if mtu == 0 {
+		return 0
+	}
+	if mtu < MIN_DISCOVERED_MTU {
+		return MIN_DISCOVERED_MTU
+	}
+	if mtu > MAX_DISCOVERED_MTU {
+		return MAX_DISCOVERED_MTU
+	}
+	return mtu
+}
+
+// SetNetworkMTU - pushes the computed mtu to every node of a network that discovers its mtu
+// returns the mtu that was set, 0 if nothing changed
+func SetNetworkMTU(networkName string) (int32, error) {
+	network, err := GetParentNetwork(networkName)
+	if err != nil {
+		return 0, err
+	}
+	if network.MTUDiscovery != "yes" {
+		return 0, nil
+	}
+	nodes, err := GetNetworkNodes(networkName)
+	if err != nil {
+		return 0, err
+	}
+	mtu := ComputeNetworkMTU(nodes)
+	if mtu == 0 {
+		return 0, nil
+	}
+	changed := false
+	for _, current := range nodes {
+		if current.MTU == mtu {
+			continue
+		}
+		node := current
+		node.MTU = mtu
+		node.PullChanges = "yes"
+		if err = UpdateNode(&current, &node); err != nil {
+			return 0, err
+		}
+		changed = true
+	}
+	if network.DefaultMTU != mtu {
+		// new nodes join with the discovered mtu
+		current := network
+		network.DefaultMTU = mtu
+		network.SetNetworkLastModified()
+		if _, _, err = UpdateNetwork(&current, &network); err != nil {
+			return 0, err
+		}
+		changed = true
+	}
+	if !changed {
+		return 0, nil
+	}
+	Log("set mtu of network "+networkName+" to "+strconv.Itoa(int(mtu)), 1)
+	return mtu, nil
+}
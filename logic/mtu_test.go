@@ -0,0 +1,69 @@
+package logic
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeNetworkMTU(t *testing.T) {
+	t.Run("NoneReported", func(t *testing.T) {
+		assert.Equal(t, int32(0), ComputeNetworkMTU([]models.Node{{MTU: 1280}, {MTU: 1400}}))
+	})
+	t.Run("Lowest", func(t *testing.T) {
+		nodes := []models.Node{{RecommendedMTU: 1420}, {RecommendedMTU: 1392}, {}}
+		assert.Equal(t, int32(1392), ComputeNetworkMTU(nodes))
+	})
+	t.Run("Clamped", func(t *testing.T) {
+		assert.Equal(t, int32(MIN_DISCOVERED_MTU), ComputeNetworkMTU([]models.Node{{RecommendedMTU: 516}}))
+		assert.Equal(t, int32(MAX_DISCOVERED_MTU), ComputeNetworkMTU([]models.Node{{RecommendedMTU: 1440}}))
+	})
+}
+
+func TestSetNetworkMTU(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	for _, table := range []string{database.NODES_TABLE_NAME, database.NETWORKS_TABLE_NAME} {
+		database.DeleteAllRecords(table)
+	}
+	network := models.Network{NetID: "skynet", AddressRange: "10.0.0.0/24", MTUDiscovery: "yes"}
+	network.SetDefaults()
+	insertTestRecord(t, network.NetID, network, database.NETWORKS_TABLE_NAME)
+	for i, recommended := range []int32{1420, 1392} {
+		node := models.Node{
+			UUID:           ServerNodeUUID("node-" + strconv.Itoa(i)),
+			Name:           "node" + strconv.Itoa(i),
+			Network:        "skynet",
+			Address:        "10.0.0." + strconv.Itoa(i+1),
+			PublicKey:      "DM5qhLAE20PG9BbfBCger+Ac9D2NDOwCtY1rbYDLf34=",
+			Endpoint:       "1.1.1." + strconv.Itoa(i+1),
+			Password:       "password",
+			RecommendedMTU: recommended,
+		}
+		SetNodeDefaults(&node)
+		node.SetID()
+		insertTestRecord(t, node.ID, node, database.NODES_TABLE_NAME)
+	}
+
+	mtu, err := SetNetworkMTU("skynet")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1392), mtu)
+	nodes, err := GetNetworkNodes("skynet")
+	assert.Nil(t, err)
+	for _, node := range nodes {
+		assert.Equal(t, int32(1392), node.MTU)
+		assert.Equal(t, "yes", node.PullChanges)
+		assert.NotZero(t, node.LastModified)
+	}
+	updated, err := GetParentNetwork("skynet")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1392), updated.DefaultMTU)
+	assert.NotZero(t, updated.NetworkLastModified)
+
+	// nothing changes on the next check in
+	mtu, err = SetNetworkMTU("skynet")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), mtu)
+}
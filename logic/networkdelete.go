@@ -0,0 +1,206 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+)
+
+// NETWORK_DELETE_OPERATION - the operation type of a forced network delete
+const NETWORK_DELETE_OPERATION = "deletenetwork"
+
+// NETWORK_DELETE_TIMEOUT - how long nodes are given to acknowledge a forced network delete by default
+const NETWORK_DELETE_TIMEOUT = 5 * time.Minute
+
+// networkDeletePollInterval - how often the nodes are looked at for acknowledgements during a forced network delete
+var networkDeletePollInterval = time.Second
+
+// networkDeleteMutex - keeps two deletes of a network from starting together
+var networkDeleteMutex sync.Mutex
+
+// StartNetworkDelete - starts a forced delete of a network in the background,
+// returns the operation its progress is reported on
+func StartNetworkDelete(networkName string, timeout time.Duration) (models.Operation, error) {
+	if _, err := GetParentNetwork(networkName); err != nil {
+		return models.Operation{}, err
+	}
+	networkDeleteMutex.Lock()
+	defer networkDeleteMutex.Unlock()
+	if _, running := GetRunningOperation(NETWORK_DELETE_OPERATION, networkName); running {
+		return models.Operation{}, errors.New("network " + networkName + " is already being deleted")
+	}
+	operation, err := CreateOperation(NETWORK_DELETE_OPERATION, networkName)
+	if err != nil {
+		return operation, err
+	}
+	running := operation
+	go func() {
+		if err := ForceDeleteNetwork(&running, timeout); err != nil {
+			Log("could not delete network "+networkName+": "+err.Error(), 1)
+		}
+	}()
+	return operation, nil
+}
+
+// ForceDeleteNetwork - deletes a network with its nodes, the nodes are told to leave and given until the timeout to acknowledge it
+// by removing themselves once they left, then the ext clients, custom dns entries, peers, server nodes and the network record are removed
+func ForceDeleteNetwork(operation *models.Operation, timeout time.Duration) error {
+	err := forceDeleteNetwork(operation, timeout)
+	if finishErr := FinishOperation(operation, err); finishErr != nil && err == nil {
+		err = finishErr
+	}
+	return err
+}
+
+func forceDeleteNetwork(operation *models.Operation, timeout time.Duration) error {
+	networkName := operation.Target
+	setStep := func(step string) {
+		operation.Step = step
+		if err := SaveOperation(operation); err != nil {
+			Log("could not save progress of deleting network "+networkName+": "+err.Error(), 2)
+		}
+	}
+
+	setStep("marking nodes for deletion")
+	nodes, err := GetNetworkNodes(networkName)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if node.IsServer == "yes" {
+			continue
+		}
+		node.Action = models.NODE_DELETE
+		data, err := json.Marshal(&node)
+		if err != nil {
+			return err
+		}
+		node.SetID()
+		if err = database.Insert(node.ID, string(data), database.NODES_TABLE_NAME); err != nil {
+			return err
+		}
+		operation.Pending = append(operation.Pending, node.GetNodeID())
+	}
+
+	setStep("waiting for nodes to acknowledge")
+	deadline := time.Now().Add(timeout)
+	for len(operation.Pending) > 0 && time.Now().Before(deadline) {
+		time.Sleep(networkDeletePollInterval)
+		var acknowledged []string
+		operation.Pending, acknowledged = checkDeleteAcknowledged(networkName, operation.Pending)
+		if len(acknowledged) > 0 {
+			operation.Acknowledged = append(operation.Acknowledged, acknowledged...)
+			setStep(operation.Step)
+		}
+	}
+	operation.TimedOut = operation.Pending
+	operation.Pending = nil
+
+	setStep("removing ext clients")
+	if err = deleteNetworkRecords(database.EXT_CLIENT_TABLE_NAME, networkName, func(value string) string {
+		var client models.ExtClient
+		if json.Unmarshal([]byte(value), &client) != nil {
+			return ""
+		}
+		return client.Network
+	}); err != nil {
+		return err
+	}
+
+	setStep("removing dns entries")
+	if err = deleteNetworkRecords(database.DNS_TABLE_NAME, networkName, func(value string) string {
+		var entry models.DNSEntry
+		if json.Unmarshal([]byte(value), &entry) != nil {
+			return ""
+		}
+		return entry.Network
+	}); err != nil {
+		return err
+	}
+
+	setStep("removing peers")
+	if err = database.DeleteRecord(database.PEERS_TABLE_NAME, networkName); err != nil && !database.IsEmptyRecord(err) {
+		Log("could not remove peers of network "+networkName+": "+err.Error(), 2)
+	}
+
+	setStep("removing nodes")
+	for _, table := range []string{database.NODES_TABLE_NAME, database.DELETED_NODES_TABLE_NAME} {
+		if err = deleteNetworkRecords(table, networkName, func(value string) string {
+			var node models.Node
+			if json.Unmarshal([]byte(value), &node) != nil || node.IsServer == "yes" {
+				return ""
+			}
+			return node.Network
+		}); err != nil {
+			return err
+		}
+	}
+	servers, err := GetSortedNetworkServerNodes(networkName)
+	if err != nil {
+		return err
+	}
+	for i := range servers {
+		// removes the interface of the server too
+		if err = DeleteNode(&servers[i], true); err != nil {
+			Log("could not remove server "+servers[i].Name+" before deleting network "+networkName+": "+err.Error(), 1)
+		}
+	}
+
+	setStep("removing network")
+	if err = database.DeleteRecord(database.NETWORKS_TABLE_NAME, networkName); err != nil {
+		return err
+	}
+	if servercfg.IsDNSMode() {
+		if err = SetDNS(); err != nil {
+			Log("could not update dns after deleting network "+networkName+": "+err.Error(), 1)
+		}
+	}
+	setStep("done")
+	return nil
+}
+
+// checkDeleteAcknowledged - splits the nodes told to leave into those still pending and those that acknowledged it,
+// a node acknowledges by removing itself from the server after its interface is gone, a check in does not count
+// as it does not show the node acted on the delete
+func checkDeleteAcknowledged(networkName string, pending []string) ([]string, []string) {
+	var stillPending, acknowledged []string
+	for _, nodeID := range pending {
+		key, err := GetRecordKey(nodeID, networkName)
+		if err != nil {
+			continue
+		}
+		_, err = database.FetchRecord(database.NODES_TABLE_NAME, key)
+		if database.IsEmptyRecord(err) {
+			acknowledged = append(acknowledged, nodeID)
+			continue
+		}
+		stillPending = append(stillPending, nodeID)
+	}
+	return stillPending, acknowledged
+}
+
+// deleteNetworkRecords - deletes the records of a table that belong to a network, networkOf gives the network of a record
+// or an empty string for a record that is kept
+func deleteNetworkRecords(table string, networkName string, networkOf func(value string) string) error {
+	records, err := database.FetchRecords(table)
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return nil
+		}
+		return err
+	}
+	for key, value := range records {
+		if networkOf(value) != networkName {
+			continue
+		}
+		if err = database.DeleteRecord(table, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package logic
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForceDeleteNetwork(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	os.Setenv("DNS_MODE", "off")
+	defer os.Unsetenv("DNS_MODE")
+	for _, table := range []string{database.NETWORKS_TABLE_NAME, database.NODES_TABLE_NAME, database.DELETED_NODES_TABLE_NAME,
+		database.EXT_CLIENT_TABLE_NAME, database.DNS_TABLE_NAME, database.PEERS_TABLE_NAME, database.OPERATIONS_TABLE_NAME} {
+		database.DeleteAllRecords(table)
+	}
+	networkDeletePollInterval = 10 * time.Millisecond
+	insertTestRecord(t, "skynet", models.Network{NetID: "skynet"}, database.NETWORKS_TABLE_NAME)
+	insertTestRecord(t, "othernet", models.Network{NetID: "othernet"}, database.NETWORKS_TABLE_NAME)
+	for _, node := range []models.Node{
+		{UUID: "node-1", Network: "skynet", Action: models.NODE_NOOP},
+		{UUID: "node-2", Network: "skynet", Action: models.NODE_NOOP},
+		{UUID: "node-3", Network: "othernet", Action: models.NODE_NOOP},
+	} {
+		node.SetID()
+		insertTestRecord(t, node.ID, node, database.NODES_TABLE_NAME)
+	}
+	insertTestRecord(t, "client###skynet", models.ExtClient{ClientID: "client", Network: "skynet"}, database.EXT_CLIENT_TABLE_NAME)
+	insertTestRecord(t, "client###othernet", models.ExtClient{ClientID: "client", Network: "othernet"}, database.EXT_CLIENT_TABLE_NAME)
+	insertTestRecord(t, "www###skynet", models.DNSEntry{Name: "www", Network: "skynet", Address: "10.0.0.5"}, database.DNS_TABLE_NAME)
+	assert.Nil(t, database.InsertPeer("skynet", "{}"))
+
+	t.Run("Acknowledged", func(t *testing.T) {
+		// a check in after the delete was asked for is not an acknowledgement, only a node that removed itself is
+		checkedIn := models.Node{UUID: "node-1", Network: "skynet", Action: models.NODE_DELETE, LastCheckIn: time.Now().Unix() + 1}
+		checkedIn.SetID()
+		insertTestRecord(t, checkedIn.ID, checkedIn, database.NODES_TABLE_NAME)
+		pending, acknowledged := checkDeleteAcknowledged("skynet", []string{"node-1", "node-2", "gone"})
+		assert.Equal(t, []string{"node-1", "node-2"}, pending)
+		assert.Equal(t, []string{"gone"}, acknowledged)
+	})
+	t.Run("Cascade", func(t *testing.T) {
+		operation, err := CreateOperation(NETWORK_DELETE_OPERATION, "skynet")
+		assert.Nil(t, err)
+		assert.Nil(t, ForceDeleteNetwork(&operation, 0))
+
+		stored, err := GetOperation(operation.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, models.OPERATION_SUCCEEDED, stored.Status)
+		assert.ElementsMatch(t, []string{"node-1", "node-2"}, stored.TimedOut)
+		assert.Empty(t, stored.Pending)
+
+		_, err = GetParentNetwork("skynet")
+		assert.True(t, database.IsEmptyRecord(err))
+		nodes, err := GetNetworkNodes("skynet")
+		assert.Nil(t, err)
+		assert.Empty(t, nodes)
+		nodes, err = GetNetworkNodes("othernet")
+		assert.Nil(t, err)
+		assert.Len(t, nodes, 1)
+		clients, err := database.FetchRecords(database.EXT_CLIENT_TABLE_NAME)
+		assert.Nil(t, err)
+		assert.Len(t, clients, 1)
+		assert.Contains(t, clients, "client###othernet")
+		_, err = database.FetchRecords(database.DNS_TABLE_NAME)
+		assert.True(t, database.IsEmptyRecord(err))
+		peers, err := database.GetPeers("skynet")
+		assert.Nil(t, err)
+		assert.Empty(t, peers)
+	})
+	t.Run("MissingNetwork", func(t *testing.T) {
+		_, err := StartNetworkDelete("skynet", 0)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestFailInterruptedOperations(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	database.DeleteAllRecords(database.OPERATIONS_TABLE_NAME)
+	running, err := CreateOperation(NETWORK_DELETE_OPERATION, "skynet")
+	assert.Nil(t, err)
+	_, found := GetRunningOperation(NETWORK_DELETE_OPERATION, "skynet")
+	assert.True(t, found)
+
+	assert.Nil(t, FailInterruptedOperations())
+	stored, err := GetOperation(running.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.OPERATION_FAILED, stored.Status)
+	assert.NotEmpty(t, stored.Error)
+	_, found = GetRunningOperation(NETWORK_DELETE_OPERATION, "skynet")
+	assert.False(t, found)
+}
@@ -5,12 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net"
-	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gravitl/netmaker/database"
 	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/netclient/link"
 	"github.com/gravitl/netmaker/netclient/ncutils"
 	"github.com/gravitl/netmaker/validation"
 )
@@ -41,7 +42,7 @@ func GetNetworks() ([]models.Network, error) {
 func GetParentNetwork(networkname string) (models.Network, error) {
 
 	var network models.Network
-	networkData, err := database.FetchRecord(database.NETWORKS_TABLE_NAME, networkname)
+	networkData, err := fetchNetworkRecord(networkname)
 	if err != nil {
 		return network, err
 	}
@@ -55,7 +56,7 @@ func GetParentNetwork(networkname string) (models.Network, error) {
 func GetNetworkSettings(networkname string) (models.Network, error) {
 
 	var network models.Network
-	networkData, err := database.FetchRecord(database.NETWORKS_TABLE_NAME, networkname)
+	networkData, err := fetchNetworkRecord(networkname)
 	if err != nil {
 		return network, err
 	}
@@ -379,6 +380,10 @@ func UpdateNetwork(currentNetwork *models.Network, newNetwork *models.Network) (
 	if newNetwork.NetID == currentNetwork.NetID {
 		hasrangeupdate := newNetwork.AddressRange != currentNetwork.AddressRange
 		localrangeupdate := newNetwork.LocalRange != currentNetwork.LocalRange
+		newNetwork.KeyRotationChanged = currentNetwork.KeyRotationChanged
+		if newNetwork.KeyRotationDays != currentNetwork.KeyRotationDays || newNetwork.KeyRotationStaggerHours != currentNetwork.KeyRotationStaggerHours {
+			newNetwork.KeyRotationChanged = time.Now().Unix()
+		}
 		data, err := json.Marshal(newNetwork)
 		if err != nil {
 			return false, false, err
@@ -405,7 +410,7 @@ func Inc(ip net.IP) {
 func GetNetwork(networkname string) (models.Network, error) {
 
 	var network models.Network
-	networkData, err := database.FetchRecord(database.NETWORKS_TABLE_NAME, networkname)
+	networkData, err := fetchNetworkRecord(networkname)
 	if err != nil {
 		return network, err
 	}
@@ -468,12 +473,7 @@ func deleteInterface(ifacename string, postdown string) error {
 	if !ncutils.IsKernel() {
 		err = RemoveConf(ifacename, true)
 	} else {
-		ipExec, errN := exec.LookPath("ip")
-		err = errN
-		if err != nil {
-			Log(err.Error(), 1)
-		}
-		_, err = ncutils.RunCmd(ipExec+" link del "+ifacename, false)
+		err = link.Delete(ifacename)
 		if postdown != "" {
 			runcmds := strings.Split(postdown, "; ")
 			err = ncutils.RunCmds(runcmds, false)
@@ -0,0 +1,112 @@
+package logic
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+)
+
+// MigrateNodeIDs - gives nodes from before node ids an id and moves them, their server keys and
+// their ext clients from the mac address key to the id key
+func MigrateNodeIDs() error {
+	migrated, err := migrateNodeTable(database.NODES_TABLE_NAME)
+	if err != nil {
+		return err
+	}
+	if _, err = migrateNodeTable(database.DELETED_NODES_TABLE_NAME); err != nil {
+		return err
+	}
+	if len(migrated) == 0 {
+		return nil
+	}
+	return migrateIngressGatewayIDs(migrated)
+}
+
+// migrateNodeTable - re-keys the nodes of a table that have no id, returns the new ids by old key
+func migrateNodeTable(table string) (map[string]string, error) {
+	migrated := make(map[string]string)
+	collection, err := database.FetchRecords(table)
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return migrated, nil
+		}
+		return migrated, err
+	}
+	for key, value := range collection {
+		var node models.Node
+		if err = json.Unmarshal([]byte(value), &node); err != nil {
+			Log("failed to unmarshal node "+key+" during id migration", 1)
+			continue
+		}
+		if node.UUID != "" {
+			continue
+		}
+		if node.IsServer == "yes" {
+			node.UUID = ServerNodeUUID(node.MacAddress)
+		} else {
+			node.UUID = uuid.NewString()
+		}
+		newkey, err := GetRecordKey(node.UUID, node.Network)
+		if err != nil {
+			return migrated, err
+		}
+		node.ID = newkey
+		data, err := json.Marshal(&node)
+		if err != nil {
+			return migrated, err
+		}
+		if err = database.Insert(newkey, string(data), table); err != nil {
+			return migrated, err
+		}
+		if err = database.DeleteRecord(table, key); err != nil {
+			return migrated, err
+		}
+		if node.IsServer == "yes" && table == database.NODES_TABLE_NAME {
+			if privkey, err := FetchPrivKey(key); err == nil {
+				if err = StorePrivKey(newkey, privkey); err != nil {
+					return migrated, err
+				}
+				RemovePrivKey(key)
+			}
+		}
+		migrated[key] = node.UUID
+		Log("migrated node "+key+" to "+newkey, 1)
+	}
+	return migrated, nil
+}
+
+// migrateIngressGatewayIDs - points ext clients at the id of their gateway instead of its mac address
+func migrateIngressGatewayIDs(migrated map[string]string) error {
+	collection, err := database.FetchRecords(database.EXT_CLIENT_TABLE_NAME)
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return nil
+		}
+		return err
+	}
+	for key, value := range collection {
+		var extclient models.ExtClient
+		if err = json.Unmarshal([]byte(value), &extclient); err != nil {
+			continue
+		}
+		oldkey, err := GetRecordKey(extclient.IngressGatewayID, extclient.Network)
+		if err != nil {
+			continue
+		}
+		nodeid, ok := migrated[oldkey]
+		if !ok {
+			continue
+		}
+		extclient.IngressGatewayID = nodeid
+		data, err := json.Marshal(&extclient)
+		if err != nil {
+			return err
+		}
+		if err = database.Insert(key, string(data), database.EXT_CLIENT_TABLE_NAME); err != nil {
+			return err
+		}
+	}
+	return nil
+}
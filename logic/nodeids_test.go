@@ -0,0 +1,68 @@
+package logic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func insertTestRecord(t *testing.T, key string, value interface{}, table string) {
+	data, err := json.Marshal(value)
+	assert.Nil(t, err)
+	assert.Nil(t, database.Insert(key, string(data), table))
+}
+
+func TestMigrateNodeIDs(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	for _, table := range []string{database.NODES_TABLE_NAME, database.DELETED_NODES_TABLE_NAME, database.EXT_CLIENT_TABLE_NAME, database.SERVERCONF_TABLE_NAME} {
+		database.DeleteAllRecords(table)
+	}
+	legacy := models.Node{ID: "01:02:03:04:05:06###skynet", MacAddress: "01:02:03:04:05:06", Network: "skynet", IsIngressGateway: "yes"}
+	server := models.Node{ID: "server-1###skynet", MacAddress: "server-1", Network: "skynet", IsServer: "yes"}
+	insertTestRecord(t, legacy.ID, legacy, database.NODES_TABLE_NAME)
+	insertTestRecord(t, server.ID, server, database.NODES_TABLE_NAME)
+	assert.Nil(t, StorePrivKey(server.ID, "privatekey"))
+	insertTestRecord(t, "client-skynet", models.ExtClient{ClientID: "client", Network: "skynet", IngressGatewayID: legacy.MacAddress}, database.EXT_CLIENT_TABLE_NAME)
+
+	t.Run("LegacyLookup", func(t *testing.T) {
+		node, err := GetNodeByID("skynet", legacy.MacAddress)
+		assert.Nil(t, err)
+		assert.Equal(t, legacy.ID, node.ID)
+	})
+	assert.Nil(t, MigrateNodeIDs())
+	t.Run("Nodes", func(t *testing.T) {
+		node, err := GetNodeByID("skynet", legacy.MacAddress)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, node.UUID)
+		assert.Equal(t, node.UUID+"###skynet", node.ID)
+		_, err = database.FetchRecord(database.NODES_TABLE_NAME, legacy.ID)
+		assert.True(t, database.IsEmptyRecord(err))
+	})
+	t.Run("ServerNode", func(t *testing.T) {
+		node, err := GetNodeByID("skynet", ServerNodeUUID("server-1"))
+		assert.Nil(t, err)
+		privkey, err := FetchPrivKey(node.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, "privatekey", privkey)
+		_, err = FetchPrivKey(server.ID)
+		assert.NotNil(t, err)
+	})
+	t.Run("ExtClients", func(t *testing.T) {
+		node, err := GetNodeByID("skynet", legacy.MacAddress)
+		assert.Nil(t, err)
+		peers, err := GetExtPeersList(node.UUID, "skynet")
+		assert.Nil(t, err)
+		assert.Len(t, peers, 1)
+	})
+	t.Run("Idempotent", func(t *testing.T) {
+		before, err := database.FetchRecords(database.NODES_TABLE_NAME)
+		assert.Nil(t, err)
+		assert.Nil(t, MigrateNodeIDs())
+		after, err := database.FetchRecords(database.NODES_TABLE_NAME)
+		assert.Nil(t, err)
+		assert.Equal(t, before, after)
+	})
+}
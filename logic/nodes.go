@@ -79,13 +79,22 @@ func GetPeers(node models.Node) ([]models.Node, error) {
 	return peers, nil
 }
 
-// IsLeader - determines if a given server node is a leader
+// IsLeader - determines if a given server node is a leader, the server node of the leading server instance leads its networks,
+// networks without a server node of the leading instance are led by the last modified server node
 func IsLeader(node *models.Node) bool {
 	nodes, err := GetSortedNetworkServerNodes(node.Network)
 	if err != nil {
 		Log("ERROR: COULD NOT RETRIEVE SERVER NODES. THIS WILL BREAK HOLE PUNCHING.", 0)
 		return false
 	}
+	if lease, err := GetLease(LEADER_LEASE); err == nil && lease.ExpiresAt > time.Now().Unix() {
+		leaderNode := ServerNodeUUID(lease.NodeID)
+		for _, n := range nodes {
+			if n.UUID == leaderNode {
+				return n.Address == node.Address
+			}
+		}
+	}
 	for _, n := range nodes {
 		if n.LastModified > time.Now().Add(-1*time.Minute).Unix() {
 			return n.Address == node.Address
@@ -111,7 +120,7 @@ func UpdateNode(currentNode *models.Node, newNode *models.Node) error {
 			return database.Insert(newNode.ID, string(data), database.NODES_TABLE_NAME)
 		}
 	}
-	return fmt.Errorf("failed to update node " + newNode.MacAddress + ", cannot change macaddress.")
+	return fmt.Errorf("failed to update node " + newNode.UUID + ", cannot change id.")
 }
 
 func IsNodeIDUnique(node *models.Node) (bool, error) {
@@ -121,7 +130,7 @@ func IsNodeIDUnique(node *models.Node) (bool, error) {
 
 func ValidateNode(node *models.Node, isUpdate bool) error {
 	v := validator.New()
-	_ = v.RegisterValidation("macaddress_unique", func(fl validator.FieldLevel) bool {
+	_ = v.RegisterValidation("id_unique", func(fl validator.FieldLevel) bool {
 		if isUpdate {
 			return true
 		}
@@ -179,7 +188,7 @@ func CheckIsServer(node *models.Node) bool {
 		if err := json.Unmarshal([]byte(value), &tmpNode); err != nil {
 			continue
 		}
-		if tmpNode.Network == node.Network && tmpNode.MacAddress != node.MacAddress {
+		if tmpNode.Network == node.Network && tmpNode.UUID != node.UUID {
 			return false
 		}
 	}
@@ -190,7 +199,7 @@ func CheckIsServer(node *models.Node) bool {
 func GetNetworkByNode(node *models.Node) (models.Network, error) {
 
 	var network models.Network
-	networkData, err := database.FetchRecord(database.NETWORKS_TABLE_NAME, node.Network)
+	networkData, err := fetchNetworkRecord(node.Network)
 	if err != nil {
 		return network, err
 	}
@@ -276,17 +285,34 @@ func GetRecordKey(id string, network string) (string, error) {
 	return id + "###" + network, nil
 }
 
-// GetNodeByMacAddress - gets a node by mac address
-func GetNodeByMacAddress(network string, macaddress string) (models.Node, error) {
+// GetNodeByID - gets a node by its id
+// nodes from before ids were used, and api calls made with their mac address, are found by mac address
+func GetNodeByID(network string, nodeid string) (models.Node, error) {
+	return getNodeByID(database.NODES_TABLE_NAME, network, nodeid)
+}
 
+// GetDeletedNodeByID - get a deleted node by its id, or by mac address like GetNodeByID
+func GetDeletedNodeByID(network string, nodeid string) (models.Node, error) {
+	return getNodeByID(database.DELETED_NODES_TABLE_NAME, network, nodeid)
+}
+
+func getNodeByID(table string, network string, nodeid string) (models.Node, error) {
 	var node models.Node
 
-	key, err := GetRecordKey(macaddress, network)
+	key, err := GetRecordKey(nodeid, network)
 	if err != nil {
 		return node, err
 	}
 
-	record, err := database.FetchRecord(database.NODES_TABLE_NAME, key)
+	var record string
+	if table == database.NODES_TABLE_NAME {
+		record, err = fetchNodeRecord(key)
+	} else {
+		record, err = database.FetchRecord(table, key)
+	}
+	if database.IsEmptyRecord(err) {
+		return getNodeByMacAddress(table, network, nodeid)
+	}
 	if err != nil {
 		return models.Node{}, err
 	}
@@ -300,28 +326,22 @@ func GetNodeByMacAddress(network string, macaddress string) (models.Node, error)
 	return node, nil
 }
 
-// GetDeletedNodeByMacAddress - get a deleted node
-func GetDeletedNodeByMacAddress(network string, macaddress string) (models.Node, error) {
-
-	var node models.Node
-
-	key, err := GetRecordKey(macaddress, network)
-	if err != nil {
-		return node, err
-	}
-
-	record, err := database.FetchRecord(database.DELETED_NODES_TABLE_NAME, key)
+func getNodeByMacAddress(table string, network string, macaddress string) (models.Node, error) {
+	collection, err := database.FetchRecords(table)
 	if err != nil {
 		return models.Node{}, err
 	}
-
-	if err = json.Unmarshal([]byte(record), &node); err != nil {
-		return models.Node{}, err
+	for _, value := range collection {
+		var node models.Node
+		if err := json.Unmarshal([]byte(value), &node); err != nil {
+			continue
+		}
+		if node.Network == network && node.MacAddress == macaddress && macaddress != "" {
+			SetNodeDefaults(&node)
+			return node, nil
+		}
 	}
-
-	SetNodeDefaults(&node)
-
-	return node, nil
+	return models.Node{}, errors.New(database.NO_RECORD)
 }
 
 // GetNodeRelay - gets the relay node of a given network
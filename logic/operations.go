@@ -0,0 +1,121 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+)
+
+// CreateOperation - creates a running operation of a type on a target
+func CreateOperation(operationType string, target string) (models.Operation, error) {
+	operation := models.Operation{
+		ID:        uuid.NewString(),
+		Type:      operationType,
+		Target:    target,
+		Status:    models.OPERATION_RUNNING,
+		Server:    servercfg.GetServerID(),
+		StartedAt: time.Now().Unix(),
+	}
+	return operation, SaveOperation(&operation)
+}
+
+// SaveOperation - stores the progress of an operation
+func SaveOperation(operation *models.Operation) error {
+	data, err := json.Marshal(operation)
+	if err != nil {
+		return err
+	}
+	return database.Insert(operation.ID, string(data), database.OPERATIONS_TABLE_NAME)
+}
+
+// GetOperation - gets an operation by its id
+func GetOperation(id string) (models.Operation, error) {
+	var operation models.Operation
+	data, err := database.FetchRecord(database.OPERATIONS_TABLE_NAME, id)
+	if err != nil {
+		return operation, err
+	}
+	err = json.Unmarshal([]byte(data), &operation)
+	return operation, err
+}
+
+// GetRunningOperation - gets the running operation of a type on a target, if there is one
+func GetRunningOperation(operationType string, target string) (models.Operation, bool) {
+	records, err := database.FetchRecords(database.OPERATIONS_TABLE_NAME)
+	if err != nil {
+		return models.Operation{}, false
+	}
+	for _, value := range records {
+		var operation models.Operation
+		if err := json.Unmarshal([]byte(value), &operation); err != nil {
+			continue
+		}
+		if operation.Type == operationType && operation.Target == target && operation.Status == models.OPERATION_RUNNING {
+			return operation, true
+		}
+	}
+	return models.Operation{}, false
+}
+
+// FinishOperation - records the end of an operation, failed when an error is given
+func FinishOperation(operation *models.Operation, err error) error {
+	operation.Status = models.OPERATION_SUCCEEDED
+	if err != nil {
+		operation.Status = models.OPERATION_FAILED
+		operation.Error = err.Error()
+	}
+	operation.FinishedAt = time.Now().Unix()
+	return SaveOperation(operation)
+}
+
+// FailInterruptedOperations - marks the operations left running by a previous run of this server instance as failed
+func FailInterruptedOperations() error {
+	serverID := servercfg.GetServerID()
+	return failRunningOperations(func(operation *models.Operation) bool {
+		// operations from before the server id was recorded belong to this instance as well
+		return operation.Server == serverID || operation.Server == ""
+	}, "interrupted by a restart of the server")
+}
+
+// FailOrphanedOperations - marks the operations left running by server instances whose lease expired as failed
+func FailOrphanedOperations() error {
+	instances, err := GetServerInstances()
+	if err != nil {
+		return err
+	}
+	alive := make(map[string]bool)
+	for _, instance := range instances {
+		alive[instance.ID] = true
+	}
+	return failRunningOperations(func(operation *models.Operation) bool {
+		return operation.Server != "" && !alive[operation.Server]
+	}, "the server instance running it stopped")
+}
+
+// failRunningOperations - marks the running operations matching a filter as failed
+func failRunningOperations(matches func(operation *models.Operation) bool, reason string) error {
+	records, err := database.FetchRecords(database.OPERATIONS_TABLE_NAME)
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return nil
+		}
+		return err
+	}
+	for _, value := range records {
+		var operation models.Operation
+		if err := json.Unmarshal([]byte(value), &operation); err != nil {
+			continue
+		}
+		if operation.Status == models.OPERATION_RUNNING && matches(&operation) {
+			if err = FinishOperation(&operation, errors.New(reason)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/netclient/ncutils"
 	"github.com/gravitl/netmaker/servercfg"
@@ -36,6 +37,7 @@ func ServerJoin(network string, serverID string, privateKey string) error {
 		DNSOn:        "no",
 		IsStatic:     "yes",
 		Name:         models.NODE_SERVER_NAME,
+		UUID:         ServerNodeUUID(serverID),
 		MacAddress:   serverID,
 		UDPHolePunch: "no",
 	}
@@ -78,6 +80,7 @@ func ServerJoin(network string, serverID string, privateKey string) error {
 	var postnode *models.Node
 	postnode = &models.Node{
 		Password:            node.Password,
+		UUID:                node.UUID,
 		MacAddress:          node.MacAddress,
 		AccessKey:           node.AccessKey,
 		Network:             network,
@@ -130,7 +133,7 @@ func ServerJoin(network string, serverID string, privateKey string) error {
 		return err
 	}
 
-	peers, hasGateway, gateways, err := GetServerPeers(node.MacAddress, network, node.IsDualStack == "yes", node.IsIngressGateway == "yes")
+	peers, hasGateway, gateways, err := GetServerPeers(node.UUID, network, node.IsDualStack == "yes", node.IsIngressGateway == "yes")
 	if err != nil && !ncutils.IsEmptyRecord(err) {
 		Log("failed to retrieve peers", 1)
 		return err
@@ -144,19 +147,24 @@ func ServerJoin(network string, serverID string, privateKey string) error {
 	return nil
 }
 
+// ServerNodeUUID - gets the uuid of the nodes of a server, it is derived from the server id so it stays the same across restarts
+func ServerNodeUUID(serverID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte("netmaker-server:"+serverID)).String()
+}
+
 // ServerCheckin - runs pulls and pushes for server
-func ServerCheckin(mac string, network string) error {
+func ServerCheckin(serverID string, network string) error {
 	var serverNode models.Node
 	var newNode *models.Node
 	var err error
-	serverNode, err = GetNode(mac, network)
+	serverNode, err = GetNode(ServerNodeUUID(serverID), network)
 	if err != nil {
 		return err
 	}
 
 	newNode, err = ServerPull(&serverNode, false)
 	if isDeleteError(err) {
-		return ServerLeave(mac, network)
+		return ServerLeave(serverID, network)
 	} else if err != nil {
 		return err
 	}
@@ -221,11 +229,11 @@ func ServerPush(serverNode *models.Node) error {
 }
 
 // ServerLeave - removes a server node
-func ServerLeave(mac string, network string) error {
+func ServerLeave(serverID string, network string) error {
 
 	var serverNode models.Node
 	var err error
-	serverNode, err = GetNode(mac, network)
+	serverNode, err = GetNode(ServerNodeUUID(serverID), network)
 	if err != nil {
 		return err
 	}
@@ -234,7 +242,7 @@ func ServerLeave(mac string, network string) error {
 }
 
 // GetServerPeers - gets peers of server
-func GetServerPeers(macaddress string, network string, dualstack bool, isIngressGateway bool) ([]wgtypes.PeerConfig, bool, []string, error) {
+func GetServerPeers(nodeid string, network string, dualstack bool, isIngressGateway bool) ([]wgtypes.PeerConfig, bool, []string, error) {
 	hasGateway := false
 	var err error
 	var gateways []string
@@ -242,7 +250,7 @@ func GetServerPeers(macaddress string, network string, dualstack bool, isIngress
 	var nodecfg models.Node
 	var nodes []models.Node // fill above fields from server or client
 
-	nodecfg, err = GetNode(macaddress, network)
+	nodecfg, err = GetNode(nodeid, network)
 	if err != nil {
 		return nil, hasGateway, gateways, err
 	}
@@ -365,7 +373,7 @@ func GetServerPeers(macaddress string, network string, dualstack bool, isIngress
 		peers = append(peers, peer)
 	}
 	if isIngressGateway {
-		extPeers, err := GetServerExtPeers(macaddress, network, dualstack)
+		extPeers, err := GetServerExtPeers(nodeid, network, dualstack)
 		if err == nil {
 			peers = append(peers, extPeers...)
 		} else {
@@ -376,19 +384,19 @@ func GetServerPeers(macaddress string, network string, dualstack bool, isIngress
 }
 
 // GetServerExtPeers - gets the extpeers for a client
-func GetServerExtPeers(macaddress string, network string, dualstack bool) ([]wgtypes.PeerConfig, error) {
+func GetServerExtPeers(nodeid string, network string, dualstack bool) ([]wgtypes.PeerConfig, error) {
 	var peers []wgtypes.PeerConfig
 	var nodecfg models.Node
 	var extPeers []models.Node
 	var err error
 	// fill above fields from either client or server
 
-	nodecfg, err = GetNode(macaddress, network)
+	nodecfg, err = GetNode(nodeid, network)
 	if err != nil {
 		return nil, err
 	}
 	var tempPeers []models.ExtPeersResponse
-	tempPeers, err = GetExtPeersList(nodecfg.MacAddress, nodecfg.Network)
+	tempPeers, err = GetExtPeersList(nodecfg.UUID, nodecfg.Network)
 	if err != nil {
 		return nil, err
 	}
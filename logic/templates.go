@@ -0,0 +1,195 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"regexp"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+)
+
+// egressInterfaceRegex - finds the outgoing interface in the default post up of an egress gateway
+var egressInterfaceRegex = regexp.MustCompile(`POSTROUTING -o (\S+) -j MASQUERADE`)
+
+// GetTemplates - gets the network templates
+func GetTemplates() ([]models.NetworkTemplate, error) {
+	templates := []models.NetworkTemplate{}
+	records, err := database.FetchRecords(database.TEMPLATES_TABLE_NAME)
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return templates, nil
+		}
+		return templates, err
+	}
+	for _, value := range records {
+		var template models.NetworkTemplate
+		if err := json.Unmarshal([]byte(value), &template); err != nil {
+			continue
+		}
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
+
+// GetTemplate - gets a network template by name
+func GetTemplate(name string) (models.NetworkTemplate, error) {
+	var template models.NetworkTemplate
+	record, err := database.FetchRecord(database.TEMPLATES_TABLE_NAME, name)
+	if err != nil {
+		return template, err
+	}
+	err = json.Unmarshal([]byte(record), &template)
+	return template, err
+}
+
+// SaveTemplate - creates or replaces a network template
+func SaveTemplate(template *models.NetworkTemplate) error {
+	// only the name is checked, the network settings are validated when a network is created from the template
+	if err := validator.New().Var(template.Name, "required,min=1,max=40,hostname_rfc1123"); err != nil {
+		return errors.New("invalid template name " + template.Name)
+	}
+	if template.Network.AddressRange != "" {
+		if _, _, err := net.ParseCIDR(template.Network.AddressRange); err != nil {
+			return errors.New("invalid address range " + template.Network.AddressRange)
+		}
+	}
+	data, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	return database.Insert(template.Name, string(data), database.TEMPLATES_TABLE_NAME)
+}
+
+// DeleteTemplate - deletes a network template
+func DeleteTemplate(name string) error {
+	if _, err := GetTemplate(name); err != nil {
+		return err
+	}
+	return database.DeleteRecord(database.TEMPLATES_TABLE_NAME, name)
+}
+
+// TemplateFromNetwork - makes a template of a network, with its custom dns entries, the gateways of its server node
+// and the names and uses of its access keys
+func TemplateFromNetwork(name string, network models.Network, entries []models.DNSEntry, servers []models.Node) models.NetworkTemplate {
+	template := models.NetworkTemplate{
+		Name:       name,
+		DNSEntries: []models.DNSEntry{},
+		Gateways:   []models.GatewayTemplate{},
+		AccessKeys: []models.AccessKeyTemplate{},
+	}
+	for _, key := range network.AccessKeys {
+		template.AccessKeys = append(template.AccessKeys, models.AccessKeyTemplate{Name: key.Name, Uses: key.Uses})
+	}
+	network.AccessKeys = nil
+	network.NodesLastModified = 0
+	network.NetworkLastModified = 0
+	network.KeyUpdateTimeStamp = 0
+	if network.DefaultInterface == defaultInterface(network.NetID) {
+		network.DefaultInterface = ""
+	}
+	if network.DisplayName == network.NetID {
+		network.DisplayName = ""
+	}
+	template.Network = network
+	for _, entry := range entries {
+		entry.Network = ""
+		template.DNSEntries = append(template.DNSEntries, entry)
+	}
+	if len(servers) > 0 {
+		server := servers[0]
+		if server.IsEgressGateway == "yes" {
+			gateway := models.GatewayTemplate{IsEgressGateway: true, Ranges: server.EgressGatewayRanges}
+			if match := egressInterfaceRegex.FindStringSubmatch(server.PostUp); match != nil {
+				gateway.Interface = match[1]
+			}
+			template.Gateways = append(template.Gateways, gateway)
+		}
+		if server.IsIngressGateway == "yes" {
+			template.Gateways = append(template.Gateways, models.GatewayTemplate{IsIngressGateway: true})
+		}
+	}
+	return template
+}
+
+// NetworkFromTemplate - the network a clone of a template creates, with the id and address ranges of the request
+func NetworkFromTemplate(template *models.NetworkTemplate, request *models.CloneNetworkRequest) (models.Network, error) {
+	if request.NetID == "" {
+		return models.Network{}, errors.New("a netid is needed for the new network")
+	}
+	if _, _, err := net.ParseCIDR(request.AddressRange); err != nil {
+		return models.Network{}, errors.New("invalid address range " + request.AddressRange)
+	}
+	network := template.Network
+	if network.IsDualStack == "yes" && request.AddressRange6 == "" {
+		return models.Network{}, errors.New("an addressrange6 is needed to clone a dual stack network")
+	}
+	if network.DefaultInterface == defaultInterface(network.NetID) {
+		network.DefaultInterface = ""
+	}
+	network.NetID = request.NetID
+	network.DisplayName = request.DisplayName
+	network.AddressRange = request.AddressRange
+	network.AddressRange6 = request.AddressRange6
+	network.AccessKeys = []models.AccessKey{}
+	// the zone of the template network stays with it
+	network.DNSZoneSuffix = ""
+	network.NodesLastModified = 0
+	network.NetworkLastModified = 0
+	network.KeyUpdateTimeStamp = 0
+	return network, nil
+}
+
+// CloneDNSEntry - moves a dns entry of a template into a new network, the addresses in the ranges of the template
+// keep their place in the ranges of the network, returns false when an address does not fit in the new range
+func CloneDNSEntry(entry models.DNSEntry, template *models.NetworkTemplate, network *models.Network) (models.DNSEntry, bool) {
+	entry.Network = network.NetID
+	if entry.Address == "" {
+		return entry, true
+	}
+	address, ok := TranslateAddress(entry.Address, template.Network.AddressRange, network.AddressRange)
+	if ok && address == entry.Address {
+		address, ok = TranslateAddress(entry.Address, template.Network.AddressRange6, network.AddressRange6)
+	}
+	entry.Address = address
+	return entry, ok
+}
+
+// TranslateAddress - moves an address in one range to the same place in another range,
+// addresses outside the first range are kept, returns false when the address does not fit in the other range
+func TranslateAddress(address string, fromRange string, toRange string) (string, bool) {
+	ip := net.ParseIP(address)
+	_, from, err := net.ParseCIDR(fromRange)
+	if ip == nil || err != nil || !from.Contains(ip) {
+		return address, true
+	}
+	_, to, err := net.ParseCIDR(toRange)
+	if err != nil {
+		return "", false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	offset := new(big.Int).Sub(new(big.Int).SetBytes(ip), new(big.Int).SetBytes(from.IP))
+	translated := new(big.Int).Add(new(big.Int).SetBytes(to.IP), offset).Bytes()
+	if len(translated) > len(to.IP) {
+		return "", false
+	}
+	result := make(net.IP, len(to.IP))
+	copy(result[len(result)-len(translated):], translated)
+	if !to.Contains(result) {
+		return "", false
+	}
+	return result.String(), true
+}
+
+// defaultInterface - the interface a network gets when none is set
+func defaultInterface(netid string) string {
+	if len(netid) < 13 {
+		return "nm-" + netid
+	}
+	return netid
+}
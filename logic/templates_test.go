@@ -0,0 +1,110 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateAddress(t *testing.T) {
+	t.Run("InRange", func(t *testing.T) {
+		address, ok := TranslateAddress("10.10.0.25", "10.10.0.0/16", "10.20.0.0/16")
+		assert.True(t, ok)
+		assert.Equal(t, "10.20.0.25", address)
+	})
+	t.Run("OutsideRange", func(t *testing.T) {
+		address, ok := TranslateAddress("192.168.1.5", "10.10.0.0/16", "10.20.0.0/16")
+		assert.True(t, ok)
+		assert.Equal(t, "192.168.1.5", address)
+	})
+	t.Run("DoesNotFit", func(t *testing.T) {
+		_, ok := TranslateAddress("10.10.3.25", "10.10.0.0/16", "10.20.0.0/24")
+		assert.False(t, ok)
+	})
+	t.Run("IPv6", func(t *testing.T) {
+		address, ok := TranslateAddress("fd00::1:5", "fd00::/64", "fd10::/64")
+		assert.True(t, ok)
+		assert.Equal(t, "fd10::1:5", address)
+	})
+}
+
+func TestTemplateFromNetwork(t *testing.T) {
+	network := models.Network{
+		NetID:            "skynet",
+		DisplayName:      "skynet",
+		AddressRange:     "10.10.0.0/16",
+		DefaultInterface: "nm-skynet",
+		DefaultKeepalive: 25,
+		DNSZoneSuffix:    "corp.internal",
+		AccessKeys:       []models.AccessKey{{Name: "ci", Value: "secret", Uses: 10}},
+	}
+	entries := []models.DNSEntry{{Name: "db", Network: "skynet", Address: "10.10.0.5"}}
+	servers := []models.Node{{
+		Name:                "netmaker",
+		IsEgressGateway:     "yes",
+		EgressGatewayRanges: []string{"192.168.0.0/24"},
+		PostUp:              "iptables -A FORWARD -i nm-skynet -j ACCEPT; iptables -t nat -A POSTROUTING -o eth0 -j MASQUERADE",
+		IsIngressGateway:    "yes",
+	}}
+	template := TemplateFromNetwork("base", network, entries, servers)
+	assert.Equal(t, "base", template.Name)
+	assert.Equal(t, []models.AccessKeyTemplate{{Name: "ci", Uses: 10}}, template.AccessKeys)
+	assert.Empty(t, template.Network.AccessKeys)
+	assert.Equal(t, "", template.Network.DefaultInterface)
+	assert.Equal(t, "", template.DNSEntries[0].Network)
+	assert.Len(t, template.Gateways, 2)
+	assert.Equal(t, "eth0", template.Gateways[0].Interface)
+	assert.True(t, template.Gateways[1].IsIngressGateway)
+
+	t.Run("Clone", func(t *testing.T) {
+		clone, err := NetworkFromTemplate(&template, &models.CloneNetworkRequest{NetID: "staging", AddressRange: "10.20.0.0/16"})
+		assert.Nil(t, err)
+		assert.Equal(t, "staging", clone.NetID)
+		assert.Equal(t, "10.20.0.0/16", clone.AddressRange)
+		assert.Equal(t, int32(25), clone.DefaultKeepalive)
+		assert.Equal(t, "", clone.DNSZoneSuffix)
+		assert.Empty(t, clone.AccessKeys)
+
+		entry, ok := CloneDNSEntry(template.DNSEntries[0], &template, &clone)
+		assert.True(t, ok)
+		assert.Equal(t, "staging", entry.Network)
+		assert.Equal(t, "10.20.0.5", entry.Address)
+	})
+	t.Run("InvalidRequest", func(t *testing.T) {
+		_, err := NetworkFromTemplate(&template, &models.CloneNetworkRequest{AddressRange: "10.20.0.0/16"})
+		assert.NotNil(t, err)
+		_, err = NetworkFromTemplate(&template, &models.CloneNetworkRequest{NetID: "staging", AddressRange: "10.20.0.0"})
+		assert.NotNil(t, err)
+		dualstack := template
+		dualstack.Network.IsDualStack = "yes"
+		_, err = NetworkFromTemplate(&dualstack, &models.CloneNetworkRequest{NetID: "staging", AddressRange: "10.20.0.0/16"})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestSaveTemplate(t *testing.T) {
+	assert.Nil(t, database.InitializeDatabase())
+	database.DeleteAllRecords(database.TEMPLATES_TABLE_NAME)
+	t.Run("Invalid", func(t *testing.T) {
+		assert.NotNil(t, SaveTemplate(&models.NetworkTemplate{Name: "bad name"}))
+		assert.NotNil(t, SaveTemplate(&models.NetworkTemplate{Name: "base", Network: models.Network{AddressRange: "10.10.0.0"}}))
+	})
+	t.Run("Saved", func(t *testing.T) {
+		template := models.NetworkTemplate{Name: "base", Network: models.Network{AddressRange: "10.10.0.0/16", DefaultMTU: 1380}}
+		assert.Nil(t, SaveTemplate(&template))
+		stored, err := GetTemplate("base")
+		assert.Nil(t, err)
+		assert.Equal(t, int32(1380), stored.Network.DefaultMTU)
+		templates, err := GetTemplates()
+		assert.Nil(t, err)
+		assert.Len(t, templates, 1)
+	})
+	t.Run("Deleted", func(t *testing.T) {
+		assert.Nil(t, DeleteTemplate("base"))
+		_, err := GetTemplate("base")
+		assert.True(t, database.IsEmptyRecord(err))
+		assert.NotNil(t, DeleteTemplate("base"))
+	})
+}
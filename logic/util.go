@@ -2,6 +2,7 @@
 package logic
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"log"
@@ -10,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gravitl/netmaker/database"
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/netclient/ncutils"
@@ -88,6 +90,10 @@ func CreateNode(node models.Node, networkName string) (models.Node, error) {
 	node.Password = string(hash)
 
 	node.Network = networkName
+	// nodes of older clients do not bring their own id
+	if node.UUID == "" {
+		node.UUID = uuid.NewString()
+	}
 	if node.Name == models.NODE_SERVER_NAME {
 		node.IsServer = "yes"
 	}
@@ -108,7 +114,7 @@ func CreateNode(node models.Node, networkName string) (models.Node, error) {
 		return node, err
 	}
 	//Create a JWT for the node
-	tokenString, _ := CreateJWT(node.MacAddress, networkName)
+	tokenString, _ := CreateJWT(node.UUID, networkName)
 	if tokenString == "" {
 		//returnErrorResponse(w, r, errorResponse)
 		return node, err
@@ -117,7 +123,7 @@ func CreateNode(node models.Node, networkName string) (models.Node, error) {
 	if err != nil {
 		return node, err
 	}
-	key, err := GetRecordKey(node.MacAddress, node.Network)
+	key, err := GetRecordKey(node.UUID, node.Network)
 	if err != nil {
 		return node, err
 	}
@@ -161,14 +167,14 @@ func SetNetworkNodesLastModified(networkName string) error {
 }
 
 // GetNode - fetches a node from database
-func GetNode(macaddress string, network string) (models.Node, error) {
+func GetNode(nodeid string, network string) (models.Node, error) {
 	var node models.Node
 
-	key, err := GetRecordKey(macaddress, network)
+	key, err := GetRecordKey(nodeid, network)
 	if err != nil {
 		return node, err
 	}
-	data, err := database.FetchRecord(database.NODES_TABLE_NAME, key)
+	data, err := fetchNodeRecord(key)
 	if err != nil {
 		if data == "" {
 			data, err = database.FetchRecord(database.DELETED_NODES_TABLE_NAME, key)
@@ -186,6 +192,11 @@ func GetNode(macaddress string, network string) (models.Node, error) {
 
 // GetNodePeers - fetches peers for a given node
 func GetNodePeers(networkName string, excludeRelayed bool) ([]models.Node, error) {
+	return getNodePeers(networkName, excludeRelayed, nil)
+}
+
+// getNodePeers - fetches the peers of a network, and records what they were built from in fingerprints when it is not nil
+func getNodePeers(networkName string, excludeRelayed bool, fingerprints map[string][sha256.Size]byte) ([]models.Node, error) {
 	var peers []models.Node
 	collection, err := database.FetchRecords(database.NODES_TABLE_NAME)
 	if err != nil {
@@ -198,6 +209,8 @@ func GetNodePeers(networkName string, excludeRelayed bool) ([]models.Node, error
 	udppeers, errN := database.GetPeers(networkName)
 	if errN != nil {
 		Log(errN.Error(), 2)
+	} else if fingerprints != nil {
+		fingerprints[models.EVENT_PEERS+"/"+networkName] = endpointsFingerprint(udppeers)
 	}
 	for _, value := range collection {
 		var node models.Node
@@ -213,6 +226,9 @@ func GetNodePeers(networkName string, excludeRelayed bool) ([]models.Node, error
 		}
 		allow := node.IsRelayed != "yes" || !excludeRelayed
 
+		if node.Network == networkName && fingerprints != nil {
+			fingerprints[models.EVENT_NODE+"/"+node.ID] = peerFingerprint(&node)
+		}
 		if node.Network == networkName && node.IsPending != "yes" && allow {
 			peer = setPeerInfo(node)
 			if node.UDPHolePunch == "yes" && errN == nil && CheckEndpoint(udppeers[node.PublicKey]) {
@@ -241,13 +257,30 @@ func GetNodePeers(networkName string, excludeRelayed bool) ([]models.Node, error
 	return peers, err
 }
 
-// GetPeersList - gets the peers of a given network
+// GetPeersList - gets the peers of a given network, from the peers cache when the network did not change since they were built
 func GetPeersList(networkName string, excludeRelayed bool, relayedNodeAddr string) ([]models.Node, error) {
+	if !servercfg.IsCacheMode() {
+		return getPeersList(networkName, excludeRelayed, relayedNodeAddr, nil)
+	}
+	key := cachePrefix(networkName) + strconv.FormatBool(excludeRelayed) + "/" + relayedNodeAddr
+	cached, err := peersCache.load(key, func() (interface{}, error) {
+		fingerprints := make(map[string][sha256.Size]byte)
+		peers, err := getPeersList(networkName, excludeRelayed, relayedNodeAddr, fingerprints)
+		return cachedPeers{peers: peers, fingerprints: fingerprints}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// each caller gets its own copy, so changing it leaves the cached list alone
+	return copyPeers(cached.(cachedPeers).peers), nil
+}
+
+func getPeersList(networkName string, excludeRelayed bool, relayedNodeAddr string, fingerprints map[string][sha256.Size]byte) ([]models.Node, error) {
 	var peers []models.Node
 	var relayNode models.Node
 	var err error
 	if relayedNodeAddr == "" {
-		peers, err = GetNodePeers(networkName, excludeRelayed)
+		peers, err = getNodePeers(networkName, excludeRelayed, fingerprints)
 
 	} else {
 		relayNode, err = GetNodeRelay(networkName, relayedNodeAddr)
@@ -259,7 +292,7 @@ func GetPeersList(networkName string, excludeRelayed bool, relayedNodeAddr strin
 			} else {
 				relayNode.AllowedIPs = append(relayNode.AllowedIPs, relayNode.RelayAddrs...)
 			}
-			nodepeers, err := GetNodePeers(networkName, false)
+			nodepeers, err := getNodePeers(networkName, false, fingerprints)
 			if err == nil && relayNode.UDPHolePunch == "yes" {
 				for _, nodepeer := range nodepeers {
 					if nodepeer.Address == relayNode.Address {
@@ -4,15 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/netclient/link"
 	"github.com/gravitl/netmaker/netclient/ncutils"
+	"github.com/gravitl/netmaker/netclient/routes"
 	"golang.zx2c4.com/wireguard/wgctrl"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 )
@@ -72,7 +72,13 @@ func initWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig
 
 	if ncutils.IsKernel() {
 		Log("setting kernel device "+ifacename, 2)
-		setKernelDevice(ifacename, node.Address)
+		addresses := []string{link.AddressWithMask(node.Address, node.NetworkSettings.AddressRange, 24)}
+		if node.Address6 != "" && node.IsDualStack == "yes" {
+			addresses = append(addresses, link.AddressWithMask(node.Address6, node.NetworkSettings.AddressRange6, 64))
+		}
+		if err = setKernelDevice(ifacename, addresses); err != nil {
+			return err
+		}
 	}
 
 	nodeport := int(node.ListenPort)
@@ -118,11 +124,6 @@ func initWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig
 			return err
 		}
 	} else {
-		ipExec, err := exec.LookPath("ip")
-		if err != nil {
-			return err
-		}
-
 		_, err = wgclient.Device(ifacename)
 		if err != nil {
 			if os.IsNotExist(err) {
@@ -143,7 +144,7 @@ func initWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig
 			}
 		}
 
-		if _, err := ncutils.RunCmd(ipExec+" link set down dev "+ifacename, false); err != nil {
+		if err := link.Down(ifacename); err != nil {
 			Log("attempted to remove interface before editing", 2)
 			return err
 		}
@@ -153,8 +154,8 @@ func initWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig
 			_ = ncutils.RunCmds(runcmds, false)
 		}
 		// set MTU of node interface
-		if _, err := ncutils.RunCmd(ipExec+" link set mtu "+strconv.Itoa(int(node.MTU))+" up dev "+ifacename, true); err != nil {
-			Log("failed to create interface with mtu "+strconv.Itoa(int(node.MTU))+" - "+ifacename, 2)
+		if err := link.Up(ifacename, int(node.MTU)); err != nil {
+			Log("failed to create interface with mtu "+strconv.Itoa(int(node.MTU))+" - "+ifacename+": "+err.Error(), 2)
 			return err
 		}
 
@@ -162,31 +163,26 @@ func initWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig
 			runcmds := strings.Split(node.PostUp, "; ")
 			_ = ncutils.RunCmds(runcmds, true)
 		}
-		if hasGateway {
-			for _, gateway := range gateways {
-				_, _ = ncutils.RunCmd(ipExec+" -4 route add "+gateway+" dev "+ifacename, true)
-			}
+		if !hasGateway {
+			gateways = nil
 		}
-		if node.Address6 != "" && node.IsDualStack == "yes" {
-			log.Println("[netclient] adding address: "+node.Address6, 1)
-			_, _ = ncutils.RunCmd(ipExec+" address add dev "+ifacename+" "+node.Address6+"/64", true)
+		result, err := routes.Sync(ifacename, gateways)
+		if err != nil {
+			Log("could not read routes of "+ifacename+": "+err.Error(), 1)
+		}
+		for _, routeError := range result.Errors {
+			Log(routeError, 1)
 		}
 	}
 
 	return err
 }
 
-func setKernelDevice(ifacename string, address string) error {
-	ipExec, err := exec.LookPath("ip")
-	if err != nil {
+func setKernelDevice(ifacename string, addresses []string) error {
+	if err := link.EnsureWireGuard(ifacename); err != nil {
 		return err
 	}
-
-	_, _ = ncutils.RunCmd("ip link delete dev "+ifacename, false)
-	_, _ = ncutils.RunCmd(ipExec+" link add dev "+ifacename+" type wireguard", true)
-	_, _ = ncutils.RunCmd(ipExec+" address add dev "+ifacename+" "+address+"/24", true) // this is a bug waiting to happen
-
-	return nil
+	return link.SetAddresses(ifacename, addresses)
 }
 
 func applyWGQuickConf(confPath string) error {
@@ -210,6 +206,7 @@ func setServerPeers(iface string, keepalive int32, peers []wgtypes.PeerConfig) e
 		Log("failed to start wgctrl", 0)
 		return err
 	}
+	defer client.Close()
 
 	device, err := client.Device(iface)
 	if err != nil {
@@ -221,57 +218,13 @@ func setServerPeers(iface string, keepalive int32, peers []wgtypes.PeerConfig) e
 		Log("no peers pulled", 1)
 		return err
 	}
-
-	for _, peer := range peers {
-
-		for _, currentPeer := range devicePeers {
-			if currentPeer.AllowedIPs[0].String() == peer.AllowedIPs[0].String() &&
-				currentPeer.PublicKey.String() != peer.PublicKey.String() {
-				_, err := ncutils.RunCmd("wg set "+iface+" peer "+currentPeer.PublicKey.String()+" remove", true)
-				if err != nil {
-					log.Println("error removing peer", peer.Endpoint.String())
-				}
-			}
-		}
-		udpendpoint := peer.Endpoint.String()
-		var allowedips string
-		var iparr []string
-		for _, ipaddr := range peer.AllowedIPs {
-			iparr = append(iparr, ipaddr.String())
-		}
-		allowedips = strings.Join(iparr, ",")
-		keepAliveString := strconv.Itoa(int(keepalive))
-		if keepAliveString == "0" {
-			keepAliveString = "5"
-		}
-		if peer.Endpoint != nil {
-			_, err = ncutils.RunCmd("wg set "+iface+" peer "+peer.PublicKey.String()+
-				" endpoint "+udpendpoint+
-				" persistent-keepalive "+keepAliveString+
-				" allowed-ips "+allowedips, true)
-		} else {
-			_, err = ncutils.RunCmd("wg set "+iface+" peer "+peer.PublicKey.String()+
-				" persistent-keepalive "+keepAliveString+
-				" allowed-ips "+allowedips, true)
-		}
-		if err != nil {
-			Log("error setting peer "+peer.PublicKey.String(), 1)
-		}
+	if keepalive == 0 {
+		keepalive = 5
 	}
-
-	for _, currentPeer := range devicePeers {
-		shouldDelete := true
-		for _, peer := range peers {
-			if peer.AllowedIPs[0].String() == currentPeer.AllowedIPs[0].String() {
-				shouldDelete = false
-			}
-		}
-		if shouldDelete {
-			output, err := ncutils.RunCmd("wg set "+iface+" peer "+currentPeer.PublicKey.String()+" remove", true)
-			if err != nil {
-				log.Println(output, "error removing peer", currentPeer.PublicKey.String())
-			}
-		}
+	updates := link.PeerUpdates(devicePeers, peers, time.Duration(keepalive)*time.Second)
+	if err = client.ConfigureDevice(iface, wgtypes.Config{Peers: updates}); err != nil {
+		Log("error setting peers of "+iface+": "+err.Error(), 1)
+		return err
 	}
 
 	return nil
@@ -280,7 +233,7 @@ func setServerPeers(iface string, keepalive int32, peers []wgtypes.PeerConfig) e
 func setWGConfig(node models.Node, network string, peerupdate bool) error {
 
 	node.SetID()
-	peers, hasGateway, gateways, err := GetServerPeers(node.MacAddress, node.Network, node.IsDualStack == "yes", node.IsIngressGateway == "yes")
+	peers, hasGateway, gateways, err := GetServerPeers(node.UUID, node.Network, node.IsDualStack == "yes", node.IsIngressGateway == "yes")
 	if err != nil {
 		return err
 	}
@@ -334,15 +287,8 @@ func removeLocalServer(node *models.Node) error {
 				Log("removed WireGuard interface: "+ifacename, 1)
 			}
 		} else {
-			ipExec, err := exec.LookPath("ip")
-			if err != nil {
-				return err
-			}
-			out, err := ncutils.RunCmd(ipExec+" link del "+ifacename, false)
-			dontprint := strings.Contains(out, "does not exist") || strings.Contains(out, "Cannot find device")
-			if err != nil && !dontprint {
-				Log("error running command: "+ipExec+" link del "+ifacename, 1)
-				Log(out, 1)
+			if err := link.Delete(ifacename); err != nil {
+				Log(err.Error(), 1)
 			}
 			if node.PostDown != "" {
 				runcmds := strings.Split(node.PostDown, "; ")
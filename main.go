@@ -29,6 +29,9 @@ import (
 
 // Start DB Connection and start API Request Handler
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
 	fmt.Println(models.RetrieveLogo()) // print the logo
 	initialize()                       // initial db and grpc server
 	setGarbageCollection()
@@ -45,6 +48,20 @@ func initialize() { // Client Mode Prereq Check
 	}
 	logic.Log("database successfully connected", 0)
 
+	if err = logic.MigrateNodeIDs(); err != nil {
+		logic.Log("Error migrating node ids", 0)
+		log.Fatal(err)
+	}
+
+	if err = logic.FailInterruptedOperations(); err != nil {
+		logic.Log("error cleaning up interrupted operations: "+err.Error(), 0)
+	}
+
+	// the first election runs before anything is served, so the leader writes the dns files on startup
+	if _, err = logic.RunLeaderElection(); err != nil {
+		logic.Log("error running the leader election: "+err.Error(), 0)
+	}
+
 	var authProvider = auth.InitializeAuthProvider()
 	if authProvider != "" {
 		logic.Log("OAuth provider, "+authProvider+", initialized", 0)
@@ -71,7 +88,7 @@ func initialize() { // Client Mode Prereq Check
 		}
 	}
 
-	if servercfg.IsDNSMode() {
+	if servercfg.IsDNSMode() && !servercfg.IsEmbeddedDNS() {
 		err := functions.SetDNSDir()
 		if err != nil {
 			log.Fatal(err)
@@ -100,11 +117,23 @@ func startControllers() {
 	}
 
 	if servercfg.IsDNSMode() {
-		err := logic.SetDNS()
-		if err != nil {
+		if servercfg.IsEmbeddedDNS() {
+			waitnetwork.Add(1)
+			go runDNS(&waitnetwork)
+		} else if err := logic.SetDNS(); err != nil {
 			logic.Log("error occurred initializing DNS: "+err.Error(), 0)
 		}
 	}
+	if servercfg.IsRestBackend() || servercfg.IsAgentBackend() {
+		go runLeaderElection()
+		if servercfg.IsSharedDB() {
+			go runEventPolling()
+		}
+		go runKeyRotation()
+		if servercfg.IsBackupMode() {
+			go runBackups()
+		}
+	}
 	//Run Rest Server
 	if servercfg.IsRestBackend() {
 		if !servercfg.DisableRemoteIPCheck() && servercfg.GetAPIHost() == "127.0.0.1" {
@@ -138,6 +167,59 @@ func runClient(wg *sync.WaitGroup) {
 	}()
 }
 
+// runLeaderElection - renews the leases of this server instance on the renew interval, the leader runs the singleton tasks after each renewal
+func runLeaderElection() {
+	for {
+		time.Sleep(logic.LEASE_RENEW_INTERVAL)
+		leader, err := logic.RunLeaderElection()
+		if err != nil {
+			logic.Log("error renewing the leases of this server instance: "+err.Error(), 0)
+		}
+		if leader {
+			logic.RunLeaderTasks()
+		}
+	}
+}
+
+// runEventPolling - reads the changes made by the other server instances sharing the database on the poll interval
+func runEventPolling() {
+	for {
+		if err := logic.PollEvents(); err != nil {
+			logic.Log("error reading the events of the other server instances: "+err.Error(), 1)
+		}
+		time.Sleep(logic.EVENT_POLL_INTERVAL)
+	}
+}
+
+// runKeyRotation - asks the nodes whose keys are due to rotate them, on the key rotation interval, only the leader rotates keys
+func runKeyRotation() {
+	for {
+		if logic.IsServerLeader() {
+			if err := logic.RotateKeys(); err != nil {
+				logic.Log("error rotating keys: "+err.Error(), 1)
+			}
+		}
+		time.Sleep(time.Duration(servercfg.GetKeyRotationInterval()) * time.Second)
+	}
+}
+
+// runBackups - takes a backup of the database every backup interval and keeps the newest of them, only the leader takes backups
+func runBackups() {
+	for {
+		interval := time.Duration(servercfg.GetBackupInterval()) * time.Second
+		if logic.IsServerLeader() {
+			if _, err := logic.RunScheduledBackup(interval, int(servercfg.GetBackupRetention())); err != nil {
+				logic.Log("error taking a backup: "+err.Error(), 0)
+			}
+		}
+		// checked often so a restart does not push the next backup back by a whole interval
+		if interval > time.Minute {
+			interval = time.Minute
+		}
+		time.Sleep(interval)
+	}
+}
+
 func runGRPC(wg *sync.WaitGroup) {
 
 	defer wg.Done()
@@ -185,12 +267,35 @@ func runGRPC(wg *sync.WaitGroup) {
 
 	// After receiving CTRL+C Properly stop the server
 	logic.Log("Stopping the Agent server...", 0)
+	// another instance can take over right away instead of waiting for the lease to expire
+	if err := logic.ReleaseLease(logic.LEADER_LEASE, servercfg.GetServerID()); err != nil {
+		logic.Log("error releasing the leader lease: "+err.Error(), 0)
+	}
 	s.Stop()
 	listener.Close()
 	logic.Log("Agent server closed..", 0)
 	logic.Log("Closed DB connection.", 0)
 }
 
+func runDNS(wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	addr := servercfg.GetDNSListenAddr()
+	dnsServer, err := logic.StartDNSServer(addr, servercfg.GetDNSUpstreams())
+	if err != nil {
+		log.Fatalf("[netmaker] Unable to start DNS server on "+addr+", error: %v", err)
+	}
+	logic.Log("DNS Server successfully started on "+addr+" (udp/tcp)", 0)
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	<-c
+
+	logic.Log("Stopping the DNS server...", 0)
+	dnsServer.Shutdown()
+	logic.Log("DNS server closed.", 0)
+}
+
 func authServerUnaryInterceptor() grpc.ServerOption {
 	return grpc.UnaryInterceptor(controller.AuthServerUnaryInterceptor)
 }
@@ -0,0 +1,33 @@
+package models
+
+// ServerArchive - the records of the database tables of a server, as written by an export
+type ServerArchive struct {
+	Version       int                          `json:"version"`
+	ServerVersion string                       `json:"serverversion"`
+	Database      string                       `json:"database"`
+	CreatedAt     int64                        `json:"createdat"`
+	Networks      []string                     `json:"networks"`
+	Tables        map[string]map[string]string `json:"tables"`
+}
+
+// ImportSummary - what an import of an archive restored
+type ImportSummary struct {
+	Version  int            `json:"version"`
+	Networks []string       `json:"networks"`
+	Records  map[string]int `json:"records"`
+	Skipped  []string       `json:"skipped"`
+}
+
+// BACKUP_SNAPSHOT - a backup that is a snapshot the database took of itself
+const BACKUP_SNAPSHOT = "snapshot"
+
+// BACKUP_ARCHIVE - a backup that is an archive of the tables, for databases that can not take snapshots
+const BACKUP_ARCHIVE = "archive"
+
+// Backup - a scheduled backup of the database kept by the server
+type Backup struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Size      int64  `json:"size"`
+	CreatedAt int64  `json:"createdat"`
+}
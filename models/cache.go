@@ -0,0 +1,15 @@
+package models
+
+// CacheStats - the size and hit rate of an in-memory cache of the server
+type CacheStats struct {
+	Name     string `json:"name" bson:"name"`
+	Size     int    `json:"size" bson:"size"`
+	Capacity int    `json:"capacity" bson:"capacity"`
+	// the seconds an entry is used before it is read again
+	TTL           int64   `json:"ttl" bson:"ttl"`
+	Hits          uint64  `json:"hits" bson:"hits"`
+	Misses        uint64  `json:"misses" bson:"misses"`
+	Evictions     uint64  `json:"evictions" bson:"evictions"`
+	Invalidations uint64  `json:"invalidations" bson:"invalidations"`
+	HitRate       float64 `json:"hitrate" bson:"hitrate"`
+}
@@ -1,8 +1,48 @@
 //TODO:  Either add a returnNetwork and returnKey, or delete this
 package models
 
+import (
+	"net"
+	"strings"
+)
+
+// DNS record types which can be stored as dns entries
+const (
+	DNS_RECORD_A     = "A"
+	DNS_RECORD_AAAA  = "AAAA"
+	DNS_RECORD_CNAME = "CNAME"
+	DNS_RECORD_SRV   = "SRV"
+	DNS_RECORD_TXT   = "TXT"
+)
+
+// DNSEntry - a dns record of a network
+// Address holds the ip of A/AAAA records, Target the host of CNAME/SRV records and Text the data of TXT records
 type DNSEntry struct {
-	Address string `json:"address" bson:"address" validate:"required,ip"`
-	Name    string `json:"name" bson:"name" validate:"required,name_unique,min=1,max=192"`
-	Network string `json:"network" bson:"network" validate:"network_exists"`
+	Address  string `json:"address" bson:"address" validate:"omitempty,ip"`
+	Name     string `json:"name" bson:"name" validate:"required,name_unique,min=1,max=192"`
+	Network  string `json:"network" bson:"network" validate:"network_exists"`
+	Type     string `json:"type,omitempty" bson:"type,omitempty" validate:"omitempty,oneof=A AAAA CNAME SRV TXT"`
+	TTL      uint32 `json:"ttl,omitempty" bson:"ttl,omitempty" validate:"omitempty,max=604800"`
+	Target   string `json:"target,omitempty" bson:"target,omitempty" validate:"omitempty,max=253"`
+	Text     string `json:"text,omitempty" bson:"text,omitempty" validate:"omitempty,max=4096"`
+	Priority uint16 `json:"priority,omitempty" bson:"priority,omitempty"`
+	Weight   uint16 `json:"weight,omitempty" bson:"weight,omitempty"`
+	Port     uint16 `json:"port,omitempty" bson:"port,omitempty"`
+}
+
+// DNSEntry.RecordType - gets the record type, entries without one are A or AAAA records depending on the address
+func (entry *DNSEntry) RecordType() string {
+	if entry.Type != "" {
+		return strings.ToUpper(entry.Type)
+	}
+	if ip := net.ParseIP(entry.Address); ip != nil && ip.To4() == nil {
+		return DNS_RECORD_AAAA
+	}
+	return DNS_RECORD_A
+}
+
+// DNSEntry.IsAddressRecord - checks if the entry is an A or AAAA record
+func (entry *DNSEntry) IsAddressRecord() bool {
+	recordType := entry.RecordType()
+	return recordType == DNS_RECORD_A || recordType == DNS_RECORD_AAAA
 }
@@ -0,0 +1,37 @@
+package models
+
+// EVENT_NODE - a node changed
+const EVENT_NODE = "node"
+
+// EVENT_NETWORK - a network changed
+const EVENT_NETWORK = "network"
+
+// EVENT_EXT_CLIENT - an ext client changed
+const EVENT_EXT_CLIENT = "extclient"
+
+// EVENT_DNS - a custom dns entry changed
+const EVENT_DNS = "dns"
+
+// EVENT_PEERS - the udp hole punching endpoints of the peers of a network changed
+const EVENT_PEERS = "peers"
+
+// EVENT_UPDATED - the record was created or updated
+const EVENT_UPDATED = "updated"
+
+// EVENT_DELETED - the record was deleted, or every record of the type when the key is empty
+const EVENT_DELETED = "deleted"
+
+// Event - a change of a node, network, ext client or dns entry, made by this server instance or another one sharing the database
+type Event struct {
+	ID     string `json:"id" bson:"id"`
+	Type   string `json:"type" bson:"type"`
+	Action string `json:"action" bson:"action"`
+	// the database key of the changed record
+	Key     string `json:"key" bson:"key"`
+	Network string `json:"network" bson:"network"`
+	// the id of the server instance that made the change
+	Server string `json:"server" bson:"server"`
+	Time   int64  `json:"time" bson:"time"`
+	// the new value of the record, only known for the changes made by this server instance
+	Value string `json:"-" bson:"-"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// SERVER_ROLE_LEADER - the server instance that runs the tasks only one instance may run
+const SERVER_ROLE_LEADER = "leader"
+
+// SERVER_ROLE_FOLLOWER - a server instance that serves the api and grpc, but leaves the singleton tasks to the leader
+const SERVER_ROLE_FOLLOWER = "follower"
+
+// Lease - a named lock in the database, held by one server instance until it expires or is released
+type Lease struct {
+	Name string `json:"name" bson:"name"`
+	// the server id of the instance holding the lease
+	Holder string `json:"holder" bson:"holder"`
+	// the node id of the instance holding the lease, its server nodes lead the networks
+	NodeID string `json:"nodeid" bson:"nodeid"`
+	// the version of netmaker the holder runs
+	Version   string `json:"version" bson:"version"`
+	RenewedAt int64  `json:"renewedat" bson:"renewedat"`
+	ExpiresAt int64  `json:"expiresat" bson:"expiresat"`
+}
+
+// ServerInstance - a server instance sharing the database, as seen from its lease
+type ServerInstance struct {
+	ID       string `json:"id" bson:"id"`
+	Role     string `json:"role" bson:"role"`
+	Version  string `json:"version" bson:"version"`
+	LastSeen int64  `json:"lastseen" bson:"lastseen"`
+}
+
+// ServerHealth - the health and role of a server instance
+type ServerHealth struct {
+	ServerID   string `json:"serverid" bson:"serverid"`
+	Role       string `json:"role" bson:"role"`
+	Leader     string `json:"leader" bson:"leader"`
+	Version    string `json:"version" bson:"version"`
+	Database   string `json:"database" bson:"database"`
+	DatabaseOK bool   `json:"databaseok" bson:"databaseok"`
+	// the seconds since the server instance started
+	Uptime int64 `json:"uptime" bson:"uptime"`
+}
@@ -38,6 +38,21 @@ type Network struct {
 	DefaultUDPHolePunch    string `json:"defaultudpholepunch" bson:"defaultudpholepunch" validate:"checkyesorno"`
 	DefaultExtClientDNS    string `json:"defaultextclientdns" bson:"defaultextclientdns"`
 	DefaultMTU             int32  `json:"defaultmtu" bson:"defaultmtu"`
+	// mtu discovery sets the mtu of all nodes to the lowest one they recommend
+	MTUDiscovery string `json:"mtudiscovery" bson:"mtudiscovery" validate:"omitempty,checkyesorno"`
+
+	// dns settings pushed to the nodes of the network
+	DNSZoneSuffix   string   `json:"dnszonesuffix" bson:"dnszonesuffix" validate:"omitempty,max=253,hostname_rfc1123"`
+	DNSSearchDomain string   `json:"dnssearchdomain" bson:"dnssearchdomain" validate:"omitempty,max=253,hostname_rfc1123"`
+	DNSUpstreams    []string `json:"dnsupstreams" bson:"dnsupstreams" validate:"omitempty,dive,ip|hostname_port"`
+	DNSForward      string   `json:"dnsforward" bson:"dnsforward" validate:"omitempty,checkyesorno"`
+
+	// key rotation asks the nodes to change their keys when they are older than the given days, 0 turns it off
+	KeyRotationDays int32 `json:"keyrotationdays" bson:"keyrotationdays" validate:"omitempty,min=0,max=3650"`
+	// the rotations of the nodes are spread over this many hours after their keys are due
+	KeyRotationStaggerHours int32 `json:"keyrotationstaggerhours" bson:"keyrotationstaggerhours" validate:"omitempty,min=0,max=8760"`
+	// when the key rotation policy last changed, keys that were already due then are due from that time on
+	KeyRotationChanged int64 `json:"keyrotationchanged" bson:"keyrotationchanged"`
 }
 
 // SaveData - sensitive fields of a network that should be kept the same
@@ -68,6 +83,14 @@ func (network *Network) SetNetworkLastModified() {
 	network.NetworkLastModified = time.Now().Unix()
 }
 
+// Network.GetDNSZone - gets the dns zone of the network, the zone suffix if set and the netid otherwise
+func (network *Network) GetDNSZone() string {
+	if network.DNSZoneSuffix != "" {
+		return strings.ToLower(strings.TrimSuffix(network.DNSZoneSuffix, "."))
+	}
+	return network.NetID
+}
+
 // Network.SetDefaults - sets default values for a network struct
 func (network *Network) SetDefaults() {
 	if network.DefaultUDPHolePunch == "" {
@@ -126,4 +149,11 @@ func (network *Network) SetDefaults() {
 	if network.DefaultMTU == 0 {
 		network.DefaultMTU = 1280
 	}
+	if network.MTUDiscovery == "" {
+		network.MTUDiscovery = "no"
+	}
+
+	if network.DNSForward == "" {
+		network.DNSForward = "no"
+	}
 }
@@ -28,6 +28,7 @@ var seededRand *rand.Rand = rand.New(
 // node struct
 type Node struct {
 	ID                  string   `json:"id,omitempty" bson:"id,omitempty"`
+	UUID                string   `json:"uuid" bson:"uuid" yaml:"uuid" validate:"required,uuid,id_unique"`
 	Address             string   `json:"address" bson:"address" yaml:"address" validate:"omitempty,ipv4"`
 	Address6            string   `json:"address6" bson:"address6" yaml:"address6" validate:"omitempty,ipv6"`
 	LocalAddress        string   `json:"localaddress" bson:"localaddress" yaml:"localaddress" validate:"omitempty,ip"`
@@ -48,7 +49,7 @@ type Node struct {
 	ExpirationDateTime  int64    `json:"expdatetime" bson:"expdatetime" yaml:"expdatetime"`
 	LastPeerUpdate      int64    `json:"lastpeerupdate" bson:"lastpeerupdate" yaml:"lastpeerupdate"`
 	LastCheckIn         int64    `json:"lastcheckin" bson:"lastcheckin" yaml:"lastcheckin"`
-	MacAddress          string   `json:"macaddress" bson:"macaddress" yaml:"macaddress" validate:"required,min=5,macaddress_unique"`
+	MacAddress          string   `json:"macaddress" bson:"macaddress" yaml:"macaddress"`
 	// checkin interval is depreciated at the network level. Set on server with CHECKIN_INTERVAL
 	CheckInInterval     int32    `json:"checkininterval" bson:"checkininterval" yaml:"checkininterval"`
 	Password            string   `json:"password" bson:"password" yaml:"password" validate:"required,min=6"`
@@ -74,6 +75,9 @@ type Node struct {
 	IPForwarding        string   `json:"ipforwarding" bson:"ipforwarding" yaml:"ipforwarding" validate:"checkyesorno"`
 	OS                  string   `json:"os" bson:"os" yaml:"os"`
 	MTU                 int32    `json:"mtu" bson:"mtu" yaml:"mtu"`
+	RecommendedMTU      int32    `json:"recommendedmtu" bson:"recommendedmtu" yaml:"recommendedmtu"`
+	// routes the node failed to set on its last pull, reported by the node
+	RouteErrors []string `json:"routeerrors" bson:"routeerrors" yaml:"routeerrors"`
 }
 
 type NodesArray []Node
@@ -193,7 +197,15 @@ func (node *Node) SetLastPeerUpdate() {
 }
 
 func (node *Node) SetID() {
-	node.ID = node.MacAddress + "###" + node.Network
+	node.ID = node.GetNodeID() + "###" + node.Network
+}
+
+// Node.GetNodeID - gets the id a node is known by on the server, the mac address for nodes that have no uuid yet
+func (node *Node) GetNodeID() string {
+	if node.UUID != "" {
+		return node.UUID
+	}
+	return node.MacAddress
 }
 
 func (node *Node) SetExpirationDateTime() {
@@ -210,6 +222,9 @@ func (newNode *Node) Fill(currentNode *Node) {
 	if newNode.ID == "" {
 		newNode.ID = currentNode.ID
 	}
+	if newNode.UUID == "" {
+		newNode.UUID = currentNode.UUID
+	}
 	if newNode.Address == "" && newNode.IsStatic != "yes" {
 		newNode.Address = currentNode.Address
 	}
@@ -227,8 +242,12 @@ func (newNode *Node) Fill(currentNode *Node) {
 	}
 	if newNode.PublicKey == "" && newNode.IsStatic != "yes" {
 		newNode.PublicKey = currentNode.PublicKey
-	} else {
+	}
+	// the key age is kept by the server, it only restarts when the key changes
+	if newNode.PublicKey != currentNode.PublicKey {
 		newNode.KeyUpdateTimeStamp = time.Now().Unix()
+	} else {
+		newNode.KeyUpdateTimeStamp = currentNode.KeyUpdateTimeStamp
 	}
 	if newNode.Endpoint == "" && newNode.IsStatic != "yes" {
 		newNode.Endpoint = currentNode.Endpoint
@@ -257,9 +276,6 @@ func (newNode *Node) Fill(currentNode *Node) {
 	if newNode.LastModified == 0 {
 		newNode.LastModified = currentNode.LastModified
 	}
-	if newNode.KeyUpdateTimeStamp == 0 {
-		newNode.LastModified = currentNode.LastModified
-	}
 	if newNode.ExpirationDateTime == 0 {
 		newNode.ExpirationDateTime = currentNode.ExpirationDateTime
 	}
@@ -340,6 +356,12 @@ func (newNode *Node) Fill(currentNode *Node) {
 	if newNode.MTU == 0 {
 		newNode.MTU = currentNode.MTU
 	}
+	if newNode.RecommendedMTU == 0 {
+		newNode.RecommendedMTU = currentNode.RecommendedMTU
+	}
+	if newNode.RouteErrors == nil {
+		newNode.RouteErrors = currentNode.RouteErrors
+	}
 	if newNode.OS == "" {
 		newNode.OS = currentNode.OS
 	}
@@ -382,8 +404,8 @@ func (node *Node) NameInNodeCharSet() bool {
 }
 
 func (node *Node) GetID() (string, error) {
-	if node.MacAddress == "" || node.Network == "" {
+	if node.GetNodeID() == "" || node.Network == "" {
 		return "", errors.New("unable to get record key")
 	}
-	return node.MacAddress + "###" + node.Network, nil
+	return node.GetNodeID() + "###" + node.Network, nil
 }
@@ -0,0 +1,31 @@
+package models
+
+// OPERATION_RUNNING - the operation has not finished yet
+const OPERATION_RUNNING = "running"
+
+// OPERATION_SUCCEEDED - the operation finished
+const OPERATION_SUCCEEDED = "succeeded"
+
+// OPERATION_FAILED - the operation stopped on an error
+const OPERATION_FAILED = "failed"
+
+// Operation - a long running change made by the server, its progress is polled by its id
+type Operation struct {
+	ID     string `json:"id" bson:"id"`
+	Type   string `json:"type" bson:"type"`
+	Target string `json:"target" bson:"target"`
+	Status string `json:"status" bson:"status"`
+	// the id of the server instance running the operation
+	Server string `json:"server" bson:"server"`
+	// the step the operation is at
+	Step string `json:"step" bson:"step"`
+	// nodes that have not acknowledged the operation yet
+	Pending []string `json:"pending" bson:"pending"`
+	// nodes that acknowledged the operation, for a network delete by removing themselves after they left
+	Acknowledged []string `json:"acknowledged" bson:"acknowledged"`
+	// nodes that did not acknowledge the operation before it timed out
+	TimedOut   []string `json:"timedout" bson:"timedout"`
+	Error      string   `json:"error,omitempty" bson:"error,omitempty"`
+	StartedAt  int64    `json:"startedat" bson:"startedat"`
+	FinishedAt int64    `json:"finishedat,omitempty" bson:"finishedat,omitempty"`
+}
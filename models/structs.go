@@ -5,8 +5,9 @@ import jwt "github.com/golang-jwt/jwt/v4"
 const PLACEHOLDER_KEY_TEXT = "ACCESS_KEY"
 const PLACEHOLDER_TOKEN_TEXT = "ACCESS_TOKEN"
 
-// AuthParams - struct for auth params
+// AuthParams - struct for auth params, nodes that have no id yet authenticate with their mac address
 type AuthParams struct {
+	ID         string `json:"id"`
 	MacAddress string `json:"macaddress"`
 	Password   string `json:"password"`
 }
@@ -48,14 +49,17 @@ type SuccessfulUserLoginResponse struct {
 
 // Claims is  a struct that will be encoded to a JWT.
 // jwt.StandardClaims is an embedded type to provide expiry time
+// MacAddress is only set in tokens issued before nodes had an ID
 type Claims struct {
 	Network    string
-	MacAddress string
+	ID         string
+	MacAddress string `json:",omitempty"`
 	jwt.StandardClaims
 }
 
 // SuccessfulLoginResponse is struct to send the request response
 type SuccessfulLoginResponse struct {
+	ID         string
 	MacAddress string
 	AuthToken  string
 }
@@ -70,6 +74,7 @@ type ErrorResponse struct {
 type NodeAuth struct {
 	Network    string
 	Password   string
+	UUID       string
 	MacAddress string
 }
 
@@ -153,3 +158,21 @@ type RelayRequest struct {
 	NetID      string   `json:"netid" bson:"netid"`
 	RelayAddrs []string `json:"relayaddrs" bson:"relayaddrs"`
 }
+
+// KeyRotationPolicy - a change of the key rotation policy of a network, fields left out keep their value
+type KeyRotationPolicy struct {
+	KeyRotationDays         *int32 `json:"keyrotationdays" bson:"keyrotationdays"`
+	KeyRotationStaggerHours *int32 `json:"keyrotationstaggerhours" bson:"keyrotationstaggerhours"`
+}
+
+// KeyRotationStatus - the key age of a node and when the key rotation policy of its network rotates it
+type KeyRotationStatus struct {
+	NodeID             string `json:"nodeid" bson:"nodeid"`
+	Name               string `json:"name" bson:"name"`
+	Network            string `json:"network" bson:"network"`
+	PublicKey          string `json:"publickey" bson:"publickey"`
+	KeyUpdateTimeStamp int64  `json:"keyupdatetimestamp" bson:"keyupdatetimestamp"`
+	KeyAgeDays         int64  `json:"keyagedays" bson:"keyagedays"`
+	DueTimeStamp       int64  `json:"duetimestamp" bson:"duetimestamp"`
+	RotationPending    bool   `json:"rotationpending" bson:"rotationpending"`
+}
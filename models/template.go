@@ -0,0 +1,45 @@
+package models
+
+// NetworkTemplate - stored settings networks are created from, with the dns entries, gateways and access keys they start with
+// the address range of the network is the range the addresses of the dns entries are moved out of
+type NetworkTemplate struct {
+	Name       string              `json:"name" bson:"name"`
+	Network    Network             `json:"network" bson:"network"`
+	DNSEntries []DNSEntry          `json:"dnsentries" bson:"dnsentries"`
+	Gateways   []GatewayTemplate   `json:"gateways" bson:"gateways"`
+	AccessKeys []AccessKeyTemplate `json:"accesskeys" bson:"accesskeys"`
+}
+
+// GatewayTemplate - a gateway made on the server node of a network created from a template
+type GatewayTemplate struct {
+	IsEgressGateway  bool     `json:"isegressgateway" bson:"isegressgateway"`
+	Ranges           []string `json:"ranges" bson:"ranges"`
+	Interface        string   `json:"interface" bson:"interface"`
+	PostUp           string   `json:"postup" bson:"postup"`
+	PostDown         string   `json:"postdown" bson:"postdown"`
+	IsIngressGateway bool     `json:"isingressgateway" bson:"isingressgateway"`
+}
+
+// AccessKeyTemplate - an access key made for a network created from a template, with a new value
+type AccessKeyTemplate struct {
+	Name string `json:"name" bson:"name"`
+	Uses int    `json:"uses" bson:"uses"`
+}
+
+// CloneNetworkRequest - the network a network or template is cloned into
+type CloneNetworkRequest struct {
+	NetID         string `json:"netid" bson:"netid"`
+	DisplayName   string `json:"displayname" bson:"displayname"`
+	AddressRange  string `json:"addressrange" bson:"addressrange"`
+	AddressRange6 string `json:"addressrange6" bson:"addressrange6"`
+}
+
+// CloneNetworkResponse - the network made by a clone, with what was made in it
+type CloneNetworkResponse struct {
+	Network    Network     `json:"network" bson:"network"`
+	AccessKeys []AccessKey `json:"accesskeys" bson:"accesskeys"`
+	DNSEntries []DNSEntry  `json:"dnsentries" bson:"dnsentries"`
+	Gateways   []string    `json:"gateways" bson:"gateways"`
+	// what could not be made, with the reason
+	Skipped []string `json:"skipped" bson:"skipped"`
+}
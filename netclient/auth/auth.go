@@ -7,6 +7,7 @@ import (
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/netclient/config"
 	"github.com/gravitl/netmaker/netclient/ncutils"
+	"github.com/gravitl/netmaker/netclient/secrets"
 
 	//    "os"
 	"context"
@@ -54,6 +55,7 @@ func AutoLogin(client nodepb.NodeServiceClient, network string) error {
 	}
 	node := models.Node{
 		Password:   pass,
+		UUID:       cfg.Node.UUID,
 		MacAddress: cfg.Node.MacAddress,
 		Network:    network,
 	}
@@ -71,24 +73,29 @@ func AutoLogin(client nodepb.NodeServiceClient, network string) error {
 		return err
 	}
 	tokenstring := []byte(res.Data)
-	err = ioutil.WriteFile(home+"nettoken-"+network, tokenstring, 0644)
+	err = ncutils.WriteFileAtomic(home+"nettoken-"+network, tokenstring, 0600)
 	if err != nil {
 		return err
 	}
 	return err
 }
 
-// StoreSecret - stores auth secret locally
+// StoreSecret - stores auth secret in the secret store of the network
 func StoreSecret(key string, network string) error {
-	d1 := []byte(key)
-	err := ioutil.WriteFile(ncutils.GetNetclientPathSpecific()+"secret-"+network, d1, 0644)
-	return err
+	store, err := config.GetSecretStore(network)
+	if err != nil {
+		return err
+	}
+	return store.Store(secrets.PasswordName(network), key)
 }
 
-// RetrieveSecret - fetches secret locally
+// RetrieveSecret - fetches secret from the secret store of the network
 func RetrieveSecret(network string) (string, error) {
-	dat, err := ioutil.ReadFile(ncutils.GetNetclientPathSpecific() + "secret-" + network)
-	return string(dat), err
+	store, err := config.GetSecretStore(network)
+	if err != nil {
+		return "", err
+	}
+	return store.Retrieve(secrets.PasswordName(network))
 }
 
 // Configuraion - struct for mac and pass
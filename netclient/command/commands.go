@@ -1,6 +1,8 @@
 package command
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"strconv"
@@ -162,8 +164,11 @@ func Push(cfg config.ClientConfig) error {
 	return err
 }
 
-func Pull(cfg config.ClientConfig) error {
+func Pull(cfg config.ClientConfig, dryRun bool) error {
 	var err error
+	if dryRun {
+		return PlanPull(cfg)
+	}
 	if cfg.Network == "all" {
 		ncutils.PrintLog("No network selected. Running Pull for all networks.", 0)
 		networks, err := ncutils.GetSystemNetworks()
@@ -188,6 +193,26 @@ func Pull(cfg config.ClientConfig) error {
 	return err
 }
 
+// PlanPull - prints what a pull would change on the interfaces of one or all networks, without changing them
+func PlanPull(cfg config.ClientConfig) error {
+	var err error
+	networks := []string{cfg.Network}
+	if cfg.Network == "all" {
+		if networks, err = ncutils.GetSystemNetworks(); err != nil {
+			return err
+		}
+	}
+	for _, network := range networks {
+		plan, err := functions.PlanPull(network)
+		if err != nil {
+			return errors.New("could not plan pull of " + network + ": " + err.Error())
+		}
+		fmt.Println("network " + network)
+		fmt.Println(plan.String())
+	}
+	return nil
+}
+
 func List(cfg config.ClientConfig) error {
 	err := functions.List(cfg.Network)
 	return err
@@ -198,3 +223,68 @@ func Uninstall() error {
 	err := functions.Uninstall()
 	return err
 }
+
+// MigrateSecrets - moves the password and private key of one or all networks to another secret store
+func MigrateSecrets(cfg config.ClientConfig, to string) error {
+	var err error
+	networks := []string{cfg.Network}
+	if cfg.Network == "all" {
+		if networks, err = ncutils.GetSystemNetworks(); err != nil {
+			return err
+		}
+	}
+	for _, network := range networks {
+		if err = functions.MigrateSecrets(network, to); err != nil {
+			return errors.New("could not migrate secrets of " + network + ": " + err.Error())
+		}
+		ncutils.PrintLog("moved secrets of "+network+" to the "+to+" store", 1)
+	}
+	return nil
+}
+
+// Apply - joins, updates and leaves networks until the joined networks match a manifest,
+// a dry run only prints the changes
+func Apply(file string, dryRun bool) error {
+	manifest, err := config.ReadManifest(file)
+	if err != nil {
+		return err
+	}
+	joined, err := functions.ReadJoinedNetworks()
+	if err != nil {
+		return err
+	}
+	actions, err := functions.PlanApply(manifest, joined)
+	if err != nil {
+		return err
+	}
+	if len(actions) == 0 {
+		ncutils.PrintLog("joined networks match "+file, 0)
+		return nil
+	}
+	var failed []string
+	for _, action := range actions {
+		ncutils.PrintLog(action.Action+" "+action.Network, 0)
+		for _, change := range action.Changes {
+			ncutils.PrintLog("    "+change, 0)
+		}
+		if dryRun {
+			continue
+		}
+		switch action.Action {
+		case functions.APPLY_JOIN:
+			err = Join(action.Config, "")
+		case functions.APPLY_UPDATE:
+			err = functions.UpdateNetwork(action.Config)
+		case functions.APPLY_LEAVE:
+			err = functions.LeaveNetwork(action.Network)
+		}
+		if err != nil {
+			ncutils.PrintLog("could not "+action.Action+" "+action.Network+": "+err.Error(), 0)
+			failed = append(failed, action.Network)
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New("could not apply " + file + " to networks " + strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package command
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/gravitl/netmaker/netclient/daemon"
+	"github.com/gravitl/netmaker/netclient/functions"
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// NetworkState - checkin state of a network run by the daemon
+type NetworkState struct {
+	Network     string    `json:"network"`
+	Server      string    `json:"server"`
+	Interval    int       `json:"interval"`
+	LastCheckin time.Time `json:"lastcheckin"`
+	LastSuccess time.Time `json:"lastsuccess"`
+	LastError   string    `json:"lasterror,omitempty"`
+	Checkins    int       `json:"checkins"`
+	Failures    int       `json:"failures"`
+}
+
+// DaemonState - state the daemon exposes on its socket
+type DaemonState struct {
+	PID      int            `json:"pid"`
+	Started  time.Time      `json:"started"`
+	Reloaded time.Time      `json:"reloaded"`
+	Networks []NetworkState `json:"networks"`
+}
+
+// netclientDaemon - runs the checkins of every network on its own interval
+type netclientDaemon struct {
+	sync.Mutex
+	started  time.Time
+	reloaded time.Time
+	networks map[string]*NetworkState
+	stop     chan struct{}
+	running  sync.WaitGroup
+	// checkins change interfaces and routes, so only one runs at a time
+	checkinLock sync.Mutex
+	checkin     func(network string) error
+	status      func(network string) (functions.NetworkStatus, error)
+}
+
+func newDaemon() *netclientDaemon {
+	return &netclientDaemon{
+		started:  time.Now(),
+		networks: make(map[string]*NetworkState),
+		checkin:  checkinNetwork,
+		status:   functions.GetNetworkStatus,
+	}
+}
+
+// Daemon - runs the netclient until it is stopped, checking in to every network it has joined
+// SIGHUP makes it re-read the networks and their configs
+func Daemon() error {
+	ncutils.KeepGRPCConns()
+	defer ncutils.CloseGRPCConns()
+
+	if err := ncutils.RestrictStateFiles(); err != nil {
+		ncutils.PrintLog("could not restrict permissions of netclient files: "+err.Error(), 1)
+	}
+	d := newDaemon()
+	d.start()
+	listener, err := d.serve(ncutils.GetDaemonSocketPath())
+	if err != nil {
+		ncutils.PrintLog("could not open daemon socket: "+err.Error(), 1)
+	} else {
+		defer listener.Close()
+	}
+	if err = daemon.SDNotify("READY=1"); err != nil {
+		ncutils.PrintLog("could not notify systemd: "+err.Error(), 1)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, os.Interrupt, syscall.SIGTERM)
+	for sig := range signals {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		ncutils.PrintLog("reloading netclient daemon", 1)
+		daemon.SDNotify("RELOADING=1")
+		d.halt()
+		ncutils.CloseGRPCConns()
+		d.start()
+		daemon.SDNotify("READY=1")
+	}
+	ncutils.PrintLog("stopping netclient daemon", 1)
+	daemon.SDNotify("STOPPING=1")
+	d.halt()
+	return nil
+}
+
+// netclientDaemon.start - schedules the checkins of the networks on the machine
+func (d *netclientDaemon) start() {
+	networks, err := ncutils.GetSystemNetworks()
+	if err != nil {
+		ncutils.PrintLog("error retrieving networks: "+err.Error(), 1)
+	}
+	d.Lock()
+	defer d.Unlock()
+	d.reloaded = time.Now()
+	d.stop = make(chan struct{})
+	current := make(map[string]*NetworkState)
+	for _, network := range networks {
+		cfg, err := config.ReadConfig(network)
+		if err != nil {
+			ncutils.PrintLog("error reading config of "+network+": "+err.Error(), 1)
+			continue
+		}
+		state, ok := d.networks[network]
+		if !ok {
+			state = &NetworkState{Network: network}
+		}
+		state.Server = cfg.Server.GRPCAddress
+		state.Interval = checkinInterval(cfg)
+		current[network] = state
+		d.running.Add(1)
+		go d.schedule(network, time.Duration(state.Interval)*time.Second, d.stop)
+	}
+	d.networks = current
+	ncutils.PrintLog("checking in to "+strconv.Itoa(len(current))+" networks", 1)
+}
+
+// netclientDaemon.halt - stops the schedulers and waits for running checkins
+func (d *netclientDaemon) halt() {
+	d.Lock()
+	close(d.stop)
+	d.Unlock()
+	d.running.Wait()
+}
+
+// netclientDaemon.schedule - checks in to a network right away and then on every interval until stopped
+func (d *netclientDaemon) schedule(network string, interval time.Duration, stop chan struct{}) {
+	defer d.running.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		d.run(network)
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// netclientDaemon.run - runs a single checkin and records its outcome
+func (d *netclientDaemon) run(network string) {
+	d.checkinLock.Lock()
+	err := d.checkin(network)
+	d.checkinLock.Unlock()
+
+	d.Lock()
+	defer d.Unlock()
+	state, ok := d.networks[network]
+	if !ok {
+		return
+	}
+	state.LastCheckin = time.Now()
+	state.Checkins++
+	if err != nil {
+		state.Failures++
+		state.LastError = err.Error()
+		ncutils.PrintLog("error checking in for "+network+" network: "+err.Error(), 1)
+	} else {
+		state.LastSuccess = state.LastCheckin
+		state.LastError = ""
+	}
+}
+
+// netclientDaemon.State - gets a copy of the state of the daemon
+func (d *netclientDaemon) State() DaemonState {
+	d.Lock()
+	defer d.Unlock()
+	state := DaemonState{
+		PID:      os.Getpid(),
+		Started:  d.started,
+		Reloaded: d.reloaded,
+		Networks: []NetworkState{},
+	}
+	for _, networkState := range d.networks {
+		state.Networks = append(state.Networks, *networkState)
+	}
+	sort.Slice(state.Networks, func(i, j int) bool {
+		return state.Networks[i].Network < state.Networks[j].Network
+	})
+	return state
+}
+
+// netclientDaemon.serve - serves the control api of the daemon on a unix socket only root can reach
+func (d *netclientDaemon) serve(socketPath string) (net.Listener, error) {
+	// a socket left by a daemon that did not stop cleanly blocks listening
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	// GET /status?network= - live state of one or all networks together with the daemon state
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		report, err := d.statusReport(r.URL.Query().Get("network"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	})
+	// POST /checkin?network= - checks in to one or all networks right away
+	mux.HandleFunc("/checkin", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		networks := d.networkNames(r.URL.Query().Get("network"))
+		if len(networks) == 0 {
+			http.Error(w, "network not found", http.StatusNotFound)
+			return
+		}
+		for _, network := range networks {
+			d.run(network)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(d.State())
+	})
+	go http.Serve(listener, mux)
+	return listener, nil
+}
+
+// netclientDaemon.networkNames - gets the networks the daemon runs, or only the given one
+func (d *netclientDaemon) networkNames(network string) []string {
+	d.Lock()
+	defer d.Unlock()
+	var networks []string
+	for name := range d.networks {
+		if network == "" || network == "all" || network == name {
+			networks = append(networks, name)
+		}
+	}
+	sort.Strings(networks)
+	return networks
+}
+
+// netclientDaemon.statusReport - gets the live state of the networks the daemon runs
+func (d *netclientDaemon) statusReport(network string) (StatusReport, error) {
+	state := d.State()
+	report := StatusReport{Daemon: &state, Networks: []functions.NetworkStatus{}}
+	for _, name := range d.networkNames(network) {
+		status, err := d.status(name)
+		if err != nil {
+			return report, err
+		}
+		report.Networks = append(report.Networks, status)
+	}
+	return report, nil
+}
+
+func checkinNetwork(network string) error {
+	cfg, err := config.ReadConfig(network)
+	if err != nil {
+		return err
+	}
+	return functions.CheckConfig(*cfg)
+}
+
+func checkinInterval(cfg *config.ClientConfig) int {
+	interval, err := strconv.Atoi(cfg.Server.CheckinInterval)
+	if err != nil || interval <= 0 {
+		return 15
+	}
+	return interval
+}
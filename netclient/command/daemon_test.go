@@ -0,0 +1,117 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gravitl/netmaker/netclient/functions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDaemonState(t *testing.T) {
+	d := newDaemon()
+	d.networks["skynet"] = &NetworkState{Network: "skynet", Interval: 15}
+	d.networks["wirecat"] = &NetworkState{Network: "wirecat", Interval: 15}
+	d.checkin = func(network string) error {
+		if network == "wirecat" {
+			return errors.New("server unreachable")
+		}
+		return nil
+	}
+	d.status = func(network string) (functions.NetworkStatus, error) {
+		return functions.NetworkStatus{Network: network, ServerReachable: network == "skynet"}, nil
+	}
+	d.run("skynet")
+	d.run("wirecat")
+	d.run("removed")
+
+	dir, err := ioutil.TempDir("", "netclient-daemon")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "netclient.sock")
+	listener, err := d.serve(socketPath)
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	client := &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+	t.Run("Status", func(t *testing.T) {
+		report, err := requestDaemonStatus(socketPath, "all")
+		assert.Nil(t, err)
+		assert.Len(t, report.Networks, 2)
+		assert.True(t, report.Networks[0].ServerReachable)
+		assert.False(t, report.Networks[1].ServerReachable)
+		assert.NotNil(t, report.Daemon)
+		report, err = requestDaemonStatus(socketPath, "wirecat")
+		assert.Nil(t, err)
+		assert.Len(t, report.Networks, 1)
+	})
+	t.Run("Checkin", func(t *testing.T) {
+		res, err := client.Post("http://netclient/checkin?network=skynet", "", nil)
+		assert.Nil(t, err)
+		defer res.Body.Close()
+		var state DaemonState
+		assert.Nil(t, json.NewDecoder(res.Body).Decode(&state))
+		assert.Equal(t, 2, state.Networks[0].Checkins)
+		assert.Equal(t, 1, state.Networks[1].Checkins)
+		res, err = client.Post("http://netclient/checkin?network=removed", "", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+	state := d.State()
+	assert.Equal(t, os.Getpid(), state.PID)
+	assert.Len(t, state.Networks, 2)
+	assert.Equal(t, "skynet", state.Networks[0].Network)
+	assert.Equal(t, 2, state.Networks[0].Checkins)
+	assert.Equal(t, 0, state.Networks[0].Failures)
+	assert.False(t, state.Networks[0].LastSuccess.IsZero())
+	assert.Equal(t, "wirecat", state.Networks[1].Network)
+	assert.Equal(t, 1, state.Networks[1].Failures)
+	assert.Equal(t, "server unreachable", state.Networks[1].LastError)
+}
+
+func TestPrintStatus(t *testing.T) {
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	report := StatusReport{Networks: []functions.NetworkStatus{{
+		Network:         "skynet",
+		Server:          "api.skynet.example:50051",
+		ServerReachable: true,
+		LastCheckin:     now.Add(-30 * time.Second),
+		Interface:       functions.InterfaceStatus{Name: "nm-skynet", Exists: true, Up: true, MTU: 1280},
+		Peers: []functions.PeerStatus{{
+			Name:          "node-2",
+			Endpoint:      "203.0.113.2:51821",
+			AllowedIPs:    []string{"10.10.10.2/32"},
+			LastHandshake: now.Add(-time.Minute),
+			ReceiveBytes:  2048,
+			TransmitBytes: 100,
+		}},
+		PendingActions: []string{"pullchanges"},
+	}}}
+	var out strings.Builder
+	printStatus(&out, report, now)
+	assert.Contains(t, out.String(), "daemon: not running")
+	assert.Contains(t, out.String(), "api.skynet.example:50051 reachable")
+	assert.Contains(t, out.String(), "nm-skynet up, mtu 1280")
+	assert.Contains(t, out.String(), "last checkin:  30s ago")
+	assert.Contains(t, out.String(), "pending:       pullchanges")
+	assert.Contains(t, out.String(), "1m0s ago")
+	assert.Contains(t, out.String(), "2.0 KiB")
+	assert.Contains(t, out.String(), "100 B")
+}
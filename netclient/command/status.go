@@ -0,0 +1,181 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/gravitl/netmaker/netclient/functions"
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// StatusReport - state of the networks shown by netclient status, daemon is only set when it runs
+type StatusReport struct {
+	Daemon   *DaemonState              `json:"daemon,omitempty"`
+	Networks []functions.NetworkStatus `json:"networks"`
+}
+
+// Status - prints the state of the networks, asking the daemon first and reading it locally when it does not run
+func Status(cfg config.ClientConfig, asJSON bool) error {
+	report, err := requestDaemonStatus(ncutils.GetDaemonSocketPath(), cfg.Network)
+	if err != nil {
+		if report, err = buildStatusReport(cfg.Network); err != nil {
+			return err
+		}
+	}
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	printStatus(os.Stdout, report, time.Now())
+	return nil
+}
+
+// buildStatusReport - reads the state of one or all networks
+func buildStatusReport(network string) (StatusReport, error) {
+	report := StatusReport{Networks: []functions.NetworkStatus{}}
+	networks := []string{network}
+	if network == "all" {
+		var err error
+		if networks, err = ncutils.GetSystemNetworks(); err != nil {
+			return report, err
+		}
+	}
+	for _, network := range networks {
+		status, err := functions.GetNetworkStatus(network)
+		if err != nil {
+			return report, errors.New("could not read status of network " + network + ": " + err.Error())
+		}
+		report.Networks = append(report.Networks, status)
+	}
+	return report, nil
+}
+
+// daemonClient - http client that talks to the daemon over its socket
+func daemonClient(socketPath string) *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+}
+
+func requestDaemonStatus(socketPath string, network string) (StatusReport, error) {
+	var report StatusReport
+	if !ncutils.FileExists(socketPath) {
+		return report, errors.New("daemon is not running")
+	}
+	res, err := daemonClient(socketPath).Get("http://netclient/status?network=" + url.QueryEscape(network))
+	if err != nil {
+		return report, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return report, errors.New("daemon returned " + res.Status)
+	}
+	err = json.NewDecoder(res.Body).Decode(&report)
+	return report, err
+}
+
+func printStatus(out io.Writer, report StatusReport, now time.Time) {
+	if report.Daemon != nil {
+		fmt.Fprintf(out, "daemon: running, pid %d, since %s\n", report.Daemon.PID, report.Daemon.Started.Format(time.RFC3339))
+	} else {
+		fmt.Fprintln(out, "daemon: not running")
+	}
+	for _, network := range report.Networks {
+		fmt.Fprintf(out, "\nnetwork %s\n", network.Network)
+		server := "reachable"
+		if !network.ServerReachable {
+			server = "unreachable"
+			if network.ServerError != "" {
+				server += " (" + network.ServerError + ")"
+			}
+		}
+		fmt.Fprintf(out, "  server:        %s %s\n", network.Server, server)
+		iface := network.Interface.Name + " missing"
+		if network.Interface.Exists {
+			state := "down"
+			if network.Interface.Up {
+				state = "up"
+			}
+			iface = fmt.Sprintf("%s %s, mtu %d", network.Interface.Name, state, network.Interface.MTU)
+			if network.Interface.ListenPort != 0 {
+				iface += ", port " + strconv.Itoa(network.Interface.ListenPort)
+			}
+			if len(network.Interface.Addresses) > 0 {
+				iface += ", " + strings.Join(network.Interface.Addresses, " ")
+			}
+		}
+		fmt.Fprintf(out, "  interface:     %s\n", iface)
+		fmt.Fprintf(out, "  last checkin:  %s\n", formatSince(network.LastCheckin, now))
+		if report.Daemon != nil {
+			for _, state := range report.Daemon.Networks {
+				if state.Network == network.Network && state.LastError != "" {
+					fmt.Fprintf(out, "  last error:    %s\n", state.LastError)
+				}
+			}
+		}
+		pending := "none"
+		if len(network.PendingActions) > 0 {
+			pending = strings.Join(network.PendingActions, ", ")
+		}
+		fmt.Fprintf(out, "  pending:       %s\n", pending)
+		if len(network.Peers) == 0 {
+			fmt.Fprintln(out, "  peers:         none")
+			continue
+		}
+		fmt.Fprintln(out, "  peers:")
+		table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "    NAME\tENDPOINT\tALLOWED IPS\tHANDSHAKE\tRECEIVED\tSENT")
+		for _, peer := range network.Peers {
+			name := peer.Name
+			if name == "" {
+				name = ncutils.ShortenString(peer.PublicKey, 8)
+			}
+			endpoint := peer.Endpoint
+			if endpoint == "" {
+				endpoint = "-"
+			}
+			fmt.Fprintf(table, "    %s\t%s\t%s\t%s\t%s\t%s\n", name, endpoint, strings.Join(peer.AllowedIPs, ","),
+				formatSince(peer.LastHandshake, now), formatBytes(peer.ReceiveBytes), formatBytes(peer.TransmitBytes))
+		}
+		table.Flush()
+	}
+}
+
+func formatSince(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return now.Sub(t).Truncate(time.Second).String() + " ago"
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return strconv.FormatInt(bytes, 10) + " B"
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	//"github.com/davecgh/go-spew/spew"
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -12,18 +13,23 @@ import (
 
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/netclient/ncutils"
+	"github.com/gravitl/netmaker/netclient/secrets"
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v3"
 )
 
-// GlobalConfig - struct for handling IntClients currently
-type GlobalConfig struct {
-	GRPCWireGuard string `yaml:"grpcwg"`
-	Client        models.IntClient
-}
+// CONFIG_SCHEMA_VERSION - version of the config format written to disk, raised when the format changes
+const CONFIG_SCHEMA_VERSION = 1
+
+// CONFIG_FILE_MODE - configs hold the secrets of the node, so only root reads them
+const CONFIG_FILE_MODE = 0600
+
+// errNewerSchema - the config was written by a newer netclient, it is not replaced with an older backup
+var errNewerSchema = errors.New("config was written by a newer netclient")
 
 // ClientConfig - struct for dealing with client configuration
 type ClientConfig struct {
+	SchemaVersion   int            `yaml:"schemaversion"`
 	Server          ServerConfig   `yaml:"server"`
 	Node            models.Node    `yaml:"node"`
 	NetworkSettings models.Network `yaml:"networksettings"`
@@ -32,6 +38,9 @@ type ClientConfig struct {
 	OperatingSystem string         `yaml:"operatingsystem"`
 	DebugJoin       bool           `yaml:"debugjoin"`
 	FWMark          int32          `yaml:"fwmark"`
+	DNSConfigurator string         `yaml:"dnsconfigurator"`
+	MTUProbeTime    int64          `yaml:"mtuprobetime"`
+	SecretStore     string         `yaml:"secretstore"`
 }
 
 // ServerConfig - struct for dealing with the server information for a netclient
@@ -46,31 +55,21 @@ type ServerConfig struct {
 }
 
 // Write - writes the config of a client to disk
+// the file is replaced atomically, so a crash leaves either the old or the new config
 func Write(config *ClientConfig, network string) error {
 	if network == "" {
 		err := errors.New("no network provided - exiting")
 		return err
 	}
-	_, err := os.Stat(ncutils.GetNetclientPath() + "/config")
-	if os.IsNotExist(err) {
-		os.MkdirAll(ncutils.GetNetclientPath()+"/config", 0744)
-	} else if err != nil {
+	if err := ensureConfigDir(); err != nil {
 		return err
 	}
-	home := ncutils.GetNetclientPathSpecific()
-
-	file := fmt.Sprintf(home + "netconfig-" + network)
-	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	config.SchemaVersion = CONFIG_SCHEMA_VERSION
+	data, err := yaml.Marshal(config)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	err = yaml.NewEncoder(f).Encode(config)
-	if err != nil {
-		return err
-	}
-	return err
+	return ncutils.WriteFileAtomic(getConfigPath(network), data, CONFIG_FILE_MODE)
 }
 
 // WriteServer - writes the config of a server to disk for client
@@ -79,115 +78,36 @@ func WriteServer(server string, accesskey string, network string) error {
 		err := errors.New("no network provided - exiting")
 		return err
 	}
-	nofile := false
-	//home, err := homedir.Dir()
-	_, err := os.Stat(ncutils.GetNetclientPath() + "/config")
-	if os.IsNotExist(err) {
-		os.MkdirAll(ncutils.GetNetclientPath()+"/config", 0744)
-	} else if err != nil {
-		fmt.Println("couldnt find or create", ncutils.GetNetclientPath())
-		return err
-	}
-	home := ncutils.GetNetclientPathSpecific()
-
-	file := fmt.Sprintf(home + "netconfig-" + network)
-	//f, err := os.Open(file)
-	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDWR, 0666)
-	//f, err := ioutil.ReadFile(file)
-	if err != nil {
-		fmt.Println("couldnt open netconfig-" + network)
-		fmt.Println(err)
-		nofile = true
-		//err = nil
-		return err
-	}
-	defer f.Close()
-
-	//cfg := &ClientConfig{}
 	var cfg ClientConfig
-
-	if !nofile {
-		fmt.Println("Writing to existing config file at " + home + "netconfig-" + network)
-		decoder := yaml.NewDecoder(f)
-		err = decoder.Decode(&cfg)
-		//err = yaml.Unmarshal(f, &cfg)
-		if err != nil {
-			//fmt.Println(err)
-			//return err
-		}
-		f.Close()
-		f, err = os.OpenFile(file, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
-		if err != nil {
-			fmt.Println("couldnt open netconfig")
-			fmt.Println(err)
-			nofile = true
-			//err = nil
-			return err
-		}
-		defer f.Close()
-
-		if err != nil {
-			fmt.Println("trouble opening file")
-			fmt.Println(err)
-		}
-
-		cfg.Server.GRPCAddress = server
-		cfg.Server.AccessKey = accesskey
-
-		err = yaml.NewEncoder(f).Encode(cfg)
-		//_, err = yaml.Marshal(f, &cfg)
+	if FileExists(getConfigPath(network)) {
+		fmt.Println("Writing to existing config file at " + getConfigPath(network))
+		existing, err := ReadConfig(network)
 		if err != nil {
-			fmt.Println("trouble encoding file")
 			return err
 		}
+		cfg = *existing
 	} else {
-		fmt.Println("Creating new config file at " + home + "netconfig-" + network)
-
-		cfg.Server.GRPCAddress = server
-		cfg.Server.AccessKey = accesskey
-
-		newf, err := os.Create(home + "netconfig-" + network)
-		err = yaml.NewEncoder(newf).Encode(cfg)
-		defer newf.Close()
-		if err != nil {
-			return err
-		}
+		fmt.Println("Creating new config file at " + getConfigPath(network))
 	}
-
-	return err
+	cfg.Server.GRPCAddress = server
+	cfg.Server.AccessKey = accesskey
+	return Write(&cfg, network)
 }
 
 // ClientConfig.ReadConfig - used to read config from client disk into memory
 func (config *ClientConfig) ReadConfig() {
-
-	nofile := false
-	//home, err := homedir.Dir()
-	home := ncutils.GetNetclientPathSpecific()
-	file := fmt.Sprintf(home + "netconfig-" + config.Network)
-	//f, err := os.Open(file)
-	f, err := os.OpenFile(file, os.O_RDONLY, 0666)
-	if err != nil {
+	if !FileExists(getConfigPath(config.Network)) {
 		fmt.Println("trouble opening file")
-		fmt.Println(err)
-		nofile = true
-		//fmt.Println("Could not access " + home + "/.netconfig,  proceeding...")
+		return
 	}
-	defer f.Close()
-
-	//var cfg ClientConfig
-
-	if !nofile {
-		decoder := yaml.NewDecoder(f)
-		err = decoder.Decode(&config)
-		if err != nil {
-			fmt.Println("no config or invalid")
-			fmt.Println(err)
-			log.Fatal(err)
-		} else {
-			config.Node.SetID()
-			//config = cfg
-		}
+	cfg, err := ReadConfig(config.Network)
+	if err != nil {
+		fmt.Println("no config or invalid")
+		fmt.Println(err)
+		log.Fatal(err)
 	}
+	*config = *cfg
+	config.Node.SetID()
 }
 
 // ModConfig - overwrites the node inside client config on disk
@@ -199,7 +119,7 @@ func ModConfig(node *models.Node) error {
 	}
 	var modconfig ClientConfig
 	var err error
-	if FileExists(ncutils.GetNetclientPathSpecific() + "netconfig-" + network) {
+	if FileExists(getConfigPath(network)) {
 		useconfig, err := ReadConfig(network)
 		if err != nil {
 			return err
@@ -213,18 +133,21 @@ func ModConfig(node *models.Node) error {
 	return err
 }
 
-// ModConfig - overwrites the node inside client config on disk
+// SaveBackup - keeps a copy of the network config to recover from, only configs that can be read are copied
 func SaveBackup(network string) error {
-
-	var configPath = ncutils.GetNetclientPathSpecific() + "netconfig-" + network
-	var backupPath = ncutils.GetNetclientPathSpecific() + "backup.netconfig-" + network
+	var configPath = getConfigPath(network)
+	var backupPath = getBackupPath(network)
 	if FileExists(configPath) {
 		input, err := ioutil.ReadFile(configPath)
 		if err != nil {
 			ncutils.Log("failed to read " + configPath + " to make a backup")
 			return err
 		}
-		if err = ioutil.WriteFile(backupPath, input, 0644); err != nil {
+		if _, err = parseConfig(input); err != nil {
+			ncutils.Log("not making a backup of " + configPath + ": " + err.Error())
+			return err
+		}
+		if err = ncutils.WriteFileAtomic(backupPath, input, CONFIG_FILE_MODE); err != nil {
 			ncutils.Log("failed to copy backup to " + backupPath)
 			return err
 		}
@@ -234,18 +157,23 @@ func SaveBackup(network string) error {
 
 // ReplaceWithBackup - replaces netconfig file with backup
 func ReplaceWithBackup(network string) error {
-	var backupPath = ncutils.GetNetclientPathSpecific() + "backup.netconfig-" + network
-	var configPath = ncutils.GetNetclientPathSpecific() + "netconfig-" + network
-	if FileExists(backupPath) {
-		input, err := ioutil.ReadFile(backupPath)
-		if err != nil {
-			ncutils.Log("failed to read file " + backupPath + " to backup network: " + network)
-			return err
-		}
-		if err = ioutil.WriteFile(configPath, input, 0644); err != nil {
-			ncutils.Log("failed backup " + backupPath + " to " + configPath)
-			return err
-		}
+	var backupPath = getBackupPath(network)
+	var configPath = getConfigPath(network)
+	if !FileExists(backupPath) {
+		return errors.New("no backup of network " + network)
+	}
+	input, err := ioutil.ReadFile(backupPath)
+	if err != nil {
+		ncutils.Log("failed to read file " + backupPath + " to backup network: " + network)
+		return err
+	}
+	if _, err = parseConfig(input); err != nil {
+		ncutils.Log("backup " + backupPath + " is not usable: " + err.Error())
+		return err
+	}
+	if err = ncutils.WriteFileAtomic(configPath, input, CONFIG_FILE_MODE); err != nil {
+		ncutils.Log("failed backup " + backupPath + " to " + configPath)
+		return err
 	}
 	ncutils.Log("used backup file for network: " + network)
 	return nil
@@ -255,42 +183,9 @@ func ReplaceWithBackup(network string) error {
 func GetCLIConfig(c *cli.Context) (ClientConfig, string, error) {
 	var cfg ClientConfig
 	if c.String("token") != "" {
-		tokenbytes, err := base64.StdEncoding.DecodeString(c.String("token"))
-		if err != nil {
-			log.Println("error decoding token")
+		if err := ReadAccessToken(&cfg, c.String("token")); err != nil {
 			return cfg, "", err
 		}
-		var accesstoken models.AccessToken
-		if err := json.Unmarshal(tokenbytes, &accesstoken); err != nil {
-			log.Println("error converting token json to object", tokenbytes)
-			return cfg, "", err
-		}
-
-		if accesstoken.ServerConfig.APIConnString != "" {
-			cfg.Server.APIAddress = accesstoken.ServerConfig.APIConnString
-		} else {
-			cfg.Server.APIAddress = accesstoken.ServerConfig.APIHost
-			if accesstoken.ServerConfig.APIPort != "" {
-				cfg.Server.APIAddress = cfg.Server.APIAddress + ":" + accesstoken.ServerConfig.APIPort
-			}
-		}
-		if accesstoken.ServerConfig.GRPCConnString != "" {
-			cfg.Server.GRPCAddress = accesstoken.ServerConfig.GRPCConnString
-		} else {
-			cfg.Server.GRPCAddress = accesstoken.ServerConfig.GRPCHost
-			if accesstoken.ServerConfig.GRPCPort != "" {
-				cfg.Server.GRPCAddress = cfg.Server.GRPCAddress + ":" + accesstoken.ServerConfig.GRPCPort
-			}
-		}
-
-		cfg.Network = accesstoken.ClientConfig.Network
-		cfg.Node.Network = accesstoken.ClientConfig.Network
-		cfg.Server.AccessKey = accesstoken.ClientConfig.Key
-		cfg.Node.LocalRange = accesstoken.ClientConfig.LocalRange
-		cfg.Server.GRPCSSL = accesstoken.ServerConfig.GRPCSSL
-		cfg.Server.CheckinInterval = accesstoken.ServerConfig.CheckinInterval
-		cfg.Server.GRPCWireGuard = accesstoken.WG.GRPCWireGuard
-		cfg.Server.CoreDNSAddr = accesstoken.ServerConfig.CoreDNSAddr
 		if c.String("grpcserver") != "" {
 			cfg.Server.GRPCAddress = c.String("grpcserver")
 		}
@@ -341,6 +236,8 @@ func GetCLIConfig(c *cli.Context) (ClientConfig, string, error) {
 	cfg.Node.Address6 = c.String("addressIPV6")
 	cfg.Node.Roaming = c.String("roaming")
 	cfg.Node.DNSOn = c.String("dnson")
+	cfg.DNSConfigurator = c.String("dnsconfigurator")
+	cfg.SecretStore = c.String("secretstore")
 	cfg.Node.IsLocal = c.String("islocal")
 	cfg.Node.IsDualStack = c.String("isdualstack")
 	cfg.Node.PostUp = c.String("postup")
@@ -363,39 +260,107 @@ func GetCLIConfig(c *cli.Context) (ClientConfig, string, error) {
 	return cfg, privateKey, nil
 }
 
+// ReadAccessToken - fills the server and network of a config from a base64 access token
+func ReadAccessToken(cfg *ClientConfig, token string) error {
+	tokenbytes, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		log.Println("error decoding token")
+		return err
+	}
+	var accesstoken models.AccessToken
+	if err := json.Unmarshal(tokenbytes, &accesstoken); err != nil {
+		log.Println("error converting token json to object", tokenbytes)
+		return err
+	}
+
+	if accesstoken.ServerConfig.APIConnString != "" {
+		cfg.Server.APIAddress = accesstoken.ServerConfig.APIConnString
+	} else {
+		cfg.Server.APIAddress = accesstoken.ServerConfig.APIHost
+		if accesstoken.ServerConfig.APIPort != "" {
+			cfg.Server.APIAddress = cfg.Server.APIAddress + ":" + accesstoken.ServerConfig.APIPort
+		}
+	}
+	if accesstoken.ServerConfig.GRPCConnString != "" {
+		cfg.Server.GRPCAddress = accesstoken.ServerConfig.GRPCConnString
+	} else {
+		cfg.Server.GRPCAddress = accesstoken.ServerConfig.GRPCHost
+		if accesstoken.ServerConfig.GRPCPort != "" {
+			cfg.Server.GRPCAddress = cfg.Server.GRPCAddress + ":" + accesstoken.ServerConfig.GRPCPort
+		}
+	}
+
+	cfg.Network = accesstoken.ClientConfig.Network
+	cfg.Node.Network = accesstoken.ClientConfig.Network
+	cfg.Server.AccessKey = accesstoken.ClientConfig.Key
+	cfg.Node.LocalRange = accesstoken.ClientConfig.LocalRange
+	cfg.Server.GRPCSSL = accesstoken.ServerConfig.GRPCSSL
+	cfg.Server.CheckinInterval = accesstoken.ServerConfig.CheckinInterval
+	cfg.Server.GRPCWireGuard = accesstoken.WG.GRPCWireGuard
+	cfg.Server.CoreDNSAddr = accesstoken.ServerConfig.CoreDNSAddr
+	return nil
+}
+
 // ReadConfig - reads a config of a client from disk for specified network
+// a config that is missing, empty or can not be decoded is recovered from the last good backup
 func ReadConfig(network string) (*ClientConfig, error) {
 	if network == "" {
 		err := errors.New("no network provided - exiting")
 		return nil, err
 	}
-	nofile := false
-	home := ncutils.GetNetclientPathSpecific()
-	file := fmt.Sprintf(home + "netconfig-" + network)
-	f, err := os.Open(file)
+	cfg, err := readConfigFile(getConfigPath(network))
+	if err == nil || errors.Is(err, errNewerSchema) {
+		return cfg, err
+	}
+	if errN := ReplaceWithBackup(network); errN != nil {
+		return nil, err
+	}
+	return readConfigFile(getConfigPath(network))
+}
 
+func readConfigFile(path string) (*ClientConfig, error) {
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		if err = ReplaceWithBackup(network); err != nil {
-			nofile = true
-		}
-		f, err = os.Open(file)
-		if err != nil {
-			nofile = true
-		}
+		return nil, err
 	}
-	defer f.Close()
+	return parseConfig(data)
+}
 
+// parseConfig - decodes a config and brings it up to the current schema version
+func parseConfig(data []byte) (*ClientConfig, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errors.New("config is empty")
+	}
 	var cfg ClientConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		fmt.Println("trouble decoding file")
+		return nil, err
+	}
+	if cfg.SchemaVersion > CONFIG_SCHEMA_VERSION {
+		return nil, fmt.Errorf("%w: version %d", errNewerSchema, cfg.SchemaVersion)
+	}
+	if cfg.Network == "" {
+		return nil, errors.New("config has no network")
+	}
+	// configs from before the schema version are version 1, later versions migrate here
+	cfg.SchemaVersion = CONFIG_SCHEMA_VERSION
+	return &cfg, nil
+}
 
-	if !nofile {
-		decoder := yaml.NewDecoder(f)
-		err = decoder.Decode(&cfg)
-		if err != nil {
-			fmt.Println("trouble decoding file")
-			return nil, err
-		}
+func ensureConfigDir() error {
+	_, err := os.Stat(ncutils.GetNetclientPath() + "/config")
+	if os.IsNotExist(err) {
+		return os.MkdirAll(ncutils.GetNetclientPath()+"/config", 0700)
 	}
-	return &cfg, err
+	return err
+}
+
+func getConfigPath(network string) string {
+	return ncutils.GetNetclientPathSpecific() + "netconfig-" + network
+}
+
+func getBackupPath(network string) string {
+	return ncutils.GetNetclientPathSpecific() + "backup.netconfig-" + network
 }
 
 // FileExists - checks if a file exists on disk
@@ -419,3 +384,12 @@ func GetNode(network string) models.Node {
 
 	return node
 }
+
+// GetSecretStore - gets the store holding the password and private key of the node in a network
+func GetSecretStore(network string) (secrets.SecretStore, error) {
+	cfg, err := ReadConfig(network)
+	if err != nil {
+		return nil, err
+	}
+	return secrets.GetSecretStore(cfg.SecretStore)
+}
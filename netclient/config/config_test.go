@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitl/netmaker/netclient/ncutils"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseConfig(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		_, err := parseConfig([]byte("  \n"))
+		assert.EqualError(t, err, "config is empty")
+	})
+	t.Run("Truncated", func(t *testing.T) {
+		_, err := parseConfig([]byte("server:\n  grpcaddress: [broken"))
+		assert.NotNil(t, err)
+	})
+	t.Run("NoNetwork", func(t *testing.T) {
+		_, err := parseConfig([]byte("daemon: \"on\"\n"))
+		assert.EqualError(t, err, "config has no network")
+	})
+	t.Run("Legacy", func(t *testing.T) {
+		cfg, err := parseConfig([]byte("network: skynet\nserver:\n  grpcaddress: a.example.com:50051\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, CONFIG_SCHEMA_VERSION, cfg.SchemaVersion)
+		assert.Equal(t, "a.example.com:50051", cfg.Server.GRPCAddress)
+	})
+	t.Run("Newer", func(t *testing.T) {
+		_, err := parseConfig([]byte("schemaversion: 99\nnetwork: skynet\n"))
+		assert.True(t, errors.Is(err, errNewerSchema))
+	})
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netclient")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "netconfig-skynet")
+
+	cfg := ClientConfig{Network: "skynet"}
+	cfg.Server.GRPCAddress = "a.example.com:50051"
+	data, err := yaml.Marshal(&cfg)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, []byte("stale"), 0644))
+	assert.Nil(t, ncutils.WriteFileAtomic(path, data, CONFIG_FILE_MODE))
+
+	read, err := readConfigFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "a.example.com:50051", read.Server.GRPCAddress)
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(CONFIG_FILE_MODE), info.Mode().Perm())
+	// the temp file is renamed away, only the config is left
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+}
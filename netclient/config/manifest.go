@@ -0,0 +1,173 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/gravitl/netmaker/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest - the networks a netclient should be in, read from a yaml file by netclient apply
+type Manifest struct {
+	Networks []ManifestNetwork `yaml:"networks"`
+}
+
+// ManifestNetwork - a network of a manifest, joined with an access token or with the server given in full,
+// server settings that are set override the ones of the token
+type ManifestNetwork struct {
+	Network         string       `yaml:"network"`
+	Token           string       `yaml:"token"`
+	Server          ServerConfig `yaml:"server"`
+	Node            NodeSettings `yaml:"node"`
+	DNSConfigurator string       `yaml:"dnsconfigurator"`
+	SecretStore     string       `yaml:"secretstore"`
+	Daemon          string       `yaml:"daemon"`
+}
+
+// NodeSettings - settings of the node in a network, named like the flags of netclient join,
+// settings that are not set are left to the server
+type NodeSettings struct {
+	Name         string `yaml:"name"`
+	Interface    string `yaml:"interface"`
+	Endpoint     string `yaml:"endpoint"`
+	Port         int32  `yaml:"port"`
+	KeepAlive    int32  `yaml:"keepalive"`
+	MTU          int32  `yaml:"mtu"`
+	LocalAddress string `yaml:"localaddress"`
+	LocalRange   string `yaml:"localrange"`
+	Address      string `yaml:"address"`
+	Address6     string `yaml:"address6"`
+	Roaming      string `yaml:"roaming"`
+	DNSOn        string `yaml:"dnson"`
+	IsLocal      string `yaml:"islocal"`
+	IsDualStack  string `yaml:"isdualstack"`
+	IPForwarding string `yaml:"ipforwarding"`
+	UDPHolePunch string `yaml:"udpholepunch"`
+	PostUp       string `yaml:"postup"`
+	PostDown     string `yaml:"postdown"`
+}
+
+// ReadManifest - reads a manifest from disk
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(data)
+}
+
+// ParseManifest - decodes a manifest and checks every network can be joined,
+// unknown settings are refused so a typo does not silently leave a setting to the server
+func ParseManifest(data []byte) (*Manifest, error) {
+	var manifest Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, errors.New("could not decode manifest: " + err.Error())
+	}
+	names := make(map[string]bool)
+	for i := range manifest.Networks {
+		network := &manifest.Networks[i]
+		cfg, err := network.ClientConfig()
+		if err != nil {
+			return nil, errors.New("network " + strconv.Itoa(i+1) + " of the manifest: " + err.Error())
+		}
+		network.Network = cfg.Network
+		if names[network.Network] {
+			return nil, errors.New("network " + network.Network + " is in the manifest more than once")
+		}
+		names[network.Network] = true
+	}
+	return &manifest, nil
+}
+
+// ManifestNetwork.ClientConfig - builds the config netclient join would build from the same settings given as flags
+func (network *ManifestNetwork) ClientConfig() (ClientConfig, error) {
+	var cfg ClientConfig
+	if network.Token != "" {
+		if err := ReadAccessToken(&cfg, network.Token); err != nil {
+			return cfg, err
+		}
+	}
+	server := network.Server
+	for _, setting := range []struct {
+		current *string
+		wanted  string
+	}{
+		{&cfg.Server.GRPCAddress, server.GRPCAddress},
+		{&cfg.Server.APIAddress, server.APIAddress},
+		{&cfg.Server.AccessKey, server.AccessKey},
+		{&cfg.Server.GRPCSSL, server.GRPCSSL},
+		{&cfg.Server.GRPCWireGuard, server.GRPCWireGuard},
+		{&cfg.Server.CoreDNSAddr, server.CoreDNSAddr},
+		{&cfg.Server.CheckinInterval, server.CheckinInterval},
+	} {
+		if setting.wanted != "" {
+			*setting.current = setting.wanted
+		}
+	}
+	if network.Network != "" {
+		cfg.Network = network.Network
+	}
+	if cfg.Network == "" {
+		return cfg, errors.New("no network or token provided")
+	}
+	if cfg.Server.GRPCAddress == "" || cfg.Server.AccessKey == "" {
+		return cfg, errors.New("no token, or server grpcaddress and accesskey, provided for " + cfg.Network)
+	}
+	if cfg.Server.CheckinInterval == "" {
+		cfg.Server.CheckinInterval = "15"
+	}
+	cfg.Node.Network = cfg.Network
+	// the defaults of the join flags
+	cfg.Node.Name, _ = os.Hostname()
+	cfg.Node.DNSOn = "yes"
+	network.Node.Apply(&cfg.Node)
+	cfg.DNSConfigurator = network.DNSConfigurator
+	cfg.SecretStore = network.SecretStore
+	cfg.Daemon = network.Daemon
+	if cfg.Daemon == "" {
+		cfg.Daemon = "on"
+	}
+	return cfg, nil
+}
+
+// NodeSettings.Apply - sets the settings on a node and describes each one that changed
+func (settings *NodeSettings) Apply(node *models.Node) []string {
+	var changes []string
+	setString := func(name string, current *string, wanted string) {
+		if wanted != "" && *current != wanted {
+			changes = append(changes, name+": "+*current+" -> "+wanted)
+			*current = wanted
+		}
+	}
+	setInt := func(name string, current *int32, wanted int32) {
+		if wanted != 0 && *current != wanted {
+			changes = append(changes, name+": "+strconv.Itoa(int(*current))+" -> "+strconv.Itoa(int(wanted)))
+			*current = wanted
+		}
+	}
+	setString("name", &node.Name, settings.Name)
+	setString("interface", &node.Interface, settings.Interface)
+	setString("endpoint", &node.Endpoint, settings.Endpoint)
+	setInt("port", &node.ListenPort, settings.Port)
+	setInt("keepalive", &node.PersistentKeepalive, settings.KeepAlive)
+	setInt("mtu", &node.MTU, settings.MTU)
+	setString("localaddress", &node.LocalAddress, settings.LocalAddress)
+	setString("localrange", &node.LocalRange, settings.LocalRange)
+	setString("address", &node.Address, settings.Address)
+	setString("address6", &node.Address6, settings.Address6)
+	setString("roaming", &node.Roaming, settings.Roaming)
+	setString("dnson", &node.DNSOn, settings.DNSOn)
+	setString("islocal", &node.IsLocal, settings.IsLocal)
+	setString("isdualstack", &node.IsDualStack, settings.IsDualStack)
+	setString("ipforwarding", &node.IPForwarding, settings.IPForwarding)
+	setString("udpholepunch", &node.UDPHolePunch, settings.UDPHolePunch)
+	setString("postup", &node.PostUp, settings.PostUp)
+	setString("postdown", &node.PostDown, settings.PostDown)
+	return changes
+}
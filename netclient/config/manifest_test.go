@@ -0,0 +1,73 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseManifest(t *testing.T) {
+	var accesstoken models.AccessToken
+	accesstoken.ClientConfig.Network = "skynet"
+	accesstoken.ClientConfig.Key = "tokenkey"
+	accesstoken.ServerConfig.GRPCConnString = "a.example.com:50051"
+	accesstoken.ServerConfig.APIConnString = "a.example.com:8081"
+	tokenjson, err := json.Marshal(&accesstoken)
+	assert.Nil(t, err)
+	token := base64.StdEncoding.EncodeToString(tokenjson)
+
+	t.Run("Token", func(t *testing.T) {
+		manifest, err := ParseManifest([]byte("networks:\n  - token: " + token + "\n    node:\n      endpoint: 1.2.3.4\n      port: 51830\n      isdualstack: \"yes\"\n"))
+		assert.Nil(t, err)
+		assert.Len(t, manifest.Networks, 1)
+		assert.Equal(t, "skynet", manifest.Networks[0].Network)
+		cfg, err := manifest.Networks[0].ClientConfig()
+		assert.Nil(t, err)
+		assert.Equal(t, "a.example.com:50051", cfg.Server.GRPCAddress)
+		assert.Equal(t, "tokenkey", cfg.Server.AccessKey)
+		assert.Equal(t, "skynet", cfg.Node.Network)
+		assert.Equal(t, "1.2.3.4", cfg.Node.Endpoint)
+		assert.Equal(t, int32(51830), cfg.Node.ListenPort)
+		assert.Equal(t, "yes", cfg.Node.IsDualStack)
+		assert.Equal(t, "yes", cfg.Node.DNSOn)
+		assert.Equal(t, "on", cfg.Daemon)
+		assert.Equal(t, "15", cfg.Server.CheckinInterval)
+	})
+	t.Run("Overrides", func(t *testing.T) {
+		manifest, err := ParseManifest([]byte("networks:\n  - token: " + token + "\n    network: lab\n    server:\n      accesskey: otherkey\n"))
+		assert.Nil(t, err)
+		cfg, err := manifest.Networks[0].ClientConfig()
+		assert.Nil(t, err)
+		assert.Equal(t, "lab", cfg.Network)
+		assert.Equal(t, "otherkey", cfg.Server.AccessKey)
+		assert.Equal(t, "a.example.com:50051", cfg.Server.GRPCAddress)
+	})
+	t.Run("Server", func(t *testing.T) {
+		_, err := ParseManifest([]byte("networks:\n  - network: lab\n    server:\n      grpcaddress: b.example.com:50051\n      accesskey: key\n"))
+		assert.Nil(t, err)
+		_, err = ParseManifest([]byte("networks:\n  - network: lab\n"))
+		assert.EqualError(t, err, "network 1 of the manifest: no token, or server grpcaddress and accesskey, provided for lab")
+	})
+	t.Run("Duplicate", func(t *testing.T) {
+		_, err := ParseManifest([]byte("networks:\n  - token: " + token + "\n  - network: skynet\n    server:\n      grpcaddress: b.example.com:50051\n      accesskey: key\n"))
+		assert.EqualError(t, err, "network skynet is in the manifest more than once")
+	})
+	t.Run("UnknownSetting", func(t *testing.T) {
+		_, err := ParseManifest([]byte("networks:\n  - token: " + token + "\n    node:\n      endpiont: 1.2.3.4\n"))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestNodeSettingsApply(t *testing.T) {
+	node := models.Node{Endpoint: "1.2.3.4", ListenPort: 51821, PostUp: "echo up"}
+	settings := NodeSettings{Endpoint: "5.6.7.8", Port: 51821, MTU: 1380}
+	changes := settings.Apply(&node)
+	assert.Equal(t, []string{"endpoint: 1.2.3.4 -> 5.6.7.8", "mtu: 0 -> 1380"}, changes)
+	assert.Equal(t, "5.6.7.8", node.Endpoint)
+	assert.Equal(t, int32(1380), node.MTU)
+	assert.Equal(t, "echo up", node.PostUp)
+	assert.Empty(t, settings.Apply(&node))
+}
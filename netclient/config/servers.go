@@ -0,0 +1,56 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// ServerNetworks - a server and the configs of the networks joined from it
+type ServerNetworks struct {
+	Server   string
+	Networks []*ClientConfig
+}
+
+// GetServers - reads the configs of the joined networks and groups them by the server they were joined from
+func GetServers() ([]ServerNetworks, error) {
+	networks, err := ncutils.GetSystemNetworks()
+	if err != nil {
+		return nil, err
+	}
+	var configs []*ClientConfig
+	for _, network := range networks {
+		cfg, err := ReadConfig(network)
+		if err != nil {
+			ncutils.PrintLog("error reading config of "+network+": "+err.Error(), 1)
+			continue
+		}
+		configs = append(configs, cfg)
+	}
+	return GroupByServer(configs), nil
+}
+
+// GroupByServer - groups network configs by their server, servers and networks are sorted by name
+func GroupByServer(configs []*ClientConfig) []ServerNetworks {
+	var servers []ServerNetworks
+	index := make(map[string]int)
+	for _, cfg := range configs {
+		i, ok := index[cfg.Server.GRPCAddress]
+		if !ok {
+			i = len(servers)
+			index[cfg.Server.GRPCAddress] = i
+			servers = append(servers, ServerNetworks{Server: cfg.Server.GRPCAddress})
+		}
+		servers[i].Networks = append(servers[i].Networks, cfg)
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].Server < servers[j].Server
+	})
+	for _, server := range servers {
+		networks := server.Networks
+		sort.Slice(networks, func(i, j int) bool {
+			return networks[i].Network < networks[j].Network
+		})
+	}
+	return servers
+}
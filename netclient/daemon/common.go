@@ -22,7 +22,7 @@ func InstallDaemon(cfg config.ClientConfig) error {
 	case "darwin":
 		err = SetupMacDaemon(interval)
 	case "linux":
-		err = SetupSystemDDaemon(interval)
+		err = SetupSystemDDaemon()
 	default:
 		err = errors.New("this os is not yet supported for daemon mode. Run join cmd with flag '--daemon off'")
 	}
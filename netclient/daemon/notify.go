@@ -0,0 +1,21 @@
+package daemon
+
+import (
+	"net"
+	"os"
+)
+
+// SDNotify - sends a state like READY=1 to systemd, does nothing when not run by a Type=notify service
+func SDNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
@@ -12,7 +12,7 @@ import (
 )
 
 // SetupSystemDDaemon - sets system daemon for supported machines
-func SetupSystemDDaemon(interval string) error {
+func SetupSystemDDaemon() error {
 
 	if ncutils.IsWindows() {
 		return nil
@@ -43,57 +43,49 @@ func SetupSystemDDaemon(interval string) error {
 	}
 
 	systemservice := `[Unit]
-Description=Network Check
-Wants=netclient.timer
+Description=Netclient Daemon
+Documentation=https://docs.netmaker.org
+Wants=network-online.target
+After=network-online.target
 
 [Service]
-Type=simple
-ExecStart=/etc/netclient/netclient checkin -n all
+Type=notify
+ExecStart=/etc/netclient/netclient daemon
+ExecReload=/bin/kill -HUP $MAINPID
+Restart=on-failure
+RestartSec=15s
 
 [Install]
 WantedBy=multi-user.target
-`
-
-	systemtimer := `[Unit]
-Description=Calls the Netmaker Mesh Client Service
-Requires=netclient.service
-
-[Timer]
-Unit=netclient.service
-
-`
-	systemtimer = systemtimer + "OnCalendar=*:*:0/" + interval
-
-	systemtimer = systemtimer +
-		`
-
-[Install]
-WantedBy=timers.target
 `
 
 	servicebytes := []byte(systemservice)
-	timerbytes := []byte(systemtimer)
 
-	if !ncutils.FileExists("/etc/systemd/system/netclient.service") {
-		err = ioutil.WriteFile("/etc/systemd/system/netclient.service", servicebytes, 0644)
-		if err != nil {
+	// the timer of older versions re-ran checkins, the service is the daemon now
+	if ncutils.FileExists("/etc/systemd/system/netclient.timer") {
+		_, _ = ncutils.RunCmd("systemctl disable --now netclient.timer", true)
+		if err = os.Remove("/etc/systemd/system/netclient.timer"); err != nil {
+			log.Println(err)
+			return err
+		}
+		if err = os.Remove("/etc/systemd/system/netclient.service"); err != nil && !os.IsNotExist(err) {
 			log.Println(err)
 			return err
 		}
 	}
 
-	if !ncutils.FileExists("/etc/systemd/system/netclient.timer") {
-		err = ioutil.WriteFile("/etc/systemd/system/netclient.timer", timerbytes, 0644)
+	if !ncutils.FileExists("/etc/systemd/system/netclient.service") {
+		err = ioutil.WriteFile("/etc/systemd/system/netclient.service", servicebytes, 0644)
 		if err != nil {
 			log.Println(err)
 			return err
 		}
 	}
 
-	_, _ = ncutils.RunCmd("systemctl enable netclient.service", true)
 	_, _ = ncutils.RunCmd("systemctl daemon-reload", true)
-	_, _ = ncutils.RunCmd("systemctl enable netclient.timer", true)
-	_, _ = ncutils.RunCmd("systemctl start netclient.timer", true)
+	_, _ = ncutils.RunCmd("systemctl enable netclient.service", true)
+	// a running daemon picks up the new network on reload
+	_, _ = ncutils.RunCmd("systemctl reload-or-restart netclient.service", true)
 	return nil
 }
 
@@ -113,6 +105,8 @@ func RemoveSystemDServices() error {
 			log.Println(err)
 		}
 		ncutils.RunCmd("systemctl disable netclient.service", false)
+		// does not wait, the daemon may be the one leaving its last network
+		ncutils.RunCmd("systemctl stop --no-block netclient.service", false)
 		ncutils.RunCmd("systemctl disable netclient.timer", false)
 		if ncutils.FileExists("/etc/systemd/system/netclient.service") {
 			err = os.Remove("/etc/systemd/system/netclient.service")
@@ -129,6 +123,8 @@ func RemoveSystemDServices() error {
 		ncutils.RunCmd("systemctl daemon-reload", false)
 		ncutils.RunCmd("systemctl reset-failed", false)
 		ncutils.Log("removed systemd remnants if any existed")
+	} else {
+		ReloadSystemDDaemon()
 	}
 	return nil
 }
@@ -140,3 +136,10 @@ func isOnlyService() bool {
 	}
 	return len(files) == 0
 }
+
+// ReloadSystemDDaemon - makes a running daemon re-read the networks it checks in to
+func ReloadSystemDDaemon() {
+	if ncutils.IsLinux() && ncutils.FileExists("/etc/systemd/system/netclient.service") {
+		ncutils.RunCmd("systemctl try-reload-or-restart netclient.service", false)
+	}
+}
@@ -0,0 +1,116 @@
+package functions
+
+import (
+	"errors"
+
+	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// actions netclient apply takes on a network
+const (
+	APPLY_JOIN   = "join"
+	APPLY_UPDATE = "update"
+	APPLY_LEAVE  = "leave"
+)
+
+// ApplyAction - a change that brings a joined network in line with a manifest
+type ApplyAction struct {
+	Action  string
+	Network string
+	// Changes - the settings an update changes
+	Changes []string
+	// Config - the config to join with, or the updated config
+	Config config.ClientConfig
+}
+
+// ReadJoinedNetworks - reads the configs of every joined network
+func ReadJoinedNetworks() ([]*config.ClientConfig, error) {
+	networks, err := ncutils.GetSystemNetworks()
+	if err != nil {
+		return nil, err
+	}
+	var joined []*config.ClientConfig
+	for _, network := range networks {
+		cfg, err := config.ReadConfig(network)
+		if err != nil {
+			return nil, errors.New("could not read config of " + network + ": " + err.Error())
+		}
+		joined = append(joined, cfg)
+	}
+	return joined, nil
+}
+
+// PlanApply - compares a manifest with the joined networks, networks that are not in the manifest are left,
+// the leaves come first so the networks joined after can take their interfaces and address ranges
+func PlanApply(manifest *config.Manifest, joined []*config.ClientConfig) ([]ApplyAction, error) {
+	current := make(map[string]*config.ClientConfig)
+	for _, cfg := range joined {
+		current[cfg.Network] = cfg
+	}
+	wanted := make(map[string]bool)
+	var leaves, updates, joins []ApplyAction
+	for i := range manifest.Networks {
+		network := &manifest.Networks[i]
+		cfg, err := network.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		wanted[cfg.Network] = true
+		existing, ok := current[cfg.Network]
+		if !ok {
+			joins = append(joins, ApplyAction{Action: APPLY_JOIN, Network: cfg.Network, Config: cfg})
+			continue
+		}
+		if existing.Server.GRPCAddress != cfg.Server.GRPCAddress {
+			return nil, errors.New("network " + cfg.Network + " is joined from server " + existing.Server.GRPCAddress +
+				" but the manifest has server " + cfg.Server.GRPCAddress + ", leave it before applying")
+		}
+		updated := *existing
+		changes := network.Node.Apply(&updated.Node)
+		if network.DNSConfigurator != "" && updated.DNSConfigurator != network.DNSConfigurator {
+			changes = append(changes, "dnsconfigurator: "+updated.DNSConfigurator+" -> "+network.DNSConfigurator)
+			updated.DNSConfigurator = network.DNSConfigurator
+		}
+		if network.SecretStore != "" && updated.SecretStore != network.SecretStore {
+			changes = append(changes, "secretstore: "+updated.SecretStore+" -> "+network.SecretStore)
+			updated.SecretStore = network.SecretStore
+		}
+		if len(changes) > 0 {
+			updates = append(updates, ApplyAction{Action: APPLY_UPDATE, Network: cfg.Network, Changes: changes, Config: updated})
+		}
+	}
+	for _, cfg := range joined {
+		if !wanted[cfg.Network] {
+			leaves = append(leaves, ApplyAction{Action: APPLY_LEAVE, Network: cfg.Network})
+		}
+	}
+	return append(append(leaves, updates...), joins...), nil
+}
+
+// UpdateNetwork - writes the updated config of a joined network, pushes it to the server and pulls the result
+func UpdateNetwork(cfg config.ClientConfig) error {
+	previous, err := config.ReadConfig(cfg.Network)
+	if err != nil {
+		return err
+	}
+	if cfg.SecretStore != previous.SecretStore {
+		if err = MigrateSecrets(cfg.Network, cfg.SecretStore); err != nil {
+			return err
+		}
+	}
+	// the pull only sees an interface change against the config, which is already changed here
+	if cfg.Node.Interface != previous.Node.Interface {
+		if err = DeleteInterface(previous.Node.Interface, previous.Node.PostDown); err != nil {
+			ncutils.PrintLog("could not delete old interface "+previous.Node.Interface, 1)
+		}
+	}
+	if err = config.Write(&cfg, cfg.Network); err != nil {
+		return err
+	}
+	if err = Push(cfg.Network); err != nil {
+		return err
+	}
+	_, err = Pull(cfg.Network, true)
+	return err
+}
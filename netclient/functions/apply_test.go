@@ -0,0 +1,64 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanApply(t *testing.T) {
+	manifest, err := config.ParseManifest([]byte(`networks:
+  - network: home
+    server:
+      grpcaddress: a.example.com:50051
+      accesskey: key
+    node:
+      endpoint: 5.6.7.8
+    secretstore: encrypted
+  - network: lab
+    server:
+      grpcaddress: a.example.com:50051
+      accesskey: key
+  - network: office
+    server:
+      grpcaddress: b.example.com:50051
+      accesskey: key
+`))
+	assert.Nil(t, err)
+	home := joinedNetwork("home", "a.example.com:50051", "nm-home", "", "")
+	home.Node.Endpoint = "1.2.3.4"
+	office := joinedNetwork("office", "b.example.com:50051", "nm-office", "", "")
+
+	t.Run("Reconcile", func(t *testing.T) {
+		actions, err := PlanApply(manifest, []*config.ClientConfig{
+			home, office, joinedNetwork("old", "b.example.com:50051", "nm-old", "", ""),
+		})
+		assert.Nil(t, err)
+		assert.Len(t, actions, 3)
+		assert.Equal(t, APPLY_LEAVE, actions[0].Action)
+		assert.Equal(t, "old", actions[0].Network)
+		assert.Equal(t, APPLY_UPDATE, actions[1].Action)
+		assert.Equal(t, "home", actions[1].Network)
+		assert.Equal(t, []string{"endpoint: 1.2.3.4 -> 5.6.7.8", "secretstore:  -> encrypted"}, actions[1].Changes)
+		assert.Equal(t, "5.6.7.8", actions[1].Config.Node.Endpoint)
+		assert.Equal(t, "nm-home", actions[1].Config.Node.Interface)
+		assert.Equal(t, "1.2.3.4", home.Node.Endpoint)
+		assert.Equal(t, APPLY_JOIN, actions[2].Action)
+		assert.Equal(t, "lab", actions[2].Network)
+		assert.Equal(t, "a.example.com:50051", actions[2].Config.Server.GRPCAddress)
+	})
+	t.Run("InSync", func(t *testing.T) {
+		synced := *home
+		synced.Node.Endpoint = "5.6.7.8"
+		synced.SecretStore = "encrypted"
+		lab := joinedNetwork("lab", "a.example.com:50051", "nm-lab", "", "")
+		actions, err := PlanApply(manifest, []*config.ClientConfig{&synced, lab, office})
+		assert.Nil(t, err)
+		assert.Empty(t, actions)
+	})
+	t.Run("OtherServer", func(t *testing.T) {
+		_, err := PlanApply(manifest, []*config.ClientConfig{joinedNetwork("lab", "b.example.com:50051", "", "", "")})
+		assert.EqualError(t, err, "network lab is joined from server b.example.com:50051 but the manifest has server a.example.com:50051, leave it before applying")
+	})
+}
@@ -82,12 +82,15 @@ func checkIP(node *models.Node, servercfg config.ServerConfig, cliconf config.Cl
 	return ipchange && err == nil
 }
 
-func setDNS(node *models.Node, servercfg config.ServerConfig, nodecfg *models.Node) {
-	if nodecfg.DNSOn == "yes" {
+func setDNS(node *models.Node, cfg *config.ClientConfig) {
+	if cfg.Node.DNSOn == "yes" {
 		ifacename := node.Interface
-		nameserver := servercfg.CoreDNSAddr
-		network := node.Network
-		local.UpdateDNS(ifacename, network, nameserver)
+		nameserver := cfg.Server.CoreDNSAddr
+		network := node.NetworkSettings
+		if network.NetID == "" {
+			network.NetID = node.Network
+		}
+		local.UpdateDNS(ifacename, cfg.DNSConfigurator, network, nameserver)
 	}
 }
 
@@ -101,7 +104,7 @@ func checkNodeActions(node *models.Node, networkName string, servercfg config.Se
 		}
 	}
 	if node.Action == models.NODE_DELETE || localNode.Action == models.NODE_DELETE {
-		err := RemoveLocalInstance(cfg, networkName)
+		err := leaveDeletedNetwork(cfg, networkName)
 		if err != nil {
 			ncutils.PrintLog("error deleting locally: "+err.Error(), 1)
 		}
@@ -145,6 +148,7 @@ func CheckConfig(cliconf config.ClientConfig) error {
 	}
 	// Check if ip changed and push if so
 	checkIP(newNode, servercfg, cliconf, network)
+	checkMTU(network)
 	return Push(network)
 }
 
@@ -156,7 +160,6 @@ func Pull(network string, manual bool) (*models.Node, error) {
 	}
 
 	node := cfg.Node
-	servercfg := cfg.Server
 
 	if cfg.Node.IPForwarding == "yes" && !ncutils.IsWindows() {
 		if err = local.SetIPForwarding(); err != nil {
@@ -170,13 +173,12 @@ func Pull(network string, manual bool) (*models.Node, error) {
 	var ctx context.Context
 
 	if cfg.Node.IsServer != "yes" {
-		conn, err := grpc.Dial(cfg.Server.GRPCAddress,
-			ncutils.GRPCRequestOpts(cfg.Server.GRPCSSL))
+		conn, err := ncutils.DialGRPC(cfg.Server.GRPCAddress, cfg.Server.GRPCSSL)
 		if err != nil {
 			ncutils.PrintLog("Cant dial GRPC server: "+err.Error(), 1)
 			return nil, err
 		}
-		defer conn.Close()
+		defer ncutils.ReleaseGRPC(conn)
 		wcclient = nodepb.NewNodeServiceClient(conn)
 
 		ctx, err = auth.SetJWT(wcclient, network)
@@ -186,7 +188,7 @@ func Pull(network string, manual bool) (*models.Node, error) {
 		}
 
 		req := &nodepb.Object{
-			Data: node.MacAddress + "###" + node.Network,
+			Data: node.GetNodeID() + "###" + node.Network,
 			Type: nodepb.STRING_TYPE,
 		}
 
@@ -197,6 +199,13 @@ func Pull(network string, manual bool) (*models.Node, error) {
 		if err = json.Unmarshal([]byte(readres.Data), &resNode); err != nil {
 			return nil, err
 		}
+		// nodes joined before node ids learn theirs from the server
+		if cfg.Node.UUID == "" && resNode.UUID != "" {
+			cfg.Node.UUID = resNode.UUID
+			if err = config.ModConfig(&cfg.Node); err != nil {
+				return nil, err
+			}
+		}
 	}
 	// ensure that the OS never changes
 	resNode.OS = runtime.GOOS
@@ -214,6 +223,10 @@ func Pull(network string, manual bool) (*models.Node, error) {
 		if err = wireguard.SetWGConfig(network, false); err != nil {
 			return nil, err
 		}
+		// report the routes that could not be set with the node
+		if updated, err := config.ReadConfig(network); err == nil {
+			resNode.RouteErrors = updated.Node.RouteErrors
+		}
 		nodeData, err := json.Marshal(&resNode)
 		if err != nil {
 			return &resNode, err
@@ -241,8 +254,8 @@ func Pull(network string, manual bool) (*models.Node, error) {
 			}
 		}
 	}
-	if ncutils.IsLinux() {
-		setDNS(&resNode, servercfg, &cfg.Node)
+	if !ncutils.IsWindows() {
+		setDNS(&resNode, cfg)
 	}
 	var bkupErr = config.SaveBackup(network)
 	if bkupErr != nil {
@@ -252,6 +265,41 @@ func Pull(network string, manual bool) (*models.Node, error) {
 	return &resNode, err
 }
 
+// PlanPull - computes what a manual pull would change on the system, without changing the system, the config or the server
+func PlanPull(network string) (*wireguard.Plan, error) {
+	cfg, err := config.ReadConfig(network)
+	if err != nil {
+		return nil, err
+	}
+	resNode := cfg.Node
+	if cfg.Node.IsServer != "yes" {
+		conn, err := ncutils.DialGRPC(cfg.Server.GRPCAddress, cfg.Server.GRPCSSL)
+		if err != nil {
+			return nil, err
+		}
+		defer ncutils.ReleaseGRPC(conn)
+		wcclient := nodepb.NewNodeServiceClient(conn)
+		ctx, err := auth.SetJWT(wcclient, network)
+		if err != nil {
+			return nil, err
+		}
+		var header metadata.MD
+		// a read only read, so the plan does not count as a check in of the node
+		readres, err := wcclient.ReadNode(ctx, &nodepb.Object{
+			Data:     cfg.Node.GetNodeID() + "###" + cfg.Node.Network,
+			Type:     nodepb.STRING_TYPE,
+			Metadata: nodepb.READ_ONLY,
+		}, grpc.Header(&header))
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(readres.Data), &resNode); err != nil {
+			return nil, err
+		}
+	}
+	return wireguard.PlanWGConfig(&resNode, cfg, false)
+}
+
 // Push - pushes current client configuration to server
 func Push(network string) error {
 
@@ -263,16 +311,19 @@ func Push(network string) error {
 	// always set the OS on client
 	postnode.OS = runtime.GOOS
 	postnode.SetLastCheckIn()
+	// an empty list clears the route errors on the server, a missing one keeps them
+	if postnode.RouteErrors == nil {
+		postnode.RouteErrors = []string{}
+	}
 
 	var header metadata.MD
 	var wcclient nodepb.NodeServiceClient
-	conn, err := grpc.Dial(cfg.Server.GRPCAddress,
-		ncutils.GRPCRequestOpts(cfg.Server.GRPCSSL))
+	conn, err := ncutils.DialGRPC(cfg.Server.GRPCAddress, cfg.Server.GRPCSSL)
 	if err != nil {
 		ncutils.PrintLog("Cant dial GRPC server: "+err.Error(), 1)
 		return err
 	}
-	defer conn.Close()
+	defer ncutils.ReleaseGRPC(conn)
 	wcclient = nodepb.NewNodeServiceClient(conn)
 
 	ctx, err := auth.SetJWT(wcclient, network)
@@ -8,7 +8,6 @@ import (
 	"log"
 	"net"
 	"os"
-	"os/exec"
 	"strings"
 
 	nodepb "github.com/gravitl/netmaker/grpc"
@@ -16,7 +15,10 @@ import (
 	"github.com/gravitl/netmaker/netclient/auth"
 	"github.com/gravitl/netmaker/netclient/config"
 	"github.com/gravitl/netmaker/netclient/daemon"
+	"github.com/gravitl/netmaker/netclient/link"
+	"github.com/gravitl/netmaker/netclient/local"
 	"github.com/gravitl/netmaker/netclient/ncutils"
+	"github.com/gravitl/netmaker/netclient/secrets"
 	"github.com/gravitl/netmaker/netclient/wireguard"
 	"golang.zx2c4.com/wireguard/wgctrl"
 	"google.golang.org/grpc"
@@ -108,10 +110,10 @@ func getPrivateAddrBackup() (string, error) {
 	return local, err
 }
 
-func needInterfaceUpdate(ctx context.Context, mac string, network string, iface string) (bool, string, error) {
+func needInterfaceUpdate(ctx context.Context, nodeid string, network string, iface string) (bool, string, error) {
 	var header metadata.MD
 	req := &nodepb.Object{
-		Data: mac + "###" + network,
+		Data: nodeid + "###" + network,
 		Type: nodepb.STRING_TYPE,
 	}
 	readres, err := wcclient.ReadNode(ctx, req, grpc.Header(&header))
@@ -177,12 +179,11 @@ func LeaveNetwork(network string) error {
 
 	if node.IsServer != "yes" {
 		var wcclient nodepb.NodeServiceClient
-		conn, err := grpc.Dial(cfg.Server.GRPCAddress,
-			ncutils.GRPCRequestOpts(cfg.Server.GRPCSSL))
+		conn, err := ncutils.DialGRPC(cfg.Server.GRPCAddress, cfg.Server.GRPCSSL)
 		if err != nil {
 			log.Printf("Unable to establish client connection to "+servercfg.GRPCAddress+": %v", err)
 		}
-		defer conn.Close()
+		defer ncutils.ReleaseGRPC(conn)
 		wcclient = nodepb.NewNodeServiceClient(conn)
 
 		ctx, err := auth.SetJWT(wcclient, network)
@@ -219,6 +220,44 @@ func LeaveNetwork(network string) error {
 	return RemoveLocalInstance(cfg, network)
 }
 
+// leaveDeletedNetwork - leaves a network the server asked the node to leave, then acknowledges it by removing the node
+// from the server, which a forced network delete waits for, the server is only told once the interface is gone
+func leaveDeletedNetwork(cfg *config.ClientConfig, networkName string) error {
+	// the token is taken before the config and secrets it is read with are wiped
+	var ctx context.Context
+	var wcclient nodepb.NodeServiceClient
+	conn, err := ncutils.DialGRPC(cfg.Server.GRPCAddress, cfg.Server.GRPCSSL)
+	if err == nil {
+		defer ncutils.ReleaseGRPC(conn)
+		wcclient = nodepb.NewNodeServiceClient(conn)
+		ctx, err = auth.SetJWT(wcclient, networkName)
+	}
+	if err != nil {
+		ncutils.PrintLog("could not reach the server to acknowledge leaving "+networkName+": "+err.Error(), 1)
+	}
+	removeErr := RemoveLocalInstance(cfg, networkName)
+	if ctx == nil {
+		return removeErr
+	}
+	if ifacename := cfg.Node.Interface; ifacename != "" {
+		if _, err = net.InterfaceByName(ifacename); err == nil {
+			ncutils.PrintLog("interface "+ifacename+" is still up, leaving "+networkName+" is not acknowledged", 1)
+			return removeErr
+		}
+	}
+	node := cfg.Node
+	node.SetID()
+	if _, err = wcclient.DeleteNode(ctx, &nodepb.Object{
+		Data: node.ID,
+		Type: nodepb.STRING_TYPE,
+	}); err != nil {
+		ncutils.PrintLog("could not acknowledge leaving "+networkName+": "+err.Error(), 1)
+	} else {
+		ncutils.PrintLog("acknowledged leaving "+networkName+" to the server", 1)
+	}
+	return removeErr
+}
+
 // RemoveLocalInstance - remove all netclient files locally for a network
 func RemoveLocalInstance(cfg *config.ClientConfig, networkName string) error {
 	err := WipeLocal(networkName)
@@ -245,12 +284,9 @@ func DeleteInterface(ifacename string, postdown string) error {
 	if !ncutils.IsKernel() {
 		err = wireguard.RemoveConf(ifacename, true)
 	} else {
-		ipExec, errN := exec.LookPath("ip")
-		err = errN
-		if err != nil {
+		if err = link.Delete(ifacename); err != nil {
 			ncutils.PrintLog(err.Error(), 1)
 		}
-		_, err = ncutils.RunCmd(ipExec+" link del "+ifacename, false)
 		if postdown != "" {
 			runcmds := strings.Split(postdown, "; ")
 			err = ncutils.RunCmds(runcmds, true)
@@ -267,21 +303,19 @@ func WipeLocal(network string) error {
 	}
 	nodecfg := cfg.Node
 	ifacename := nodecfg.Interface
+	if ifacename != "" && nodecfg.DNSOn == "yes" {
+		if err = local.RevertDNS(ifacename, cfg.DNSConfigurator); err != nil {
+			ncutils.PrintLog("unable to revert dns of "+ifacename+": "+err.Error(), 1)
+		}
+	}
 	if ifacename != "" {
 		if !ncutils.IsKernel() {
 			if err = wireguard.RemoveConf(ifacename, true); err == nil {
 				ncutils.PrintLog("removed WireGuard interface: "+ifacename, 1)
 			}
 		} else {
-			ipExec, err := exec.LookPath("ip")
-			if err != nil {
-				return err
-			}
-			out, err := ncutils.RunCmd(ipExec+" link del "+ifacename, false)
-			dontprint := strings.Contains(out, "does not exist") || strings.Contains(out, "Cannot find device")
-			if err != nil && !dontprint {
-				ncutils.PrintLog("error running command: "+ipExec+" link del "+ifacename, 1)
-				ncutils.PrintLog(out, 1)
+			if err := link.Delete(ifacename); err != nil {
+				ncutils.PrintLog(err.Error(), 1)
 			}
 			if nodecfg.PostDown != "" {
 				runcmds := strings.Split(nodecfg.PostDown, "; ")
@@ -299,11 +333,12 @@ func WipeLocal(network string) error {
 	if ncutils.FileExists(home + "nettoken-" + network) {
 		_ = os.Remove(home + "nettoken-" + network)
 	}
-	if ncutils.FileExists(home + "secret-" + network) {
-		_ = os.Remove(home + "secret-" + network)
-	}
-	if ncutils.FileExists(home + "wgkey-" + network) {
-		_ = os.Remove(home + "wgkey-" + network)
+	if store, errN := secrets.GetSecretStore(cfg.SecretStore); errN == nil {
+		for _, name := range secrets.NetworkSecrets(network) {
+			if errN = store.Delete(name); errN != nil {
+				ncutils.PrintLog("could not delete secret "+name+": "+errN.Error(), 1)
+			}
+		}
 	}
 	if ncutils.FileExists(home + "nm-" + network + ".conf") {
 		_ = os.Remove(home + "nm-" + network + ".conf")
@@ -0,0 +1,62 @@
+package functions
+
+import (
+	"errors"
+	"net"
+
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// CheckConflicts - checks a node against the networks already joined, from this or any other server,
+// two networks can not share a name or an interface, and their address ranges can not overlap
+func CheckConflicts(node *models.Node, server string) error {
+	networks, err := ncutils.GetSystemNetworks()
+	if err != nil {
+		return err
+	}
+	var joined []*config.ClientConfig
+	for _, network := range networks {
+		if cfg, err := config.ReadConfig(network); err == nil {
+			joined = append(joined, cfg)
+		}
+	}
+	return findConflict(node, server, joined)
+}
+
+func findConflict(node *models.Node, server string, joined []*config.ClientConfig) error {
+	for _, cfg := range joined {
+		if cfg.Network == node.Network {
+			if cfg.Server.GRPCAddress != server {
+				// ALREADY_INSTALLED keeps the failed join from removing the joined network
+				return errors.New("ALREADY_INSTALLED. network " + node.Network + " is already joined from server " + cfg.Server.GRPCAddress)
+			}
+			continue
+		}
+		description := "network " + cfg.Network + " of server " + cfg.Server.GRPCAddress
+		if node.Interface != "" && cfg.Node.Interface == node.Interface {
+			return errors.New("interface " + node.Interface + " is already used by " + description)
+		}
+		if rangesOverlap(node.NetworkSettings.AddressRange, cfg.NetworkSettings.AddressRange) {
+			return errors.New("address range " + node.NetworkSettings.AddressRange + " overlaps " + cfg.NetworkSettings.AddressRange + " of " + description)
+		}
+		if rangesOverlap(node.NetworkSettings.AddressRange6, cfg.NetworkSettings.AddressRange6) {
+			return errors.New("address range " + node.NetworkSettings.AddressRange6 + " overlaps " + cfg.NetworkSettings.AddressRange6 + " of " + description)
+		}
+	}
+	return nil
+}
+
+// rangesOverlap - checks if two cidrs share addresses, ranges that do not parse never overlap
+func rangesOverlap(first string, second string) bool {
+	_, firstNet, err := net.ParseCIDR(first)
+	if err != nil {
+		return false
+	}
+	_, secondNet, err := net.ParseCIDR(second)
+	if err != nil {
+		return false
+	}
+	return firstNet.Contains(secondNet.IP) || secondNet.Contains(firstNet.IP)
+}
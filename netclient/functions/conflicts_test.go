@@ -0,0 +1,67 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func joinedNetwork(network string, server string, iface string, addressrange string, addressrange6 string) *config.ClientConfig {
+	cfg := &config.ClientConfig{Network: network}
+	cfg.Server.GRPCAddress = server
+	cfg.Node.Network = network
+	cfg.Node.Interface = iface
+	cfg.NetworkSettings.AddressRange = addressrange
+	cfg.NetworkSettings.AddressRange6 = addressrange6
+	return cfg
+}
+
+func TestFindConflict(t *testing.T) {
+	joined := []*config.ClientConfig{
+		joinedNetwork("home", "a.example.com:50051", "nm-home", "10.10.0.0/16", "fd00:10::/64"),
+		joinedNetwork("office", "b.example.com:50051", "nm-office", "10.20.0.0/24", ""),
+	}
+	node := func(network string, iface string, addressrange string, addressrange6 string) *models.Node {
+		return &models.Node{Network: network, Interface: iface,
+			NetworkSettings: models.Network{AddressRange: addressrange, AddressRange6: addressrange6}}
+	}
+	t.Run("NoConflict", func(t *testing.T) {
+		assert.Nil(t, findConflict(node("lab", "nm-lab", "10.30.0.0/24", "fd00:30::/64"), "b.example.com:50051", joined))
+	})
+	t.Run("SameNetworkSameServer", func(t *testing.T) {
+		assert.Nil(t, findConflict(node("home", "nm-home", "10.10.0.0/16", ""), "a.example.com:50051", joined))
+	})
+	t.Run("NetworkFromOtherServer", func(t *testing.T) {
+		err := findConflict(node("home", "", "", ""), "b.example.com:50051", joined)
+		assert.EqualError(t, err, "ALREADY_INSTALLED. network home is already joined from server a.example.com:50051")
+	})
+	t.Run("Interface", func(t *testing.T) {
+		err := findConflict(node("lab", "nm-office", "10.30.0.0/24", ""), "a.example.com:50051", joined)
+		assert.EqualError(t, err, "interface nm-office is already used by network office of server b.example.com:50051")
+	})
+	t.Run("AddressRange", func(t *testing.T) {
+		err := findConflict(node("lab", "nm-lab", "10.20.0.128/25", ""), "a.example.com:50051", joined)
+		assert.EqualError(t, err, "address range 10.20.0.128/25 overlaps 10.20.0.0/24 of network office of server b.example.com:50051")
+		assert.NotNil(t, findConflict(node("lab", "nm-lab", "10.0.0.0/8", ""), "a.example.com:50051", joined))
+	})
+	t.Run("AddressRange6", func(t *testing.T) {
+		err := findConflict(node("lab", "nm-lab", "10.30.0.0/24", "fd00:10::/48"), "b.example.com:50051", joined)
+		assert.EqualError(t, err, "address range fd00:10::/48 overlaps fd00:10::/64 of network home of server a.example.com:50051")
+	})
+}
+
+func TestGroupByServer(t *testing.T) {
+	servers := config.GroupByServer([]*config.ClientConfig{
+		joinedNetwork("office", "b.example.com:50051", "", "", ""),
+		joinedNetwork("lab", "a.example.com:50051", "", "", ""),
+		joinedNetwork("home", "a.example.com:50051", "", "", ""),
+	})
+	assert.Len(t, servers, 2)
+	assert.Equal(t, "a.example.com:50051", servers[0].Server)
+	assert.Equal(t, "home", servers[0].Networks[0].Network)
+	assert.Equal(t, "lab", servers[0].Networks[1].Network)
+	assert.Equal(t, "b.example.com:50051", servers[1].Server)
+	assert.Len(t, servers[1].Networks, 1)
+}
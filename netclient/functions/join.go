@@ -7,9 +7,9 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
-	"os/exec"
 	"time"
 
+	"github.com/google/uuid"
 	nodepb "github.com/gravitl/netmaker/grpc"
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/netclient/auth"
@@ -17,6 +17,7 @@ import (
 	"github.com/gravitl/netmaker/netclient/daemon"
 	"github.com/gravitl/netmaker/netclient/local"
 	"github.com/gravitl/netmaker/netclient/ncutils"
+	"github.com/gravitl/netmaker/netclient/secrets"
 	"github.com/gravitl/netmaker/netclient/server"
 	"github.com/gravitl/netmaker/netclient/wireguard"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
@@ -31,10 +32,26 @@ func JoinNetwork(cfg config.ClientConfig, privateKey string) error {
 
 	var err error
 	if cfg.Node.IsServer != "yes" {
+		if err = CheckConflicts(&cfg.Node, cfg.Server.GRPCAddress); err != nil {
+			return err
+		}
 		if local.HasNetwork(cfg.Network) {
 			err := errors.New("ALREADY_INSTALLED. Netclient appears to already be installed for " + cfg.Network + ". To re-install, please remove by executing 'sudo netclient leave -n " + cfg.Network + "'. Then re-run the install command.")
 			return err
 		}
+		if cfg.Node.DNSOn != "no" && cfg.DNSConfigurator == "" {
+			if dnsconfigurator := local.DetectDNSConfigurator(); dnsconfigurator != nil {
+				cfg.DNSConfigurator = dnsconfigurator.Name()
+				ncutils.PrintLog("configuring dns with "+cfg.DNSConfigurator, 1)
+			} else {
+				ncutils.PrintLog("no supported dns configuration found", 2)
+				ncutils.PrintLog("unable to configure DNS automatically, disabling automated DNS management", 2)
+				cfg.Node.DNSOn = "no"
+			}
+		}
+		if _, err = secrets.GetNodeSecretStore(cfg.SecretStore); err != nil {
+			return err
+		}
 		if cfg.FWMark == 0 {
 			rand.Seed(time.Now().UnixNano())
 			var min int32 = 1000
@@ -49,7 +66,9 @@ func JoinNetwork(cfg config.ClientConfig, privateKey string) error {
 		if cfg.Node.Password == "" {
 			cfg.Node.Password = ncutils.GenPass()
 		}
-		auth.StoreSecret(cfg.Node.Password, cfg.Node.Network)
+		if err = auth.StoreSecret(cfg.Node.Password, cfg.Node.Network); err != nil {
+			return err
+		}
 	}
 
 	if cfg.Node.LocalRange != "" && cfg.Node.LocalAddress == "" {
@@ -90,15 +109,10 @@ func JoinNetwork(cfg config.ClientConfig, privateKey string) error {
 			cfg.Node.MacAddress = macs[0]
 		}
 	}
-	if ncutils.IsLinux() {
-		_, err := exec.LookPath("resolvectl")
-		if err != nil {
-			ncutils.PrintLog("resolvectl not present", 2)
-			ncutils.PrintLog("unable to configure DNS automatically, disabling automated DNS management", 2)
-			cfg.Node.DNSOn = "no"
-		}
+	// the id stays the same when the mac address changes, the server keys the node by it
+	if cfg.Node.UUID == "" {
+		cfg.Node.UUID = uuid.NewString()
 	}
-
 	if ncutils.IsFreeBSD() {
 		cfg.Node.UDPHolePunch = "no"
 	}
@@ -108,6 +122,7 @@ func JoinNetwork(cfg config.ClientConfig, privateKey string) error {
 	var node models.Node // fill this node with appropriate calls
 	postnode := &models.Node{
 		Password:            cfg.Node.Password,
+		UUID:                cfg.Node.UUID,
 		MacAddress:          cfg.Node.MacAddress,
 		AccessKey:           cfg.Server.AccessKey,
 		IsStatic:            cfg.Node.IsStatic,
@@ -163,6 +178,19 @@ func JoinNetwork(cfg config.ClientConfig, privateKey string) error {
 		if err = json.Unmarshal([]byte(nodeData), &node); err != nil {
 			return err
 		}
+		// the range and interface are only known once the server created the node
+		if conflict := CheckConflicts(&node, cfg.Server.GRPCAddress); conflict != nil {
+			ncutils.PrintLog("removing node from "+cfg.Network+": "+conflict.Error(), 1)
+			// the interface may belong to the other network, leaving must not delete it
+			node.Interface = ""
+			if err = config.ModConfig(&node); err == nil {
+				err = LeaveNetwork(cfg.Network)
+			}
+			if err != nil {
+				ncutils.PrintLog("could not remove node: "+err.Error(), 1)
+			}
+			return conflict
+		}
 	}
 
 	// get free port based on returned default listen port
@@ -212,7 +240,7 @@ func JoinNetwork(cfg config.ClientConfig, privateKey string) error {
 	}
 
 	ncutils.Log("retrieving peers")
-	peers, hasGateway, gateways, err := server.GetPeers(node.MacAddress, cfg.Network, cfg.Server.GRPCAddress, node.IsDualStack == "yes", node.IsIngressGateway == "yes", node.IsServer == "yes")
+	peers, hasGateway, gateways, err := server.GetPeers(node.GetNodeID(), cfg.Network, cfg.Server.GRPCAddress, node.IsDualStack == "yes", node.IsIngressGateway == "yes", node.IsServer == "yes")
 	if err != nil && !ncutils.IsEmptyRecord(err) {
 		ncutils.Log("failed to retrieve peers")
 		return err
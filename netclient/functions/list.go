@@ -27,8 +27,13 @@ type Network struct {
 	Peers       []Peer `json:"peers"`
 }
 
+// Server - a server and the names of the networks joined from it
+type Server struct {
+	Address  string   `json:"server"`
+	Networks []string `json:"networks"`
+}
+
 func List(network string) error {
-	nets := []Network{}
 	var err error
 	var networks []string
 	if network == "all" {
@@ -40,29 +45,45 @@ func List(network string) error {
 		networks = append(networks, network)
 	}
 
+	var configs []*config.ClientConfig
 	for _, network := range networks {
-		net, err := getNetwork(network)
+		cfg, err := config.ReadConfig(network)
 		if err != nil {
 			ncutils.PrintLog(network+": Could not retrieve network configuration.", 1)
-			return err
+			return fmt.Errorf("reading configuration for network %v: %w", network, err)
+		}
+		configs = append(configs, cfg)
+	}
+
+	nets := []Network{}
+	servers := []Server{}
+	for _, server := range config.GroupByServer(configs) {
+		names := []string{}
+		for _, cfg := range server.Networks {
+			net, err := getNetwork(cfg)
+			if err != nil {
+				ncutils.PrintLog(cfg.Network+": Could not retrieve network configuration.", 1)
+				return err
+			}
+			nets = append(nets, net)
+			names = append(names, net.Name)
 		}
-		nets = append(nets, net)
+		servers = append(servers, Server{Address: server.Server, Networks: names})
 	}
 
+	// the networks stay a flat list, the servers group their names
 	jsoncfg, _ := json.Marshal(struct {
 		Networks []Network `json:"networks"`
-	}{nets})
+		Servers  []Server  `json:"servers"`
+	}{nets, servers})
 	fmt.Println(string(jsoncfg))
 
 	return nil
 }
 
-func getNetwork(network string) (Network, error) {
-	cfg, err := config.ReadConfig(network)
-	if err != nil {
-		return Network{}, fmt.Errorf("reading configuration for network %v: %w", network, err)
-	}
-	peers, err := getPeers(network)
+func getNetwork(cfg *config.ClientConfig) (Network, error) {
+	network := cfg.Network
+	peers, err := getPeers(cfg)
 	if err != nil {
 		return Network{}, fmt.Errorf("listing peers for network %v: %w", network, err)
 	}
@@ -79,27 +100,23 @@ func getNetwork(network string) (Network, error) {
 	}, nil
 }
 
-func getPeers(network string) ([]Peer, error) {
-	cfg, err := config.ReadConfig(network)
-	if err != nil {
-		return []Peer{}, err
-	}
+func getPeers(cfg *config.ClientConfig) ([]Peer, error) {
+	network := cfg.Network
 	nodecfg := cfg.Node
 	var nodes []models.Node
 
 	var wcclient nodepb.NodeServiceClient
-	conn, err := grpc.Dial(cfg.Server.GRPCAddress,
-		ncutils.GRPCRequestOpts(cfg.Server.GRPCSSL))
+	conn, err := ncutils.DialGRPC(cfg.Server.GRPCAddress, cfg.Server.GRPCSSL)
 
 	if err != nil {
 		return []Peer{}, fmt.Errorf("connecting to %v: %w", cfg.Server.GRPCAddress, err)
 	}
-	defer conn.Close()
+	defer ncutils.ReleaseGRPC(conn)
 	// Instantiate the BlogServiceClient with our client connection to the server
 	wcclient = nodepb.NewNodeServiceClient(conn)
 
 	req := &nodepb.Object{
-		Data: nodecfg.MacAddress + "###" + nodecfg.Network,
+		Data: nodecfg.GetNodeID() + "###" + nodecfg.Network,
 		Type: nodepb.STRING_TYPE,
 	}
 
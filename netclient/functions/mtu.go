@@ -0,0 +1,153 @@
+package functions
+
+import (
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/gravitl/netmaker/netclient/link"
+	"github.com/gravitl/netmaker/netclient/ncutils"
+	"golang.zx2c4.com/wireguard/wgctrl"
+)
+
+// MTU_PROBE_INTERVAL - seconds between two path mtu probes of a network
+const MTU_PROBE_INTERVAL = 3600
+
+// smallest and largest tunnel mtu that is probed, the largest fits a 1500 byte path with the wireguard headers
+const minProbeMTU = 576
+const maxProbeMTU = 1440
+
+// probeDF - sends a single ping of the given ip packet size with the don't fragment bit set through an interface,
+// true if it is answered
+var probeDF = pingDF
+
+// setProbeMTU - sets the mtu of the interface while it is probed
+var setProbeMTU = link.Up
+
+// checkMTU - probes the mtu of the tunnel to the peers, the server node among them, when the network discovers its mtu
+// the recommended mtu is stored on the node and reported on the next push
+func checkMTU(network string) {
+	cfg, err := config.ReadConfig(network)
+	if err != nil {
+		return
+	}
+	if cfg.Node.NetworkSettings.MTUDiscovery != "yes" && cfg.NetworkSettings.MTUDiscovery != "yes" {
+		return
+	}
+	if time.Now().Unix()-cfg.MTUProbeTime < MTU_PROBE_INTERVAL {
+		return
+	}
+	cfg.MTUProbeTime = time.Now().Unix()
+	mtu := RecommendMTU(cfg.Node.Interface, mtuProbeTargets(cfg.Node.Interface))
+	if mtu == 0 {
+		ncutils.PrintLog("could not probe the tunnel mtu of network "+network, 1)
+	} else {
+		ncutils.PrintLog("recommended mtu of network "+network+" is "+strconv.Itoa(int(mtu)), 1)
+		cfg.Node.RecommendedMTU = mtu
+	}
+	if err = config.Write(cfg, network); err != nil {
+		ncutils.PrintLog("error saving mtu probe: "+err.Error(), 1)
+	}
+}
+
+// RecommendMTU - gets the largest tunnel mtu that reaches every given peer address through an interface,
+// 0 if none could be probed
+func RecommendMTU(ifacename string, peers []net.IP) int32 {
+	if len(peers) == 0 {
+		return 0
+	}
+	// packets larger than the mtu of the interface do not leave it, so it is raised while probing when the os allows it
+	largest := int32(maxProbeMTU)
+	if iface, err := net.InterfaceByName(ifacename); err == nil {
+		if iface.MTU < maxProbeMTU {
+			if err = setProbeMTU(ifacename, maxProbeMTU); err == nil {
+				defer setProbeMTU(ifacename, iface.MTU)
+			} else {
+				largest = int32(iface.MTU)
+			}
+		}
+	}
+	var recommended int32
+	for _, ip := range peers {
+		mtu := ProbePathMTU(ifacename, ip, largest)
+		if mtu == 0 {
+			continue
+		}
+		if recommended == 0 || mtu < recommended {
+			recommended = mtu
+		}
+	}
+	return recommended
+}
+
+// ProbePathMTU - searches the largest packet up to a size that reaches an ip through an interface without fragmentation,
+// 0 if it does not answer at all
+func ProbePathMTU(ifacename string, ip net.IP, largest int32) int32 {
+	low, high := int32(minProbeMTU), largest
+	if !probeDF(ifacename, ip, low) {
+		return 0
+	}
+	for low < high {
+		size := (low + high + 1) / 2
+		if probeDF(ifacename, ip, size) {
+			low = size
+		} else {
+			high = size - 1
+		}
+	}
+	return low
+}
+
+// mtuProbeTargets - gets the tunnel addresses of the wireguard peers of an interface, the host routes among their allowed ips
+func mtuProbeTargets(ifacename string) []net.IP {
+	var targets []net.IP
+	wgclient, err := wgctrl.New()
+	if err != nil {
+		return targets
+	}
+	defer wgclient.Close()
+	device, err := wgclient.Device(ifacename)
+	if err != nil {
+		return targets
+	}
+	for _, peer := range device.Peers {
+		for _, allowed := range peer.AllowedIPs {
+			if ones, bits := allowed.Mask.Size(); ones == bits && bits > 0 {
+				targets = append(targets, allowed.IP)
+				break
+			}
+		}
+	}
+	return targets
+}
+
+func pingDF(ifacename string, ip net.IP, mtu int32) bool {
+	// the payload leaves room for the ip and icmp headers
+	header := int32(28)
+	if ip.To4() == nil {
+		header = 48
+	}
+	size := strconv.Itoa(int(mtu - header))
+	var cmd *exec.Cmd
+	switch {
+	case ncutils.IsWindows():
+		cmd = exec.Command("ping", "-f", "-l", size, "-n", "1", "-w", "1000", ip.String())
+	case ncutils.IsMac() || ncutils.IsFreeBSD():
+		if ip.To4() == nil {
+			// ping6 can not set the don't fragment bit here
+			return false
+		}
+		cmd = exec.Command("ping", "-D", "-s", size, "-c", "1", "-W", "1000", ip.String())
+	default:
+		cmd = exec.Command("ping", "-I", ifacename, "-M", "do", "-s", size, "-c", "1", "-W", "1", ip.String())
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return false
+	}
+	// windows ping succeeds when the packet could not be sent
+	return !ncutils.IsWindows() || strings.Contains(string(out), "TTL=")
+}
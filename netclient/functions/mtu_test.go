@@ -0,0 +1,29 @@
+package functions
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecommendMTU(t *testing.T) {
+	tunnelMTU := map[string]int32{"10.0.0.1": 1420, "10.0.0.2": 1392, "fd00::1": 1440}
+	probeDF = func(ifacename string, ip net.IP, mtu int32) bool {
+		return ifacename == "nm-test" && mtu <= tunnelMTU[ip.String()]
+	}
+	defer func() { probeDF = pingDF }()
+	t.Run("ProbePathMTU", func(t *testing.T) {
+		assert.Equal(t, int32(1392), ProbePathMTU("nm-test", net.ParseIP("10.0.0.2"), maxProbeMTU))
+		assert.Equal(t, int32(1280), ProbePathMTU("nm-test", net.ParseIP("fd00::1"), 1280))
+		assert.Equal(t, int32(0), ProbePathMTU("nm-test", net.ParseIP("10.0.0.3"), maxProbeMTU))
+	})
+	t.Run("Lowest", func(t *testing.T) {
+		assert.Equal(t, int32(1392), RecommendMTU("nm-test", []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}))
+		assert.Equal(t, int32(1440), RecommendMTU("nm-test", []net.IP{net.ParseIP("fd00::1")}))
+	})
+	t.Run("Unreachable", func(t *testing.T) {
+		assert.Equal(t, int32(0), RecommendMTU("nm-test", []net.IP{net.ParseIP("10.0.0.3")}))
+		assert.Equal(t, int32(0), RecommendMTU("nm-test", nil))
+	})
+}
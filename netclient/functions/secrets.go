@@ -0,0 +1,27 @@
+package functions
+
+import (
+	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/gravitl/netmaker/netclient/secrets"
+)
+
+// MigrateSecrets - moves the password and private key of a network to another secret store,
+// the config only points at the new store once it holds every secret
+func MigrateSecrets(network string, to string) error {
+	cfg, err := config.ReadConfig(network)
+	if err != nil {
+		return err
+	}
+	from, err := secrets.GetSecretStore(cfg.SecretStore)
+	if err != nil {
+		return err
+	}
+	target, err := secrets.GetNodeSecretStore(to)
+	if err != nil {
+		return err
+	}
+	return secrets.Migrate(from, target, secrets.NetworkSecrets(network), func() error {
+		cfg.SecretStore = target.Name()
+		return config.Write(cfg, network)
+	})
+}
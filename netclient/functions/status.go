@@ -0,0 +1,202 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sort"
+	"time"
+
+	nodepb "github.com/gravitl/netmaker/grpc"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/netclient/auth"
+	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/gravitl/netmaker/netclient/ncutils"
+	"golang.zx2c4.com/wireguard/wgctrl"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// InterfaceStatus - state of the wireguard interface of a network
+type InterfaceStatus struct {
+	Name       string   `json:"name"`
+	Exists     bool     `json:"exists"`
+	Up         bool     `json:"up"`
+	MTU        int      `json:"mtu,omitempty"`
+	ListenPort int      `json:"listenport,omitempty"`
+	Addresses  []string `json:"addresses,omitempty"`
+}
+
+// PeerStatus - state of a wireguard peer as seen by the interface
+type PeerStatus struct {
+	Name          string    `json:"name,omitempty"`
+	PublicKey     string    `json:"publickey"`
+	Endpoint      string    `json:"endpoint,omitempty"`
+	AllowedIPs    []string  `json:"allowedips"`
+	LastHandshake time.Time `json:"lasthandshake"`
+	ReceiveBytes  int64     `json:"receivebytes"`
+	TransmitBytes int64     `json:"transmitbytes"`
+}
+
+// NetworkStatus - live state of a network on this machine
+type NetworkStatus struct {
+	Network         string          `json:"network"`
+	Server          string          `json:"server"`
+	ServerReachable bool            `json:"serverreachable"`
+	ServerError     string          `json:"servererror,omitempty"`
+	LastCheckin     time.Time       `json:"lastcheckin"`
+	Interface       InterfaceStatus `json:"interface"`
+	Peers           []PeerStatus    `json:"peers"`
+	PendingActions  []string        `json:"pendingactions"`
+}
+
+// GetNetworkStatus - gets the interface, peer and server state of a network
+// the server is only read from, pending actions are not applied
+func GetNetworkStatus(network string) (NetworkStatus, error) {
+	cfg, err := config.ReadConfig(network)
+	if err != nil {
+		return NetworkStatus{}, err
+	}
+	status := NetworkStatus{
+		Network:        network,
+		Server:         cfg.Server.GRPCAddress,
+		Interface:      getInterfaceStatus(cfg.Node.Interface),
+		Peers:          []PeerStatus{},
+		PendingActions: []string{},
+	}
+	// the checkin time is only saved once the server accepted the push
+	if cfg.Node.LastCheckIn > 0 {
+		status.LastCheckin = time.Unix(cfg.Node.LastCheckIn, 0)
+	}
+	peerNames := make(map[string]string)
+	if cfg.Node.IsServer != "yes" {
+		serverNode, serverPeers, err := readServerState(cfg)
+		if err != nil {
+			status.ServerError = err.Error()
+		} else {
+			status.ServerReachable = true
+			status.PendingActions = pendingActions(&serverNode)
+			for _, peer := range serverPeers {
+				peerNames[peer.PublicKey] = peer.Name
+			}
+		}
+	}
+	if status.Interface.Exists {
+		if devicePeers, listenPort, err := getDevicePeers(cfg.Node.Interface); err == nil {
+			status.Interface.ListenPort = listenPort
+			for _, peer := range devicePeers {
+				status.Peers = append(status.Peers, getPeerStatus(peer, peerNames))
+			}
+		}
+	}
+	sort.Slice(status.Peers, func(i, j int) bool {
+		if status.Peers[i].Name != status.Peers[j].Name {
+			return status.Peers[i].Name < status.Peers[j].Name
+		}
+		return status.Peers[i].PublicKey < status.Peers[j].PublicKey
+	})
+	return status, nil
+}
+
+func getInterfaceStatus(ifacename string) InterfaceStatus {
+	status := InterfaceStatus{Name: ifacename}
+	iface, err := net.InterfaceByName(ifacename)
+	if err != nil {
+		return status
+	}
+	status.Exists = true
+	status.Up = iface.Flags&net.FlagUp != 0
+	status.MTU = iface.MTU
+	if addrs, err := iface.Addrs(); err == nil {
+		for _, addr := range addrs {
+			status.Addresses = append(status.Addresses, addr.String())
+		}
+	}
+	return status
+}
+
+// getDevicePeers - gets the peers of an interface with their handshakes and transfer counters
+func getDevicePeers(ifacename string) ([]wgtypes.Peer, int, error) {
+	if ncutils.IsFreeBSD() {
+		peers, err := ncutils.GetPeers(ifacename)
+		return peers, 0, err
+	}
+	wgclient, err := wgctrl.New()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer wgclient.Close()
+	device, err := wgclient.Device(ifacename)
+	if err != nil {
+		return nil, 0, err
+	}
+	return device.Peers, device.ListenPort, nil
+}
+
+func getPeerStatus(peer wgtypes.Peer, names map[string]string) PeerStatus {
+	status := PeerStatus{
+		Name:          names[peer.PublicKey.String()],
+		PublicKey:     peer.PublicKey.String(),
+		AllowedIPs:    []string{},
+		LastHandshake: peer.LastHandshakeTime,
+		ReceiveBytes:  peer.ReceiveBytes,
+		TransmitBytes: peer.TransmitBytes,
+	}
+	if peer.Endpoint != nil {
+		status.Endpoint = peer.Endpoint.String()
+	}
+	for _, allowedIP := range peer.AllowedIPs {
+		status.AllowedIPs = append(status.AllowedIPs, allowedIP.String())
+	}
+	return status
+}
+
+// pendingActions - gets what the server wants the node to do on its next checkin
+func pendingActions(node *models.Node) []string {
+	actions := []string{}
+	if node.IsPending == "yes" {
+		actions = append(actions, models.NODE_IS_PENDING)
+	}
+	if node.Action != "" && node.Action != models.NODE_NOOP {
+		actions = append(actions, node.Action)
+	}
+	if node.PullChanges == "yes" {
+		actions = append(actions, "pullchanges")
+	}
+	return actions
+}
+
+// readServerState - reads the node and its peers from the server without changing anything
+func readServerState(cfg *config.ClientConfig) (models.Node, []models.Node, error) {
+	var node models.Node
+	var peers []models.Node
+	conn, err := ncutils.DialGRPC(cfg.Server.GRPCAddress, cfg.Server.GRPCSSL)
+	if err != nil {
+		return node, peers, err
+	}
+	defer ncutils.ReleaseGRPC(conn)
+	wcclient := nodepb.NewNodeServiceClient(conn)
+	ctx, err := auth.SetJWT(wcclient, cfg.Network)
+	if err != nil {
+		return node, peers, err
+	}
+	req := &nodepb.Object{
+		Data:     cfg.Node.GetNodeID() + "###" + cfg.Network,
+		Type:     nodepb.STRING_TYPE,
+		Metadata: nodepb.READ_ONLY,
+	}
+	readctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res, err := wcclient.ReadNode(readctx, req)
+	if err != nil {
+		return node, peers, err
+	}
+	if err = json.Unmarshal([]byte(res.Data), &node); err != nil {
+		return node, peers, err
+	}
+	res, err = wcclient.GetPeers(readctx, req)
+	if err != nil {
+		return node, peers, err
+	}
+	err = json.Unmarshal([]byte(res.Data), &peers)
+	return node, peers, err
+}
@@ -0,0 +1,180 @@
+package link
+
+import (
+	"errors"
+	"net"
+	"strconv"
+)
+
+// ErrNotSupported - links are not managed on this os
+var ErrNotSupported = errors.New("link management is not supported on this os")
+
+// Error - a link operation that failed, with the operation and the link it was done on
+type Error struct {
+	Op   string
+	Link string
+	Err  error
+}
+
+// Error.Error - describes the failed operation
+func (e *Error) Error() string {
+	return e.Op + " " + e.Link + ": " + e.Err.Error()
+}
+
+// Error.Unwrap - the error of the link layer
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Layer - the link layer interfaces are configured through, netlink on linux
+type Layer interface {
+	Exists(name string) (bool, error)
+	AddWireGuard(name string) error
+	Delete(name string) error
+	SetUp(name string) error
+	SetDown(name string) error
+	SetMTU(name string, mtu int) error
+	// Addresses - the addresses of a link with their masks
+	Addresses(name string) ([]string, error)
+	AddAddress(name string, address *net.IPNet) error
+	DeleteAddress(name string, address *net.IPNet) error
+}
+
+// Manager - configures wireguard interfaces through a link layer, every call can be repeated
+type Manager struct {
+	layer Layer
+}
+
+// NewManager - creates a manager on a link layer
+func NewManager(layer Layer) *Manager {
+	return &Manager{layer: layer}
+}
+
+var defaultManager = NewManager(kernelLayer)
+
+// EnsureWireGuard - creates a wireguard link unless it exists
+func EnsureWireGuard(name string) error {
+	return defaultManager.EnsureWireGuard(name)
+}
+
+// SetAddresses - sets the addresses of a link
+func SetAddresses(name string, addresses []string) error {
+	return defaultManager.SetAddresses(name, addresses)
+}
+
+// Up - sets the mtu of a link and brings it up
+func Up(name string, mtu int) error {
+	return defaultManager.Up(name, mtu)
+}
+
+// Down - brings a link down
+func Down(name string) error {
+	return defaultManager.Down(name)
+}
+
+// Delete - deletes a link
+func Delete(name string) error {
+	return defaultManager.Delete(name)
+}
+
+// Manager.EnsureWireGuard - creates a wireguard link unless it exists, the peers and keys of an existing link are kept
+func (manager *Manager) EnsureWireGuard(name string) error {
+	exists, err := manager.layer.Exists(name)
+	if err != nil {
+		return &Error{Op: "find link", Link: name, Err: err}
+	}
+	if exists {
+		return nil
+	}
+	if err = manager.layer.AddWireGuard(name); err != nil {
+		return &Error{Op: "add wireguard link", Link: name, Err: err}
+	}
+	return nil
+}
+
+// Manager.SetAddresses - adds the addresses missing from a link and removes the ones not given,
+// addresses are given with their mask and the link local addresses of the os are kept
+func (manager *Manager) SetAddresses(name string, addresses []string) error {
+	var wanted []*net.IPNet
+	wantedSet := make(map[string]bool)
+	for _, address := range addresses {
+		ip, ipnet, err := net.ParseCIDR(address)
+		if err != nil {
+			return &Error{Op: "parse address", Link: name, Err: err}
+		}
+		ipnet.IP = ip
+		wanted = append(wanted, ipnet)
+		wantedSet[ipnet.String()] = true
+	}
+	current, err := manager.layer.Addresses(name)
+	if err != nil {
+		return &Error{Op: "list addresses of", Link: name, Err: err}
+	}
+	currentSet := make(map[string]bool)
+	for _, address := range current {
+		currentSet[address] = true
+		ip, ipnet, err := net.ParseCIDR(address)
+		if err != nil || wantedSet[address] || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		ipnet.IP = ip
+		if err = manager.layer.DeleteAddress(name, ipnet); err != nil {
+			return &Error{Op: "delete address " + address + " from", Link: name, Err: err}
+		}
+	}
+	for _, address := range wanted {
+		if currentSet[address.String()] {
+			continue
+		}
+		if err = manager.layer.AddAddress(name, address); err != nil {
+			return &Error{Op: "add address " + address.String() + " to", Link: name, Err: err}
+		}
+	}
+	return nil
+}
+
+// Manager.Up - sets the mtu of a link, unless it is 0, and brings it up
+func (manager *Manager) Up(name string, mtu int) error {
+	if mtu > 0 {
+		if err := manager.layer.SetMTU(name, mtu); err != nil {
+			return &Error{Op: "set mtu " + strconv.Itoa(mtu) + " of", Link: name, Err: err}
+		}
+	}
+	if err := manager.layer.SetUp(name); err != nil {
+		return &Error{Op: "bring up", Link: name, Err: err}
+	}
+	return nil
+}
+
+// Manager.Down - brings a link down
+func (manager *Manager) Down(name string) error {
+	if err := manager.layer.SetDown(name); err != nil {
+		return &Error{Op: "bring down", Link: name, Err: err}
+	}
+	return nil
+}
+
+// Manager.Delete - deletes a link, a link that does not exist is not an error
+func (manager *Manager) Delete(name string) error {
+	exists, err := manager.layer.Exists(name)
+	if err != nil {
+		return &Error{Op: "find link", Link: name, Err: err}
+	}
+	if !exists {
+		return nil
+	}
+	if err = manager.layer.Delete(name); err != nil {
+		return &Error{Op: "delete link", Link: name, Err: err}
+	}
+	return nil
+}
+
+// AddressWithMask - gives an address the mask of the address range of its network,
+// or the default mask when the range can not be parsed
+func AddressWithMask(address string, addressRange string, defaultBits int) string {
+	if _, ipnet, err := net.ParseCIDR(addressRange); err == nil {
+		bits, _ := ipnet.Mask.Size()
+		return address + "/" + strconv.Itoa(bits)
+	}
+	return address + "/" + strconv.Itoa(defaultBits)
+}
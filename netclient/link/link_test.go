@@ -0,0 +1,267 @@
+package link
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// fakeLink - a link of the fake layer
+type fakeLink struct {
+	up        bool
+	mtu       int
+	addresses []string
+}
+
+// fakeLayer - a link layer in memory that records the calls made to it
+type fakeLayer struct {
+	links map[string]*fakeLink
+	calls []string
+}
+
+func newFakeLayer() *fakeLayer {
+	return &fakeLayer{links: make(map[string]*fakeLink)}
+}
+
+func (layer *fakeLayer) get(name string) (*fakeLink, error) {
+	link, ok := layer.links[name]
+	if !ok {
+		return nil, errors.New("link not found")
+	}
+	return link, nil
+}
+
+func (layer *fakeLayer) Exists(name string) (bool, error) {
+	_, ok := layer.links[name]
+	return ok, nil
+}
+
+func (layer *fakeLayer) AddWireGuard(name string) error {
+	layer.calls = append(layer.calls, "add "+name)
+	if _, ok := layer.links[name]; ok {
+		return errors.New("file exists")
+	}
+	layer.links[name] = &fakeLink{}
+	return nil
+}
+
+func (layer *fakeLayer) Delete(name string) error {
+	layer.calls = append(layer.calls, "delete "+name)
+	if _, err := layer.get(name); err != nil {
+		return err
+	}
+	delete(layer.links, name)
+	return nil
+}
+
+func (layer *fakeLayer) SetUp(name string) error {
+	link, err := layer.get(name)
+	if err != nil {
+		return err
+	}
+	link.up = true
+	return nil
+}
+
+func (layer *fakeLayer) SetDown(name string) error {
+	link, err := layer.get(name)
+	if err != nil {
+		return err
+	}
+	link.up = false
+	return nil
+}
+
+func (layer *fakeLayer) SetMTU(name string, mtu int) error {
+	link, err := layer.get(name)
+	if err != nil {
+		return err
+	}
+	link.mtu = mtu
+	return nil
+}
+
+func (layer *fakeLayer) Addresses(name string) ([]string, error) {
+	link, err := layer.get(name)
+	if err != nil {
+		return nil, err
+	}
+	addresses := append([]string{}, link.addresses...)
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+func (layer *fakeLayer) AddAddress(name string, address *net.IPNet) error {
+	link, err := layer.get(name)
+	if err != nil {
+		return err
+	}
+	layer.calls = append(layer.calls, "add address "+address.String())
+	link.addresses = append(link.addresses, address.String())
+	return nil
+}
+
+func (layer *fakeLayer) DeleteAddress(name string, address *net.IPNet) error {
+	link, err := layer.get(name)
+	if err != nil {
+		return err
+	}
+	layer.calls = append(layer.calls, "delete address "+address.String())
+	var kept []string
+	for _, current := range link.addresses {
+		if current != address.String() {
+			kept = append(kept, current)
+		}
+	}
+	link.addresses = kept
+	return nil
+}
+
+func TestEnsureWireGuard(t *testing.T) {
+	layer := newFakeLayer()
+	manager := NewManager(layer)
+	t.Run("Creates", func(t *testing.T) {
+		err := manager.EnsureWireGuard("nm-skynet")
+		assert.Nil(t, err)
+		assert.Contains(t, layer.links, "nm-skynet")
+	})
+	t.Run("Repeated", func(t *testing.T) {
+		err := manager.EnsureWireGuard("nm-skynet")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"add nm-skynet"}, layer.calls)
+	})
+}
+
+func TestSetAddresses(t *testing.T) {
+	layer := newFakeLayer()
+	layer.links["nm-skynet"] = &fakeLink{addresses: []string{"10.10.10.1/24", "10.10.10.9/24", "fe80::1/64"}}
+	manager := NewManager(layer)
+	t.Run("AddsAndRemoves", func(t *testing.T) {
+		err := manager.SetAddresses("nm-skynet", []string{"10.10.10.1/24", "fd00::1/64"})
+		assert.Nil(t, err)
+		addresses, _ := layer.Addresses("nm-skynet")
+		assert.Equal(t, []string{"10.10.10.1/24", "fd00::1/64", "fe80::1/64"}, addresses)
+	})
+	t.Run("Repeated", func(t *testing.T) {
+		layer.calls = nil
+		err := manager.SetAddresses("nm-skynet", []string{"10.10.10.1/24", "fd00::1/64"})
+		assert.Nil(t, err)
+		assert.Empty(t, layer.calls)
+	})
+	t.Run("InvalidAddress", func(t *testing.T) {
+		err := manager.SetAddresses("nm-skynet", []string{"10.10.10.1"})
+		var linkErr *Error
+		assert.True(t, errors.As(err, &linkErr))
+		assert.Equal(t, "parse address", linkErr.Op)
+	})
+}
+
+func TestUp(t *testing.T) {
+	layer := newFakeLayer()
+	layer.links["nm-skynet"] = &fakeLink{mtu: 1420}
+	manager := NewManager(layer)
+	t.Run("SetsMTU", func(t *testing.T) {
+		err := manager.Up("nm-skynet", 1280)
+		assert.Nil(t, err)
+		assert.True(t, layer.links["nm-skynet"].up)
+		assert.Equal(t, 1280, layer.links["nm-skynet"].mtu)
+	})
+	t.Run("KeepsMTU", func(t *testing.T) {
+		err := manager.Down("nm-skynet")
+		assert.Nil(t, err)
+		assert.False(t, layer.links["nm-skynet"].up)
+		err = manager.Up("nm-skynet", 0)
+		assert.Nil(t, err)
+		assert.Equal(t, 1280, layer.links["nm-skynet"].mtu)
+	})
+	t.Run("MissingLink", func(t *testing.T) {
+		err := manager.Up("nm-other", 1280)
+		var linkErr *Error
+		assert.True(t, errors.As(err, &linkErr))
+		assert.Equal(t, "nm-other", linkErr.Link)
+		assert.EqualError(t, err, "set mtu 1280 of nm-other: link not found")
+	})
+}
+
+func TestDelete(t *testing.T) {
+	layer := newFakeLayer()
+	layer.links["nm-skynet"] = &fakeLink{}
+	manager := NewManager(layer)
+	t.Run("Existing", func(t *testing.T) {
+		err := manager.Delete("nm-skynet")
+		assert.Nil(t, err)
+		assert.NotContains(t, layer.links, "nm-skynet")
+	})
+	t.Run("Missing", func(t *testing.T) {
+		layer.calls = nil
+		err := manager.Delete("nm-skynet")
+		assert.Nil(t, err)
+		assert.Empty(t, layer.calls)
+	})
+}
+
+func TestErrorUnwrap(t *testing.T) {
+	manager := NewManager(unavailableLayer{})
+	err := manager.EnsureWireGuard("nm-skynet")
+	assert.True(t, errors.Is(err, ErrNotSupported))
+	assert.EqualError(t, err, "find link nm-skynet: "+ErrNotSupported.Error())
+}
+
+func TestAddressWithMask(t *testing.T) {
+	assert.Equal(t, "10.10.10.1/16", AddressWithMask("10.10.10.1", "10.10.0.0/16", 24))
+	assert.Equal(t, "10.10.10.1/24", AddressWithMask("10.10.10.1", "", 24))
+	assert.Equal(t, "fd00::1/64", AddressWithMask("fd00::1", "not a range", 64))
+}
+
+func TestPeerUpdates(t *testing.T) {
+	oldKey, _ := wgtypes.GeneratePrivateKey()
+	newKey, _ := wgtypes.GeneratePrivateKey()
+	goneKey, _ := wgtypes.GeneratePrivateKey()
+	_, first, _ := net.ParseCIDR("10.10.10.2/32")
+	_, second, _ := net.ParseCIDR("10.10.10.3/32")
+	devicePeers := []wgtypes.Peer{
+		{PublicKey: oldKey.PublicKey(), AllowedIPs: []net.IPNet{*first}},
+		{PublicKey: goneKey.PublicKey(), AllowedIPs: []net.IPNet{*second}},
+	}
+	peers := []wgtypes.PeerConfig{
+		{PublicKey: newKey.PublicKey(), AllowedIPs: []net.IPNet{*first}},
+	}
+	updates := PeerUpdates(devicePeers, peers, 5*time.Second)
+	assert.Len(t, updates, 3)
+	removed := make(map[wgtypes.Key]bool)
+	for _, update := range updates {
+		if update.Remove {
+			removed[update.PublicKey] = true
+			continue
+		}
+		assert.Equal(t, newKey.PublicKey(), update.PublicKey)
+		assert.True(t, update.ReplaceAllowedIPs)
+		assert.Equal(t, 5*time.Second, *update.PersistentKeepaliveInterval)
+	}
+	assert.True(t, removed[oldKey.PublicKey()])
+	assert.True(t, removed[goneKey.PublicKey()])
+}
+
+// unavailableLayer - a link layer that fails every call
+type unavailableLayer struct{}
+
+func (unavailableLayer) Exists(name string) (bool, error)  { return false, ErrNotSupported }
+func (unavailableLayer) AddWireGuard(name string) error    { return ErrNotSupported }
+func (unavailableLayer) Delete(name string) error          { return ErrNotSupported }
+func (unavailableLayer) SetUp(name string) error           { return ErrNotSupported }
+func (unavailableLayer) SetDown(name string) error         { return ErrNotSupported }
+func (unavailableLayer) SetMTU(name string, mtu int) error { return ErrNotSupported }
+func (unavailableLayer) Addresses(name string) ([]string, error) {
+	return nil, ErrNotSupported
+}
+func (unavailableLayer) AddAddress(name string, address *net.IPNet) error {
+	return ErrNotSupported
+}
+func (unavailableLayer) DeleteAddress(name string, address *net.IPNet) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,93 @@
+package link
+
+import (
+	"errors"
+	"net"
+
+	"github.com/vishvananda/netlink"
+)
+
+// netlinkLayer - configures links of the kernel over netlink, without the ip and wg binaries
+type netlinkLayer struct{}
+
+var kernelLayer Layer = netlinkLayer{}
+
+func (netlinkLayer) Exists(name string) (bool, error) {
+	_, err := netlink.LinkByName(name)
+	var notFound netlink.LinkNotFoundError
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (netlinkLayer) AddWireGuard(name string) error {
+	return netlink.LinkAdd(&netlink.GenericLink{
+		LinkAttrs: netlink.LinkAttrs{Name: name},
+		LinkType:  "wireguard",
+	})
+}
+
+func (netlinkLayer) Delete(name string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkDel(link)
+}
+
+func (netlinkLayer) SetUp(name string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkSetUp(link)
+}
+
+func (netlinkLayer) SetDown(name string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkSetDown(link)
+}
+
+func (netlinkLayer) SetMTU(name string, mtu int) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkSetMTU(link, mtu)
+}
+
+func (netlinkLayer) Addresses(name string) ([]string, error) {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []string
+	for _, addr := range addrs {
+		addresses = append(addresses, addr.IPNet.String())
+	}
+	return addresses, nil
+}
+
+func (netlinkLayer) AddAddress(name string, address *net.IPNet) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	return netlink.AddrReplace(link, &netlink.Addr{IPNet: address})
+}
+
+func (netlinkLayer) DeleteAddress(name string, address *net.IPNet) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	return netlink.AddrDel(link, &netlink.Addr{IPNet: address})
+}
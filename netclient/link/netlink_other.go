@@ -0,0 +1,47 @@
+//go:build !linux
+// +build !linux
+
+package link
+
+import "net"
+
+// unsupportedLayer - other oses bring their interfaces up with wg-quick or their own tools
+type unsupportedLayer struct{}
+
+var kernelLayer Layer = unsupportedLayer{}
+
+func (unsupportedLayer) Exists(name string) (bool, error) {
+	return false, ErrNotSupported
+}
+
+func (unsupportedLayer) AddWireGuard(name string) error {
+	return ErrNotSupported
+}
+
+func (unsupportedLayer) Delete(name string) error {
+	return ErrNotSupported
+}
+
+func (unsupportedLayer) SetUp(name string) error {
+	return ErrNotSupported
+}
+
+func (unsupportedLayer) SetDown(name string) error {
+	return ErrNotSupported
+}
+
+func (unsupportedLayer) SetMTU(name string, mtu int) error {
+	return ErrNotSupported
+}
+
+func (unsupportedLayer) Addresses(name string) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+func (unsupportedLayer) AddAddress(name string, address *net.IPNet) error {
+	return ErrNotSupported
+}
+
+func (unsupportedLayer) DeleteAddress(name string, address *net.IPNet) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,48 @@
+package link
+
+import (
+	"time"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// PeerUpdates - the peer changes that give a wireguard device the given peers, to configure it with in place of wg set,
+// a peer is removed when another key took its address or when it is no longer given, the others are updated in place
+func PeerUpdates(devicePeers []wgtypes.Peer, peers []wgtypes.PeerConfig, keepalive time.Duration) []wgtypes.PeerConfig {
+	var updates []wgtypes.PeerConfig
+	removed := make(map[wgtypes.Key]bool)
+	remove := func(key wgtypes.Key) {
+		if !removed[key] {
+			removed[key] = true
+			updates = append(updates, wgtypes.PeerConfig{PublicKey: key, Remove: true})
+		}
+	}
+	wantedAddresses := make(map[string]bool)
+	for _, peer := range peers {
+		if len(peer.AllowedIPs) == 0 {
+			continue
+		}
+		address := peer.AllowedIPs[0].String()
+		wantedAddresses[address] = true
+		for _, currentPeer := range devicePeers {
+			if len(currentPeer.AllowedIPs) > 0 && currentPeer.AllowedIPs[0].String() == address &&
+				currentPeer.PublicKey != peer.PublicKey {
+				remove(currentPeer.PublicKey)
+			}
+		}
+		peerKeepalive := keepalive
+		updates = append(updates, wgtypes.PeerConfig{
+			PublicKey:                   peer.PublicKey,
+			Endpoint:                    peer.Endpoint,
+			PersistentKeepaliveInterval: &peerKeepalive,
+			ReplaceAllowedIPs:           true,
+			AllowedIPs:                  peer.AllowedIPs,
+		})
+	}
+	for _, currentPeer := range devicePeers {
+		if len(currentPeer.AllowedIPs) == 0 || !wantedAddresses[currentPeer.AllowedIPs[0].String()] {
+			remove(currentPeer.PublicKey)
+		}
+	}
+	return updates
+}
@@ -7,9 +7,8 @@ import (
 
 	//"github.com/davecgh/go-spew/spew"
 	"log"
-	"os/exec"
 
-	"github.com/gravitl/netmaker/netclient/ncutils"
+	"github.com/gravitl/netmaker/models"
 )
 
 // SetDNS - sets the DNS of a local machine
@@ -34,30 +33,25 @@ func SetDNS(nameserver string) error {
 	return err
 }
 
-// UpdateDNS - updates local DNS of client
-func UpdateDNS(ifacename string, network string, nameserver string) error {
-	if ncutils.IsWindows() {
+// UpdateDNS - updates local DNS of client through the named configurator, detecting one if blank
+func UpdateDNS(ifacename string, configurator string, network models.Network, nameserver string) error {
+	dnsconfigurator := GetDNSConfigurator(configurator)
+	if dnsconfigurator == nil {
+		log.Println("WARNING: no supported dns configuration found. Unable to set dns. Install systemd-resolved, NetworkManager or resolvconf, or set dns manually.")
 		return nil
 	}
-	_, err := exec.LookPath("resolvectl")
+	err := dnsconfigurator.SetDNS(ifacename, NewDNSConfig(network, nameserver))
 	if err != nil {
-		log.Println(err)
-		log.Println("WARNING: resolvectl not present. Unable to set dns. Install resolvectl or run manually.")
-	} else {
-		_, err = ncutils.RunCmd("resolvectl domain "+ifacename+" ~"+network, true)
-		if err != nil {
-			log.Println("WARNING: Error encountered setting domain on dns. Aborted setting dns.")
-		} else {
-			_, err = ncutils.RunCmd("resolvectl default-route "+ifacename+" false", true)
-			if err != nil {
-				log.Println("WARNING: Error encountered setting default-route on dns. Aborted setting dns.")
-			} else {
-				_, err = ncutils.RunCmd("resolvectl dns "+ifacename+" "+nameserver, true)
-				if err != nil {
-					log.Println("WARNING: Error encountered running resolvectl dns " + ifacename + " " + nameserver)
-				}
-			}
-		}
+		log.Println("WARNING: Error encountered setting dns of " + ifacename + " with " + dnsconfigurator.Name() + ": " + err.Error())
 	}
 	return err
 }
+
+// RevertDNS - removes the dns settings of an interface made by UpdateDNS
+func RevertDNS(ifacename string, configurator string) error {
+	dnsconfigurator := GetDNSConfigurator(configurator)
+	if dnsconfigurator == nil {
+		return nil
+	}
+	return dnsconfigurator.RevertDNS(ifacename)
+}
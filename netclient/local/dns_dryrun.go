@@ -0,0 +1,40 @@
+package local
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// DryRunConfigurator - logs and records dns settings instead of applying them, for tests and hosts managed by hand
+type DryRunConfigurator struct {
+	sync.Mutex
+	Interfaces map[string]DNSConfig
+}
+
+func (d *DryRunConfigurator) Name() string {
+	return DNS_CONFIGURATOR_DRYRUN
+}
+
+// DryRunConfigurator.Available - never auto detected, has to be picked by name
+func (d *DryRunConfigurator) Available() bool {
+	return false
+}
+
+func (d *DryRunConfigurator) SetDNS(ifacename string, dnsconfig DNSConfig) error {
+	d.Lock()
+	defer d.Unlock()
+	d.Interfaces[ifacename] = dnsconfig
+	ncutils.PrintLog("dry run, would set dns of "+ifacename+" to "+strings.Join(dnsconfig.Nameservers, " ")+
+		" for "+strings.Join(dnsconfig.RouteDomains, " "), 1)
+	return nil
+}
+
+func (d *DryRunConfigurator) RevertDNS(ifacename string) error {
+	d.Lock()
+	defer d.Unlock()
+	delete(d.Interfaces, ifacename)
+	ncutils.PrintLog("dry run, would revert dns of "+ifacename, 1)
+	return nil
+}
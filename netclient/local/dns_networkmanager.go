@@ -0,0 +1,76 @@
+package local
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// networkManagerConfigurator - configures NetworkManager through nmcli, the interface has to be managed by NetworkManager
+type networkManagerConfigurator struct{}
+
+func (n *networkManagerConfigurator) Name() string {
+	return DNS_CONFIGURATOR_NETWORKMANAGER
+}
+
+// networkManagerConfigurator.Available - NetworkManager has to be running and writing resolv.conf itself
+func (n *networkManagerConfigurator) Available() bool {
+	if _, err := exec.LookPath("nmcli"); err != nil {
+		return false
+	}
+	out, err := exec.Command("nmcli", "-t", "-f", "RUNNING", "general").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "running" {
+		return false
+	}
+	return resolvConfContains("NetworkManager")
+}
+
+func (n *networkManagerConfigurator) SetDNS(ifacename string, dnsconfig DNSConfig) error {
+	var dns4, dns6 []string
+	for _, nameserver := range dnsconfig.Nameservers {
+		ip := nameserverIP(nameserver)
+		if ip == nil {
+			continue
+		}
+		if ip.To4() != nil {
+			dns4 = append(dns4, ip.String())
+		} else {
+			dns6 = append(dns6, ip.String())
+		}
+	}
+	if len(dns4) == 0 && len(dns6) == 0 {
+		return errors.New("no valid nameserver for " + ifacename)
+	}
+	searches := append([]string{}, dnsconfig.SearchDomains...)
+	for _, domain := range dnsconfig.RouteDomains {
+		searches = append(searches, "~"+domain)
+	}
+	// a negative priority makes the interface the only one used for queries it does not route by domain
+	priority := "50"
+	if dnsconfig.IsDefaultRoute() {
+		priority = "-50"
+	}
+	args := []string{"device", "modify", ifacename}
+	if len(dns4) > 0 {
+		args = append(args, "ipv4.dns", strings.Join(dns4, " "), "ipv4.dns-search", strings.Join(searches, ","), "ipv4.dns-priority", priority)
+	}
+	if len(dns6) > 0 {
+		args = append(args, "ipv6.dns", strings.Join(dns6, " "), "ipv6.dns-search", strings.Join(searches, ","), "ipv6.dns-priority", priority)
+	}
+	out, err := exec.Command("nmcli", args...).CombinedOutput()
+	if err != nil {
+		ncutils.PrintLog("error running nmcli: "+strings.TrimSpace(string(out)), 1)
+	}
+	return err
+}
+
+func (n *networkManagerConfigurator) RevertDNS(ifacename string) error {
+	// reapplying the connection drops the runtime changes made by SetDNS
+	out, err := exec.Command("nmcli", "device", "reapply", ifacename).CombinedOutput()
+	if err != nil && strings.Contains(string(out), "not found") {
+		return nil
+	}
+	return err
+}
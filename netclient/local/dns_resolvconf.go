@@ -0,0 +1,113 @@
+package local
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// path of the resolver config of the host
+var resolvConfPath = "/etc/resolv.conf"
+
+// resolvConfConfigurator - configures the resolver through resolvconf, or by editing resolv.conf when it is not installed
+// resolv.conf can not route by domain, so the nameservers are used for every query
+type resolvConfConfigurator struct{}
+
+func (r *resolvConfConfigurator) Name() string {
+	return DNS_CONFIGURATOR_RESOLVCONF
+}
+
+func (r *resolvConfConfigurator) Available() bool {
+	if _, err := exec.LookPath("resolvconf"); err == nil {
+		return true
+	}
+	info, err := os.Lstat(resolvConfPath)
+	return err == nil && info.Mode().IsRegular()
+}
+
+func (r *resolvConfConfigurator) SetDNS(ifacename string, dnsconfig DNSConfig) error {
+	block := resolvConfBlock(dnsconfig)
+	if resolvconf, err := exec.LookPath("resolvconf"); err == nil {
+		cmd := exec.Command(resolvconf, "-a", ifacename+".netmaker")
+		cmd.Stdin = strings.NewReader(block)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			ncutils.PrintLog("error running resolvconf: "+strings.TrimSpace(string(out)), 1)
+		}
+		return err
+	}
+	content, err := ioutil.ReadFile(resolvConfPath)
+	if err != nil {
+		return err
+	}
+	// the block goes first so its nameservers are tried before the ones of the host
+	updated := resolvConfMarker(ifacename, "begin") + block + resolvConfMarker(ifacename, "end") + removeResolvConfBlock(string(content), ifacename)
+	return ioutil.WriteFile(resolvConfPath, []byte(updated), 0644)
+}
+
+func (r *resolvConfConfigurator) RevertDNS(ifacename string) error {
+	if resolvconf, err := exec.LookPath("resolvconf"); err == nil {
+		out, err := exec.Command(resolvconf, "-d", ifacename+".netmaker").CombinedOutput()
+		if err != nil {
+			ncutils.PrintLog("error running resolvconf: "+strings.TrimSpace(string(out)), 1)
+		}
+		return err
+	}
+	content, err := ioutil.ReadFile(resolvConfPath)
+	if err != nil {
+		return err
+	}
+	reverted := removeResolvConfBlock(string(content), ifacename)
+	if reverted == string(content) {
+		return nil
+	}
+	return ioutil.WriteFile(resolvConfPath, []byte(reverted), 0644)
+}
+
+func resolvConfBlock(dnsconfig DNSConfig) string {
+	var block bytes.Buffer
+	for _, nameserver := range dnsconfig.Nameservers {
+		if ip := nameserverIP(nameserver); ip != nil {
+			block.WriteString("nameserver " + ip.String() + "\n")
+		}
+	}
+	var searches []string
+	searches = append(searches, dnsconfig.SearchDomains...)
+	for _, domain := range dnsconfig.RouteDomains {
+		if domain != "." {
+			searches = append(searches, domain)
+		}
+	}
+	if len(searches) > 0 {
+		block.WriteString("search " + strings.Join(searches, " ") + "\n")
+	}
+	return block.String()
+}
+
+func resolvConfMarker(ifacename string, position string) string {
+	return "# netmaker " + ifacename + " " + position + "\n"
+}
+
+// removeResolvConfBlock - removes the lines added for an interface from resolv.conf content
+func removeResolvConfBlock(content string, ifacename string) string {
+	begin := strings.Index(content, resolvConfMarker(ifacename, "begin"))
+	if begin < 0 {
+		return content
+	}
+	endMarker := resolvConfMarker(ifacename, "end")
+	end := strings.Index(content[begin:], endMarker)
+	if end < 0 {
+		return content
+	}
+	return content[:begin] + content[begin+end+len(endMarker):]
+}
+
+// resolvConfContains - checks if the resolv.conf of the host contains text
+func resolvConfContains(text string) bool {
+	content, err := ioutil.ReadFile(resolvConfPath)
+	return err == nil && strings.Contains(string(content), text)
+}
@@ -0,0 +1,117 @@
+package local
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	resolvedBusName   = "org.freedesktop.resolve1"
+	resolvedBusPath   = dbus.ObjectPath("/org/freedesktop/resolve1")
+	resolvedInterface = "org.freedesktop.resolve1.Manager"
+	// address families as resolved expects them, the linux values
+	resolvedAFInet  = 2
+	resolvedAFInet6 = 10
+)
+
+// resolvedNameserver - a(iay) entry of SetLinkDNS
+type resolvedNameserver struct {
+	Family  int32
+	Address []byte
+}
+
+// resolvedDomain - a(sb) entry of SetLinkDomains
+type resolvedDomain struct {
+	Domain      string
+	RoutingOnly bool
+}
+
+// resolvedConfigurator - configures systemd-resolved over D-Bus
+type resolvedConfigurator struct{}
+
+func (r *resolvedConfigurator) Name() string {
+	return DNS_CONFIGURATOR_RESOLVED
+}
+
+// resolvedConfigurator.Available - resolved has to own its bus name and the host has to use its stub resolver
+func (r *resolvedConfigurator) Available() bool {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return false
+	}
+	var hasOwner bool
+	err = conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, resolvedBusName).Store(&hasOwner)
+	if err != nil || !hasOwner {
+		return false
+	}
+	if target, err := filepath.EvalSymlinks(resolvConfPath); err == nil && strings.HasPrefix(target, "/run/systemd/resolve/") {
+		return true
+	}
+	return resolvConfContains("127.0.0.53")
+}
+
+func (r *resolvedConfigurator) SetDNS(ifacename string, dnsconfig DNSConfig) error {
+	iface, err := net.InterfaceByName(ifacename)
+	if err != nil {
+		return err
+	}
+	var nameservers []resolvedNameserver
+	for _, nameserver := range dnsconfig.Nameservers {
+		ip := nameserverIP(nameserver)
+		if ip == nil {
+			continue
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			nameservers = append(nameservers, resolvedNameserver{Family: resolvedAFInet, Address: ip4})
+		} else {
+			nameservers = append(nameservers, resolvedNameserver{Family: resolvedAFInet6, Address: ip.To16()})
+		}
+	}
+	if len(nameservers) == 0 {
+		return errors.New("no valid nameserver for " + ifacename)
+	}
+	var domains []resolvedDomain
+	for _, domain := range dnsconfig.SearchDomains {
+		domains = append(domains, resolvedDomain{Domain: domain})
+	}
+	for _, domain := range dnsconfig.RouteDomains {
+		domains = append(domains, resolvedDomain{Domain: domain, RoutingOnly: true})
+	}
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	resolved := conn.Object(resolvedBusName, resolvedBusPath)
+	ifindex := int32(iface.Index)
+	if err = resolved.Call(resolvedInterface+".SetLinkDNS", 0, ifindex, nameservers).Err; err != nil {
+		return err
+	}
+	if err = resolved.Call(resolvedInterface+".SetLinkDomains", 0, ifindex, domains).Err; err != nil {
+		return err
+	}
+	// default routes per link are only supported since systemd 240, older versions route by domain alone
+	if err = resolved.Call(resolvedInterface+".SetLinkDefaultRoute", 0, ifindex, dnsconfig.IsDefaultRoute()).Err; err != nil {
+		var dbusErr dbus.Error
+		if !errors.As(err, &dbusErr) || dbusErr.Name != "org.freedesktop.DBus.Error.UnknownMethod" {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *resolvedConfigurator) RevertDNS(ifacename string) error {
+	iface, err := net.InterfaceByName(ifacename)
+	if err != nil {
+		// resolved drops the settings of links that are gone
+		return nil
+	}
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	return conn.Object(resolvedBusName, resolvedBusPath).Call(resolvedInterface+".RevertLink", 0, int32(iface.Index)).Err
+}
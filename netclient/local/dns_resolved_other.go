@@ -0,0 +1,25 @@
+//go:build !linux
+// +build !linux
+
+package local
+
+import "errors"
+
+// resolvedConfigurator - systemd-resolved only exists on linux
+type resolvedConfigurator struct{}
+
+func (r *resolvedConfigurator) Name() string {
+	return DNS_CONFIGURATOR_RESOLVED
+}
+
+func (r *resolvedConfigurator) Available() bool {
+	return false
+}
+
+func (r *resolvedConfigurator) SetDNS(ifacename string, dnsconfig DNSConfig) error {
+	return errors.New("systemd-resolved is not supported on this platform")
+}
+
+func (r *resolvedConfigurator) RevertDNS(ifacename string) error {
+	return nil
+}
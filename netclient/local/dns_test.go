@@ -0,0 +1,76 @@
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDNSConfig(t *testing.T) {
+	t.Run("SplitDNS", func(t *testing.T) {
+		dnsconfig := NewDNSConfig(models.Network{NetID: "skynet", DNSUpstreams: []string{"1.1.1.1"}}, "10.0.0.1")
+		assert.Equal(t, []string{"10.0.0.1"}, dnsconfig.Nameservers)
+		assert.Equal(t, []string{"skynet"}, dnsconfig.RouteDomains)
+		assert.False(t, dnsconfig.IsDefaultRoute())
+	})
+	t.Run("Forward", func(t *testing.T) {
+		network := models.Network{NetID: "skynet", DNSZoneSuffix: "corp.internal", DNSSearchDomain: "corp.internal", DNSForward: "yes", DNSUpstreams: []string{"1.1.1.1"}}
+		dnsconfig := NewDNSConfig(network, "10.0.0.1")
+		assert.Equal(t, []string{"10.0.0.1", "1.1.1.1"}, dnsconfig.Nameservers)
+		assert.Equal(t, []string{"corp.internal"}, dnsconfig.SearchDomains)
+		assert.Equal(t, []string{"corp.internal", "."}, dnsconfig.RouteDomains)
+		assert.True(t, dnsconfig.IsDefaultRoute())
+	})
+}
+
+func TestDryRunDNS(t *testing.T) {
+	err := UpdateDNS("nm-skynet", DNS_CONFIGURATOR_DRYRUN, models.Network{NetID: "skynet"}, "10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, DryRun.Interfaces["nm-skynet"].Nameservers)
+	err = RevertDNS("nm-skynet", DNS_CONFIGURATOR_DRYRUN)
+	assert.Nil(t, err)
+	assert.NotContains(t, DryRun.Interfaces, "nm-skynet")
+}
+
+func TestResolvConfFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "netclient-dns")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	original := "nameserver 192.168.1.1\nsearch home\n"
+	resolvConfPath = filepath.Join(dir, "resolv.conf")
+	defer func() { resolvConfPath = "/etc/resolv.conf" }()
+	assert.Nil(t, ioutil.WriteFile(resolvConfPath, []byte(original), 0644))
+
+	dnsconfig := DNSConfig{Nameservers: []string{"10.0.0.1:53"}, RouteDomains: []string{"skynet", "."}}
+	t.Run("Block", func(t *testing.T) {
+		assert.Equal(t, "nameserver 10.0.0.1\nsearch skynet\n", resolvConfBlock(dnsconfig))
+	})
+	t.Run("RemoveBlock", func(t *testing.T) {
+		content := resolvConfMarker("nm-skynet", "begin") + "nameserver 10.0.0.1\n" + resolvConfMarker("nm-skynet", "end") + original
+		assert.Equal(t, original, removeResolvConfBlock(content, "nm-skynet"))
+		assert.Equal(t, content, removeResolvConfBlock(content, "nm-wirecat"))
+	})
+	t.Run("NoMarkers", func(t *testing.T) {
+		assert.Equal(t, original, removeResolvConfBlock(original, "nm-skynet"))
+	})
+	t.Run("SetAndRevert", func(t *testing.T) {
+		if _, err := exec.LookPath("resolvconf"); err == nil {
+			t.Skip("resolvconf is installed, resolv.conf is not edited directly")
+		}
+		configurator := &resolvConfConfigurator{}
+		assert.Nil(t, configurator.SetDNS("nm-skynet", dnsconfig))
+		assert.Nil(t, configurator.SetDNS("nm-skynet", dnsconfig))
+		content, err := ioutil.ReadFile(resolvConfPath)
+		assert.Nil(t, err)
+		assert.Equal(t, resolvConfMarker("nm-skynet", "begin")+resolvConfBlock(dnsconfig)+resolvConfMarker("nm-skynet", "end")+original, string(content))
+		assert.Nil(t, configurator.RevertDNS("nm-skynet"))
+		content, err = ioutil.ReadFile(resolvConfPath)
+		assert.Nil(t, err)
+		assert.Equal(t, original, string(content))
+	})
+}
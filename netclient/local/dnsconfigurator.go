@@ -0,0 +1,106 @@
+package local
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// names of the dns configurators, stored in the client config at join time
+const (
+	DNS_CONFIGURATOR_RESOLVED       = "resolved"
+	DNS_CONFIGURATOR_NETWORKMANAGER = "networkmanager"
+	DNS_CONFIGURATOR_RESOLVCONF     = "resolvconf"
+	DNS_CONFIGURATOR_DRYRUN         = "dryrun"
+)
+
+// DNSConfig - the resolver settings of a network interface
+type DNSConfig struct {
+	Nameservers   []string
+	SearchDomains []string
+	// domains only resolved through the nameservers, "." routes every query to them
+	RouteDomains []string
+}
+
+// DNSConfig.IsDefaultRoute - checks if every query should go to the nameservers of the interface
+func (dnsconfig *DNSConfig) IsDefaultRoute() bool {
+	for _, domain := range dnsconfig.RouteDomains {
+		if domain == "." {
+			return true
+		}
+	}
+	return false
+}
+
+// DNSConfigurator - configures the resolver of the host for a network interface
+type DNSConfigurator interface {
+	Name() string
+	// Available - checks if the configurator manages the resolver of this host
+	Available() bool
+	SetDNS(ifacename string, dnsconfig DNSConfig) error
+	RevertDNS(ifacename string) error
+}
+
+// configurators in order of preference for auto detection
+var dnsConfigurators = []DNSConfigurator{
+	&resolvedConfigurator{},
+	&networkManagerConfigurator{},
+	&resolvConfConfigurator{},
+}
+
+// DryRun - the dry-run configurator, keeps the applied settings in memory instead of touching the host
+var DryRun = &DryRunConfigurator{Interfaces: make(map[string]DNSConfig)}
+
+// DetectDNSConfigurator - finds the configurator managing the resolver of this host, nil if none
+func DetectDNSConfigurator() DNSConfigurator {
+	if ncutils.IsWindows() || ncutils.IsMac() {
+		return nil
+	}
+	for _, configurator := range dnsConfigurators {
+		if configurator.Available() {
+			return configurator
+		}
+	}
+	return nil
+}
+
+// GetDNSConfigurator - gets a configurator by name, detecting one if the name is blank or unknown
+func GetDNSConfigurator(name string) DNSConfigurator {
+	if name == DNS_CONFIGURATOR_DRYRUN {
+		return DryRun
+	}
+	for _, configurator := range dnsConfigurators {
+		if configurator.Name() == name {
+			return configurator
+		}
+	}
+	return DetectDNSConfigurator()
+}
+
+// NewDNSConfig - builds the resolver settings of a network
+// queries for the network zone and search domain go to the nameserver, everything else too if the network forwards
+func NewDNSConfig(network models.Network, nameserver string) DNSConfig {
+	dnsconfig := DNSConfig{
+		Nameservers:  []string{nameserver},
+		RouteDomains: []string{network.GetDNSZone()},
+	}
+	if network.DNSSearchDomain != "" {
+		dnsconfig.SearchDomains = append(dnsconfig.SearchDomains, network.DNSSearchDomain)
+	}
+	if network.DNSForward == "yes" {
+		dnsconfig.RouteDomains = append(dnsconfig.RouteDomains, ".")
+		// the network upstreams are fallbacks for when the nameserver is unreachable
+		dnsconfig.Nameservers = append(dnsconfig.Nameservers, network.DNSUpstreams...)
+	}
+	return dnsconfig
+}
+
+// nameserverIP - strips the port from a nameserver, resolvers only take addresses
+func nameserverIP(nameserver string) net.IP {
+	if host, _, err := net.SplitHostPort(nameserver); err == nil {
+		nameserver = host
+	}
+	return net.ParseIP(strings.Trim(nameserver, "[]"))
+}
@@ -164,6 +164,18 @@ func main() {
 			Value:   "yes",
 			Usage:   "Sets private dns if 'yes'. Ignores if 'no'. Will retrieve from network if unset.",
 		},
+		&cli.StringFlag{
+			Name:    "dnsconfigurator",
+			EnvVars: []string{"NETCLIENT_DNS_CONFIGURATOR"},
+			Value:   "",
+			Usage:   "How private dns is set: 'resolved', 'networkmanager', 'resolvconf' or 'dryrun'. Detected at join if unset.",
+		},
+		&cli.StringFlag{
+			Name:    "secretstore",
+			EnvVars: []string{"NETCLIENT_SECRET_STORE"},
+			Value:   "",
+			Usage:   "Where the password and private key of the node are kept: 'file' or 'encrypted'. 'file' if unset.",
+		},
 		&cli.StringFlag{
 			Name:    "islocal",
 			EnvVars: []string{"NETCLIENT_IS_LOCAL"},
@@ -236,6 +248,27 @@ func main() {
 				return err
 			},
 		},
+		{
+			Name:  "apply",
+			Usage: "Join, update and leave networks to match a declarative yaml file.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "file",
+					Aliases:  []string{"f"},
+					EnvVars:  []string{"NETCLIENT_APPLY_FILE"},
+					Required: true,
+					Usage:    "Yaml file listing the networks this machine should be in.",
+				},
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Print the changes without making them.",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				err := command.Apply(c.String("file"), c.Bool("dry-run"))
+				return err
+			},
+		},
 		{
 			Name:  "leave",
 			Usage: "Leave a Netmaker network.",
@@ -266,6 +299,33 @@ func main() {
 				return err
 			},
 		},
+		{
+			Name:  "daemon",
+			Usage: "Runs the netclient in the foreground, checking in to every joined network on its interval. Reloads on SIGHUP.",
+			Flags: cliFlags,
+			Action: func(c *cli.Context) error {
+				err := command.Daemon()
+				return err
+			},
+		},
+		{
+			Name:  "status",
+			Usage: "Show the live state of the interfaces, peers and servers of the networks.",
+			Flags: append([]cli.Flag{
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "Print the status as json.",
+				},
+			}, cliFlags...),
+			Action: func(c *cli.Context) error {
+				cfg, _, err := config.GetCLIConfig(c)
+				if err != nil {
+					return err
+				}
+				err = command.Status(cfg, c.Bool("json"))
+				return err
+			},
+		},
 		{
 			Name:  "push",
 			Usage: "Push configuration changes to server.",
@@ -284,7 +344,12 @@ func main() {
 		{
 			Name:  "pull",
 			Usage: "Pull latest configuration and peers from server.",
-			Flags: cliFlags,
+			Flags: append([]cli.Flag{
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Print the changes the pull would make to the interface, peers and routes without making them.",
+				},
+			}, cliFlags...),
 			// the action, or code that will be executed when
 			// we execute our `ns` command
 			Action: func(c *cli.Context) error {
@@ -292,7 +357,7 @@ func main() {
 				if err != nil {
 					return err
 				}
-				err = command.Pull(cfg)
+				err = command.Pull(cfg, c.Bool("dry-run"))
 				return err
 			},
 		},
@@ -311,6 +376,31 @@ func main() {
 				return err
 			},
 		},
+		{
+			Name:  "secrets",
+			Usage: "Manage where the credentials of the node are kept.",
+			Subcommands: []*cli.Command{
+				{
+					Name:  "migrate",
+					Usage: "Move the password and private key of the networks to another secret store.",
+					Flags: append([]cli.Flag{
+						&cli.StringFlag{
+							Name:     "to",
+							Required: true,
+							Usage:    "Secret store to move to: 'file' or 'encrypted'.",
+						},
+					}, cliFlags...),
+					Action: func(c *cli.Context) error {
+						cfg, _, err := config.GetCLIConfig(c)
+						if err != nil {
+							return err
+						}
+						err = command.MigrateSecrets(cfg, c.String("to"))
+						return err
+					},
+				},
+			},
+		},
 		{
 			Name:  "uninstall",
 			Usage: "Uninstall the netclient system service.",
@@ -347,7 +437,8 @@ func main() {
 
 		_, err = exec.LookPath("wg")
 		uspace := ncutils.GetWireGuard()
-		if err != nil {
+		// kernel wireguard on linux is configured over netlink and does not need wireguard-tools
+		if err != nil && !ncutils.IsKernel() {
 			if uspace == "wg" {
 				log.Println(err)
 				log.Fatal("WireGuard not installed. Please install WireGuard (wireguard-tools) and try again.")
@@ -0,0 +1,83 @@
+package ncutils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteFileAtomic - writes a file so that it is either fully replaced or left as it was,
+// the data is written to a temp file in the same dir, synced and renamed over the file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	// starts with a dot so it is never mistaken for a config while it is written
+	tmp, err := ioutil.TempFile(dir, "."+name+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir - flushes a rename in a dir to disk, windows can not open dirs for syncing
+func syncDir(dir string) error {
+	if IsWindows() {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// RestrictStateFiles - makes the configs, keys and tokens in the netclient dir readable by root only,
+// files written by older versions were readable by everyone
+func RestrictStateFiles() error {
+	dir := GetNetclientPathSpecific()
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !f.Mode().IsRegular() || f.Mode().Perm()&0077 == 0 || !isStateFile(f.Name()) {
+			continue
+		}
+		if err = os.Chmod(filepath.Join(dir, f.Name()), f.Mode().Perm()&0700); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isStateFile(name string) bool {
+	for _, prefix := range []string{"netconfig-", "backup.netconfig-", "nettoken-", "secret-", "wgkey-"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, ".conf")
+}
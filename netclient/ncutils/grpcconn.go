@@ -0,0 +1,66 @@
+package ncutils
+
+import (
+	"path/filepath"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// grpc connections kept open by the daemon, one per server
+var grpcConns = struct {
+	sync.Mutex
+	keep  bool
+	conns map[string]*grpc.ClientConn
+}{conns: make(map[string]*grpc.ClientConn)}
+
+// KeepGRPCConns - makes DialGRPC reuse one connection per server until CloseGRPCConns is called
+func KeepGRPCConns() {
+	grpcConns.Lock()
+	defer grpcConns.Unlock()
+	grpcConns.keep = true
+}
+
+// DialGRPC - dials a server, or gets the open connection to it when they are kept
+func DialGRPC(address string, isSecure string) (*grpc.ClientConn, error) {
+	grpcConns.Lock()
+	defer grpcConns.Unlock()
+	if !grpcConns.keep {
+		return grpc.Dial(address, GRPCRequestOpts(isSecure))
+	}
+	key := address + "#" + isSecure
+	if conn, ok := grpcConns.conns[key]; ok && conn.GetState() != connectivity.Shutdown {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(address, GRPCRequestOpts(isSecure))
+	if err != nil {
+		return nil, err
+	}
+	grpcConns.conns[key] = conn
+	return conn, nil
+}
+
+// ReleaseGRPC - closes a connection from DialGRPC unless it is kept
+func ReleaseGRPC(conn *grpc.ClientConn) {
+	grpcConns.Lock()
+	defer grpcConns.Unlock()
+	if !grpcConns.keep {
+		conn.Close()
+	}
+}
+
+// CloseGRPCConns - closes the kept connections, they are dialed again when needed
+func CloseGRPCConns() {
+	grpcConns.Lock()
+	defer grpcConns.Unlock()
+	for key, conn := range grpcConns.conns {
+		conn.Close()
+		delete(grpcConns.conns, key)
+	}
+}
+
+// GetDaemonSocketPath - gets the path of the unix socket of the netclient daemon
+func GetDaemonSocketPath() string {
+	return filepath.Join(GetNetclientPath(), "netclient.sock")
+}
@@ -410,7 +410,8 @@ func GetSystemNetworks() ([]string, error) {
 		return networks, err
 	}
 	for _, f := range files {
-		if strings.Contains(f.Name(), "netconfig-") {
+		// backups and configs that are being written are not networks of their own
+		if strings.HasPrefix(f.Name(), "netconfig-") {
 			networkname := stringAfter(f.Name(), "netconfig-")
 			networks = append(networks, networkname)
 		}
@@ -32,6 +32,16 @@ func GetPeers(iface string) ([]wgtypes.Peer, error) {
 		if len(fields) > 7 {
 			pkeepalivestring = fields[7]
 		}
+		// handshake and transfer counters are 0 until the peer has been reached
+		var handshake time.Time
+		var rxbytes, txbytes int64
+		if len(fields) > 6 {
+			if seconds, err := strconv.ParseInt(fields[4], 10, 64); err == nil && seconds > 0 {
+				handshake = time.Unix(seconds, 0)
+			}
+			rxbytes, _ = strconv.ParseInt(fields[5], 10, 64)
+			txbytes, _ = strconv.ParseInt(fields[6], 10, 64)
+		}
 		// AllowedIPs = private IP + defined networks
 
 		pubkey, err := wgtypes.ParseKey(pubkeystring)
@@ -76,7 +86,7 @@ func GetPeers(iface string) ([]wgtypes.Peer, error) {
 			Port: port,
 		}
 		var dur time.Duration
-		if pkeepalivestring != "" {
+		if pkeepalivestring != "" && pkeepalivestring != "off" {
 			if dur, err = time.ParseDuration(pkeepalivestring+"s"); err != nil {
 				Log("error parsing peer "+pubkeystring+", could not parse keepalive: "+err.Error())
 			}
@@ -88,6 +98,9 @@ func GetPeers(iface string) ([]wgtypes.Peer, error) {
 			Endpoint:          &endpoint,
 			AllowedIPs:        allowedIPs,
 			PersistentKeepaliveInterval: dur,
+			LastHandshakeTime: handshake,
+			ReceiveBytes: rxbytes,
+			TransmitBytes: txbytes,
 		})
 	}
 
@@ -0,0 +1,130 @@
+package routes
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"sync"
+)
+
+// ROUTE_PROTOCOL - protocol the routes of the netclient are marked with in the kernel,
+// routes added by hand or by postup commands are never removed
+const ROUTE_PROTOCOL = 110
+
+// ErrNotSupported - routes are not managed on this os
+var ErrNotSupported = errors.New("route management is not supported on this os")
+
+// ErrForeignRoute - a route to the destination exists that was not added by the netclient, it is left alone
+var ErrForeignRoute = errors.New("a route to the destination was added by hand or by another program")
+
+// routeTable - the kernel routes of the netclient, only routes marked with ROUTE_PROTOCOL are listed or deleted
+type routeTable interface {
+	List(iface string) ([]string, error)
+	// Add - adds a route, a route to the destination is only taken over when it is marked with ROUTE_PROTOCOL
+	Add(iface string, destination *net.IPNet) error
+	Delete(iface string, destination *net.IPNet) error
+}
+
+// Result - the routes a reconcile changed and the ones it failed to change
+type Result struct {
+	Added   []string
+	Removed []string
+	Errors  []string
+}
+
+// Manager - keeps the routes wanted on every interface and makes the kernel match them
+type Manager struct {
+	table   routeTable
+	mutex   sync.Mutex
+	desired map[string][]string
+}
+
+var defaultManager = newManager(kernelTable)
+
+func newManager(table routeTable) *Manager {
+	return &Manager{table: table, desired: make(map[string][]string)}
+}
+
+// Sync - sets the routes wanted on an interface and reconciles them with the kernel
+func Sync(iface string, destinations []string) (Result, error) {
+	defaultManager.SetRoutes(iface, destinations)
+	return defaultManager.Reconcile(iface)
+}
+
+// Current - the routes the netclient has on an interface in the kernel
+func Current(iface string) ([]string, error) {
+	return defaultManager.table.List(iface)
+}
+
+// Manager.SetRoutes - sets the routes wanted on an interface, invalid destinations are reported on reconcile
+func (manager *Manager) SetRoutes(iface string, destinations []string) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	manager.desired[iface] = append([]string{}, destinations...)
+}
+
+// Manager.Reconcile - adds the wanted routes missing from the kernel and deletes the routes of the netclient that are no longer wanted,
+// a route that fails does not stop the others
+func (manager *Manager) Reconcile(iface string) (Result, error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	var result Result
+	current, err := manager.table.List(iface)
+	if err != nil {
+		return result, err
+	}
+	var wanted []string
+	for _, destination := range manager.desired[iface] {
+		_, ipnet, err := net.ParseCIDR(destination)
+		if err != nil {
+			result.Errors = append(result.Errors, "invalid route "+destination)
+			continue
+		}
+		wanted = append(wanted, ipnet.String())
+	}
+	add, remove := diffRoutes(current, wanted)
+	for _, destination := range add {
+		_, ipnet, _ := net.ParseCIDR(destination)
+		if err := manager.table.Add(iface, ipnet); err != nil {
+			result.Errors = append(result.Errors, "could not add route "+destination+" to "+iface+": "+err.Error())
+			continue
+		}
+		result.Added = append(result.Added, destination)
+	}
+	for _, destination := range remove {
+		_, ipnet, err := net.ParseCIDR(destination)
+		if err == nil {
+			err = manager.table.Delete(iface, ipnet)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, "could not remove route "+destination+" from "+iface+": "+err.Error())
+			continue
+		}
+		result.Removed = append(result.Removed, destination)
+	}
+	return result, nil
+}
+
+// diffRoutes - the sorted routes to add and to remove to get from the current routes to the wanted ones
+func diffRoutes(current []string, wanted []string) ([]string, []string) {
+	currentSet := make(map[string]bool)
+	for _, route := range current {
+		currentSet[route] = true
+	}
+	wantedSet := make(map[string]bool)
+	var add, remove []string
+	for _, route := range wanted {
+		if !currentSet[route] && !wantedSet[route] {
+			add = append(add, route)
+		}
+		wantedSet[route] = true
+	}
+	for _, route := range current {
+		if !wantedSet[route] {
+			remove = append(remove, route)
+		}
+	}
+	sort.Strings(add)
+	sort.Strings(remove)
+	return add, remove
+}
@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTable - a route table in memory, destinations in failing can not be changed
+type fakeTable struct {
+	routes  map[string][]string
+	failing map[string]bool
+}
+
+func (table *fakeTable) List(iface string) ([]string, error) {
+	if _, ok := table.routes[iface]; !ok {
+		return nil, errors.New("link not found")
+	}
+	routes := append([]string{}, table.routes[iface]...)
+	sort.Strings(routes)
+	return routes, nil
+}
+
+func (table *fakeTable) Add(iface string, destination *net.IPNet) error {
+	if table.failing[destination.String()] {
+		return errors.New("network is unreachable")
+	}
+	for _, route := range table.routes[iface] {
+		if route == destination.String() {
+			return nil
+		}
+	}
+	table.routes[iface] = append(table.routes[iface], destination.String())
+	return nil
+}
+
+func (table *fakeTable) Delete(iface string, destination *net.IPNet) error {
+	if table.failing[destination.String()] {
+		return errors.New("operation not permitted")
+	}
+	var kept []string
+	for _, route := range table.routes[iface] {
+		if route != destination.String() {
+			kept = append(kept, route)
+		}
+	}
+	table.routes[iface] = kept
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	table := &fakeTable{
+		routes:  map[string][]string{"nm-skynet": {"10.20.0.0/16", "10.30.0.0/16"}},
+		failing: map[string]bool{},
+	}
+	manager := newManager(table)
+
+	t.Run("AddAndRemove", func(t *testing.T) {
+		manager.SetRoutes("nm-skynet", []string{"10.20.0.0/16", "10.40.0.1/16", "fd00:40::/64"})
+		result, err := manager.Reconcile("nm-skynet")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"10.40.0.0/16", "fd00:40::/64"}, result.Added)
+		assert.Equal(t, []string{"10.30.0.0/16"}, result.Removed)
+		assert.Empty(t, result.Errors)
+		routes, _ := table.List("nm-skynet")
+		assert.Equal(t, []string{"10.20.0.0/16", "10.40.0.0/16", "fd00:40::/64"}, routes)
+	})
+	t.Run("Idempotent", func(t *testing.T) {
+		result, err := manager.Reconcile("nm-skynet")
+		assert.Nil(t, err)
+		assert.Equal(t, Result{}, result)
+	})
+	t.Run("Failures", func(t *testing.T) {
+		table.failing["10.50.0.0/16"] = true
+		table.failing["10.20.0.0/16"] = true
+		manager.SetRoutes("nm-skynet", []string{"10.40.0.0/16", "fd00:40::/64", "10.50.0.0/16", "not a route"})
+		result, err := manager.Reconcile("nm-skynet")
+		assert.Nil(t, err)
+		assert.Empty(t, result.Added)
+		assert.Empty(t, result.Removed)
+		assert.Equal(t, []string{
+			"invalid route not a route",
+			"could not add route 10.50.0.0/16 to nm-skynet: network is unreachable",
+			"could not remove route 10.20.0.0/16 from nm-skynet: operation not permitted",
+		}, result.Errors)
+	})
+	t.Run("NoRoutes", func(t *testing.T) {
+		table.failing = map[string]bool{}
+		manager.SetRoutes("nm-skynet", nil)
+		result, err := manager.Reconcile("nm-skynet")
+		assert.Nil(t, err)
+		assert.Len(t, result.Removed, 3)
+		routes, _ := table.List("nm-skynet")
+		assert.Empty(t, routes)
+	})
+	t.Run("MissingInterface", func(t *testing.T) {
+		_, err := manager.Reconcile("nm-missing")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"errors"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+// netlinkTable - the main routing table of the kernel, read and changed over netlink
+type netlinkTable struct{}
+
+var kernelTable routeTable = netlinkTable{}
+
+func (netlinkTable) List(iface string) ([]string, error) {
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return nil, err
+	}
+	filter := &netlink.Route{LinkIndex: link.Attrs().Index, Protocol: ROUTE_PROTOCOL}
+	kernelRoutes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, filter, netlink.RT_FILTER_OIF|netlink.RT_FILTER_PROTOCOL)
+	if err != nil {
+		return nil, err
+	}
+	var destinations []string
+	for _, route := range kernelRoutes {
+		if route.Dst != nil {
+			destinations = append(destinations, route.Dst.String())
+		}
+	}
+	return destinations, nil
+}
+
+func (netlinkTable) Add(iface string, destination *net.IPNet) error {
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return err
+	}
+	route := &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Dst:       destination,
+		Scope:     netlink.SCOPE_LINK,
+		Protocol:  ROUTE_PROTOCOL,
+	}
+	err = netlink.RouteAdd(route)
+	if !errors.Is(err, syscall.EEXIST) {
+		return err
+	}
+	// only a route of the netclient, left by an older netclient or a failed run, is taken over
+	family := netlink.FAMILY_V4
+	if destination.IP.To4() == nil {
+		family = netlink.FAMILY_V6
+	}
+	existing, err := netlink.RouteListFiltered(family, &netlink.Route{Dst: destination}, netlink.RT_FILTER_DST)
+	if err != nil {
+		return err
+	}
+	for _, current := range existing {
+		if current.Protocol != ROUTE_PROTOCOL {
+			return ErrForeignRoute
+		}
+	}
+	return netlink.RouteReplace(route)
+}
+
+func (netlinkTable) Delete(iface string, destination *net.IPNet) error {
+	link, err := netlink.LinkByName(iface)
+	if err != nil {
+		return err
+	}
+	return netlink.RouteDel(&netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Dst:       destination,
+		Protocol:  ROUTE_PROTOCOL,
+	})
+}
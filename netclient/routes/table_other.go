@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+package routes
+
+import "net"
+
+// unsupportedTable - other oses keep routing the network range from the interface setup
+type unsupportedTable struct{}
+
+var kernelTable routeTable = unsupportedTable{}
+
+func (unsupportedTable) List(iface string) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+func (unsupportedTable) Add(iface string, destination *net.IPNet) error {
+	return ErrNotSupported
+}
+
+func (unsupportedTable) Delete(iface string, destination *net.IPNet) error {
+	return ErrNotSupported
+}
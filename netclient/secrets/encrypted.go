@@ -0,0 +1,131 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// ENCRYPTED_SECRET_SUFFIX - suffix of the files the encrypted store keeps its secrets in
+const ENCRYPTED_SECRET_SUFFIX = ".enc"
+
+// encryptedStore - keeps every secret in a file encrypted with aes-gcm, the key is kept on the same disk,
+// so this keeps the secrets out of plain sight but does not protect a copy of the disk or a backup
+type encryptedStore struct {
+	dir        string
+	machineKey func() ([]byte, error)
+}
+
+func (store *encryptedStore) Name() string {
+	return SECRET_STORE_ENCRYPTED
+}
+
+func (store *encryptedStore) Available() bool {
+	return true
+}
+
+func (store *encryptedStore) Persistent() bool {
+	return true
+}
+
+func (store *encryptedStore) Store(name string, secret string) error {
+	aead, err := store.cipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	// the name is authenticated, so a secret can not be passed off as another
+	sealed := aead.Seal(nonce, nonce, []byte(secret), []byte(name))
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+	return ncutils.WriteFileAtomic(store.path(name), []byte(encoded), 0600)
+}
+
+func (store *encryptedStore) Retrieve(name string) (string, error) {
+	data, err := ioutil.ReadFile(store.path(name))
+	if os.IsNotExist(err) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return "", err
+	}
+	aead, err := store.cipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted secret " + name + " is too short")
+	}
+	secret, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return "", errors.New("could not decrypt secret " + name + ", it was encrypted on another machine or changed")
+	}
+	return string(secret), nil
+}
+
+func (store *encryptedStore) Delete(name string) error {
+	err := os.Remove(store.path(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (store *encryptedStore) path(name string) string {
+	return filepath.Join(store.dir, name+ENCRYPTED_SECRET_SUFFIX)
+}
+
+func (store *encryptedStore) cipher() (cipher.AEAD, error) {
+	key, err := store.machineKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// getMachineKey - derives the key of the encrypted store from a random secret generated on first use, mixed with the
+// machine id where the os has one, both are files on this machine so whoever copies the disk can derive the key too
+func getMachineKey() ([]byte, error) {
+	keyPath := filepath.Join(ncutils.GetNetclientPath(), "machine.key")
+	secret, err := ioutil.ReadFile(keyPath)
+	if os.IsNotExist(err) {
+		secret = make([]byte, 32)
+		if _, err = io.ReadFull(rand.Reader, secret); err != nil {
+			return nil, err
+		}
+		err = ncutils.WriteFileAtomic(keyPath, secret, 0600)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) != 32 {
+		return nil, errors.New("machine key " + keyPath + " is corrupted")
+	}
+	hash := sha256.New()
+	hash.Write([]byte("netclient secrets"))
+	hash.Write(secret)
+	if machineID, err := ioutil.ReadFile("/etc/machine-id"); err == nil {
+		hash.Write([]byte(strings.TrimSpace(string(machineID))))
+	}
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,46 @@
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// fileStore - keeps every secret in a plain file only root can read, the layout the netclient always used
+type fileStore struct {
+	dir string
+}
+
+func (store *fileStore) Name() string {
+	return SECRET_STORE_FILE
+}
+
+func (store *fileStore) Available() bool {
+	return true
+}
+
+func (store *fileStore) Persistent() bool {
+	return true
+}
+
+func (store *fileStore) Store(name string, secret string) error {
+	return ncutils.WriteFileAtomic(filepath.Join(store.dir, name), []byte(secret), 0600)
+}
+
+func (store *fileStore) Retrieve(name string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(store.dir, name))
+	if os.IsNotExist(err) {
+		return "", ErrNotFound
+	}
+	return string(data), err
+}
+
+func (store *fileStore) Delete(name string) error {
+	err := os.Remove(filepath.Join(store.dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// keyringStore - keeps every secret as a user key in the persistent kernel keyring of root,
+// the kernel drops the keyring on reboot and when it is not used for a few days, so the secrets do not outlive it
+// and it is refused for the password and private key of a node
+type keyringStore struct{}
+
+func (store *keyringStore) Name() string {
+	return SECRET_STORE_KEYRING
+}
+
+func (store *keyringStore) Available() bool {
+	_, err := store.keyring()
+	return err == nil
+}
+
+func (store *keyringStore) Persistent() bool {
+	return false
+}
+
+func (store *keyringStore) Store(name string, secret string) error {
+	ringid, err := store.keyring()
+	if err != nil {
+		return err
+	}
+	// adding a key with the same description replaces its payload
+	_, err = unix.AddKey("user", keyDescription(name), []byte(secret), ringid)
+	return err
+}
+
+func (store *keyringStore) Retrieve(name string) (string, error) {
+	id, _, err := store.find(name)
+	if err != nil {
+		return "", err
+	}
+	length, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, nil, 0)
+	if err != nil {
+		return "", err
+	}
+	buffer := make([]byte, length)
+	length, err = unix.KeyctlBuffer(unix.KEYCTL_READ, id, buffer, 0)
+	if err != nil {
+		return "", err
+	}
+	if length > len(buffer) {
+		return "", errors.New("secret " + name + " changed while it was read")
+	}
+	return string(buffer[:length]), nil
+}
+
+func (store *keyringStore) Delete(name string) error {
+	id, ringid, err := store.find(name)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = unix.KeyctlInt(unix.KEYCTL_UNLINK, id, ringid, 0, 0)
+	return err
+}
+
+// keyringStore.find - finds the key of a secret and the keyring it is in
+func (store *keyringStore) find(name string) (int, int, error) {
+	ringid, err := store.keyring()
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err := unix.KeyctlSearch(ringid, "user", keyDescription(name), 0)
+	if errors.Is(err, unix.ENOKEY) {
+		return 0, ringid, ErrNotFound
+	}
+	return id, ringid, err
+}
+
+// keyringStore.keyring - gets the persistent keyring of the user, or the user keyring on kernels without persistent keyrings
+func (store *keyringStore) keyring() (int, error) {
+	ringid, err := unix.KeyctlInt(unix.KEYCTL_GET_PERSISTENT, -1, unix.KEY_SPEC_PROCESS_KEYRING, 0, 0)
+	if err == nil {
+		return ringid, nil
+	}
+	return unix.KeyctlGetKeyringID(unix.KEY_SPEC_USER_KEYRING, true)
+}
+
+func keyDescription(name string) string {
+	return "netclient:" + name
+}
@@ -0,0 +1,33 @@
+//go:build !linux
+// +build !linux
+
+package secrets
+
+import "errors"
+
+// keyringStore - the kernel keyring only exists on linux
+type keyringStore struct{}
+
+func (store *keyringStore) Name() string {
+	return SECRET_STORE_KEYRING
+}
+
+func (store *keyringStore) Available() bool {
+	return false
+}
+
+func (store *keyringStore) Persistent() bool {
+	return false
+}
+
+func (store *keyringStore) Store(name string, secret string) error {
+	return errors.New("the kernel keyring is only available on linux")
+}
+
+func (store *keyringStore) Retrieve(name string) (string, error) {
+	return "", errors.New("the kernel keyring is only available on linux")
+}
+
+func (store *keyringStore) Delete(name string) error {
+	return errors.New("the kernel keyring is only available on linux")
+}
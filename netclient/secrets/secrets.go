@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"errors"
+
+	"github.com/gravitl/netmaker/netclient/ncutils"
+)
+
+// names of the secret stores, stored in the client config at join time
+const (
+	SECRET_STORE_FILE      = "file"
+	SECRET_STORE_ENCRYPTED = "encrypted"
+	SECRET_STORE_KEYRING   = "keyring"
+)
+
+// ErrNotFound - the store has no secret by that name
+var ErrNotFound = errors.New("secret not found")
+
+// SecretStore - keeps the credentials of the node, like its password and wireguard private key
+type SecretStore interface {
+	Name() string
+	// Available - checks if the store can be used on this host
+	Available() bool
+	// Persistent - checks if the secrets outlive a reboot of the host
+	Persistent() bool
+	Store(name string, secret string) error
+	// Retrieve - gets a secret, ErrNotFound if the store does not have it
+	Retrieve(name string) (string, error)
+	// Delete - removes a secret, removing a missing secret is not an error
+	Delete(name string) error
+}
+
+// stores the netclient can use, the file store is the default
+var secretStores = []SecretStore{
+	&fileStore{dir: ncutils.GetNetclientPathSpecific()},
+	&encryptedStore{dir: ncutils.GetNetclientPathSpecific(), machineKey: getMachineKey},
+	&keyringStore{},
+}
+
+// GetSecretStore - gets a store by name, a blank name is the file store
+func GetSecretStore(name string) (SecretStore, error) {
+	if name == "" {
+		name = SECRET_STORE_FILE
+	}
+	for _, store := range secretStores {
+		if store.Name() == name {
+			if !store.Available() {
+				return nil, errors.New("secret store " + name + " is not available on this host")
+			}
+			return store, nil
+		}
+	}
+	return nil, errors.New("unknown secret store " + name)
+}
+
+// GetNodeSecretStore - gets a store by name that can hold the password and private key of a node, which the node
+// needs after a reboot as its config is still there, so stores that lose their secrets on reboot are refused
+func GetNodeSecretStore(name string) (SecretStore, error) {
+	store, err := GetSecretStore(name)
+	if err != nil {
+		return nil, err
+	}
+	if !store.Persistent() {
+		return nil, errors.New("secret store " + store.Name() + " loses its secrets on reboot, so it can not hold the password and private key of a node")
+	}
+	return store, nil
+}
+
+// PasswordName - name of the secret holding the password of the node in a network
+func PasswordName(network string) string {
+	return "secret-" + network
+}
+
+// PrivateKeyName - name of the secret holding the wireguard private key of the node in a network
+func PrivateKeyName(network string) string {
+	return "wgkey-" + network
+}
+
+// NetworkSecrets - names of the secrets the node keeps for a network
+func NetworkSecrets(network string) []string {
+	return []string{PasswordName(network), PrivateKeyName(network)}
+}
+
+// Migrate - moves secrets from one store to another, switchover is called once every secret
+// is in the new store and the secrets are only deleted from the old store when it succeeds
+func Migrate(from SecretStore, to SecretStore, names []string, switchover func() error) error {
+	if from.Name() == to.Name() {
+		return nil
+	}
+	var moved []string
+	for _, name := range names {
+		secret, err := from.Retrieve(name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err = to.Store(name, secret); err != nil {
+			return err
+		}
+		moved = append(moved, name)
+	}
+	if err := switchover(); err != nil {
+		return err
+	}
+	for _, name := range moved {
+		if err := from.Delete(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testStores(t *testing.T) (*fileStore, *encryptedStore, func()) {
+	dir, err := ioutil.TempDir("", "netclient-secrets")
+	assert.Nil(t, err)
+	key := bytes.Repeat([]byte{7}, 32)
+	encrypted := &encryptedStore{dir: dir, machineKey: func() ([]byte, error) { return key, nil }}
+	return &fileStore{dir: dir}, encrypted, func() { os.RemoveAll(dir) }
+}
+
+func TestSecretStores(t *testing.T) {
+	file, encrypted, cleanup := testStores(t)
+	defer cleanup()
+	for _, store := range []SecretStore{file, encrypted} {
+		t.Run(store.Name(), func(t *testing.T) {
+			_, err := store.Retrieve(PasswordName("skynet"))
+			assert.True(t, errors.Is(err, ErrNotFound))
+			assert.Nil(t, store.Store(PasswordName("skynet"), "password"))
+			assert.Nil(t, store.Store(PasswordName("skynet"), "newpassword"))
+			secret, err := store.Retrieve(PasswordName("skynet"))
+			assert.Nil(t, err)
+			assert.Equal(t, "newpassword", secret)
+			assert.Nil(t, store.Delete(PasswordName("skynet")))
+			assert.Nil(t, store.Delete(PasswordName("skynet")))
+			_, err = store.Retrieve(PasswordName("skynet"))
+			assert.True(t, errors.Is(err, ErrNotFound))
+		})
+	}
+}
+
+func TestGetNodeSecretStore(t *testing.T) {
+	for _, name := range []string{"", SECRET_STORE_FILE, SECRET_STORE_ENCRYPTED} {
+		store, err := GetNodeSecretStore(name)
+		assert.Nil(t, err)
+		assert.True(t, store.Persistent())
+	}
+	// lost on reboot, or not there at all off linux
+	_, err := GetNodeSecretStore(SECRET_STORE_KEYRING)
+	assert.NotNil(t, err)
+	_, err = GetNodeSecretStore("missing")
+	assert.NotNil(t, err)
+}
+
+func TestEncryptedStore(t *testing.T) {
+	_, encrypted, cleanup := testStores(t)
+	defer cleanup()
+	assert.Nil(t, encrypted.Store(PrivateKeyName("skynet"), "privatekey"))
+	data, err := ioutil.ReadFile(filepath.Join(encrypted.dir, PrivateKeyName("skynet")+ENCRYPTED_SECRET_SUFFIX))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "privatekey")
+
+	t.Run("OtherMachine", func(t *testing.T) {
+		other := &encryptedStore{dir: encrypted.dir, machineKey: func() ([]byte, error) { return bytes.Repeat([]byte{8}, 32), nil }}
+		_, err := other.Retrieve(PrivateKeyName("skynet"))
+		assert.NotNil(t, err)
+	})
+	t.Run("Renamed", func(t *testing.T) {
+		assert.Nil(t, os.Rename(encrypted.path(PrivateKeyName("skynet")), encrypted.path(PasswordName("skynet"))))
+		_, err := encrypted.Retrieve(PasswordName("skynet"))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	file, encrypted, cleanup := testStores(t)
+	defer cleanup()
+	assert.Nil(t, file.Store(PasswordName("skynet"), "password"))
+	assert.Nil(t, file.Store(PrivateKeyName("skynet"), "privatekey"))
+
+	t.Run("SwitchoverFails", func(t *testing.T) {
+		err := Migrate(file, encrypted, NetworkSecrets("skynet"), func() error { return errors.New("disk full") })
+		assert.EqualError(t, err, "disk full")
+		secret, err := file.Retrieve(PasswordName("skynet"))
+		assert.Nil(t, err)
+		assert.Equal(t, "password", secret)
+	})
+	t.Run("Moved", func(t *testing.T) {
+		switched := false
+		err := Migrate(file, encrypted, append(NetworkSecrets("skynet"), PasswordName("other")), func() error {
+			switched = true
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.True(t, switched)
+		for name, want := range map[string]string{PasswordName("skynet"): "password", PrivateKeyName("skynet"): "privatekey"} {
+			secret, err := encrypted.Retrieve(name)
+			assert.Nil(t, err)
+			assert.Equal(t, want, secret)
+			_, err = file.Retrieve(name)
+			assert.True(t, errors.Is(err, ErrNotFound))
+		}
+	})
+}
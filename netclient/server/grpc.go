@@ -24,13 +24,12 @@ const RELAY_KEEPALIVE_MARKER = "20007ms"
 func getGrpcClient(cfg *config.ClientConfig) (nodepb.NodeServiceClient, error) {
 	var wcclient nodepb.NodeServiceClient
 	// == GRPC SETUP ==
-	conn, err := grpc.Dial(cfg.Server.GRPCAddress,
-		ncutils.GRPCRequestOpts(cfg.Server.GRPCSSL))
+	conn, err := ncutils.DialGRPC(cfg.Server.GRPCAddress, cfg.Server.GRPCSSL)
 
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
+	defer ncutils.ReleaseGRPC(conn)
 	wcclient = nodepb.NewNodeServiceClient(conn)
 	return wcclient, nil
 }
@@ -73,7 +72,7 @@ func CheckIn(network string) (*models.Node, error) {
 }
 
 // GetPeers - gets the peers for a node
-func GetPeers(macaddress string, network string, server string, dualstack bool, isIngressGateway bool, isServer bool) ([]wgtypes.PeerConfig, bool, []string, error) {
+func GetPeers(nodeid string, network string, server string, dualstack bool, isIngressGateway bool, isServer bool) ([]wgtypes.PeerConfig, bool, []string, error) {
 	hasGateway := false
 	var err error
 	var gateways []string
@@ -88,18 +87,17 @@ func GetPeers(macaddress string, network string, server string, dualstack bool,
 		}
 		nodecfg = cfg.Node
 		var wcclient nodepb.NodeServiceClient
-		conn, err := grpc.Dial(cfg.Server.GRPCAddress,
-			ncutils.GRPCRequestOpts(cfg.Server.GRPCSSL))
+		conn, err := ncutils.DialGRPC(cfg.Server.GRPCAddress, cfg.Server.GRPCSSL)
 
 		if err != nil {
 			log.Fatalf("Unable to establish client connection to localhost:50051: %v", err)
 		}
-		defer conn.Close()
+		defer ncutils.ReleaseGRPC(conn)
 		// Instantiate the BlogServiceClient with our client connection to the server
 		wcclient = nodepb.NewNodeServiceClient(conn)
 
 		req := &nodepb.Object{
-			Data: macaddress + "###" + network,
+			Data: nodeid + "###" + network,
 			Type: nodepb.STRING_TYPE,
 		}
 
@@ -235,7 +233,7 @@ func GetPeers(macaddress string, network string, server string, dualstack bool,
 		peers = append(peers, peer)
 	}
 	if isIngressGateway {
-		extPeers, err := GetExtPeers(macaddress, network, server, dualstack)
+		extPeers, err := GetExtPeers(nodeid, network, server, dualstack)
 		if err == nil {
 			peers = append(peers, extPeers...)
 		} else {
@@ -246,7 +244,7 @@ func GetPeers(macaddress string, network string, server string, dualstack bool,
 }
 
 // GetExtPeers - gets the extpeers for a client
-func GetExtPeers(macaddress string, network string, server string, dualstack bool) ([]wgtypes.PeerConfig, error) {
+func GetExtPeers(nodeid string, network string, server string, dualstack bool) ([]wgtypes.PeerConfig, error) {
 	var peers []wgtypes.PeerConfig
 	var nodecfg models.Node
 	var extPeers []models.Node
@@ -262,17 +260,16 @@ func GetExtPeers(macaddress string, network string, server string, dualstack boo
 		nodecfg = cfg.Node
 		var wcclient nodepb.NodeServiceClient
 
-		conn, err := grpc.Dial(cfg.Server.GRPCAddress,
-			ncutils.GRPCRequestOpts(cfg.Server.GRPCSSL))
+		conn, err := ncutils.DialGRPC(cfg.Server.GRPCAddress, cfg.Server.GRPCSSL)
 		if err != nil {
 			log.Fatalf("Unable to establish client connection to localhost:50051: %v", err)
 		}
-		defer conn.Close()
+		defer ncutils.ReleaseGRPC(conn)
 		// Instantiate the BlogServiceClient with our client connection to the server
 		wcclient = nodepb.NewNodeServiceClient(conn)
 
 		req := &nodepb.Object{
-			Data: macaddress + "###" + network,
+			Data: nodeid + "###" + network,
 			Type: nodepb.STRING_TYPE,
 		}
 
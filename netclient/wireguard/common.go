@@ -3,10 +3,8 @@ package wireguard
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
-	"os/exec"
 	"runtime"
 	"strconv"
 	"strings"
@@ -14,6 +12,7 @@ import (
 
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/gravitl/netmaker/netclient/link"
 	"github.com/gravitl/netmaker/netclient/local"
 	"github.com/gravitl/netmaker/netclient/ncutils"
 	"github.com/gravitl/netmaker/netclient/server"
@@ -23,85 +22,74 @@ import (
 
 // SetPeers - sets peers on a given WireGuard interface
 func SetPeers(iface string, keepalive int32, peers []wgtypes.PeerConfig) error {
-
-	var devicePeers []wgtypes.Peer
-	var err error
+	if keepalive == 0 {
+		keepalive = 15
+	}
+	// wgctrl can not configure the freebsd kernel module, so the wg tool sets the peers there
 	if ncutils.IsFreeBSD() {
-		if devicePeers, err = ncutils.GetPeers(iface); err != nil {
-			return err
-		}
-	} else {
-		client, err := wgctrl.New()
+		devicePeers, err := ncutils.GetPeers(iface)
 		if err != nil {
-			ncutils.PrintLog("failed to start wgctrl", 0)
 			return err
 		}
-		device, err := client.Device(iface)
-		if err != nil {
-			ncutils.PrintLog("failed to parse interface", 0)
-			return err
+		if len(devicePeers) > 1 && len(peers) == 0 {
+			ncutils.PrintLog("no peers pulled", 1)
+			return nil
 		}
-		devicePeers = device.Peers
+		return setPeersWithWG(iface, link.PeerUpdates(devicePeers, peers, time.Duration(keepalive)*time.Second))
 	}
-	if len(devicePeers) > 1 && len(peers) == 0 {
+	client, err := wgctrl.New()
+	if err != nil {
+		ncutils.PrintLog("failed to start wgctrl", 0)
+		return err
+	}
+	defer client.Close()
+	device, err := client.Device(iface)
+	if err != nil {
+		ncutils.PrintLog("failed to parse interface", 0)
+		return err
+	}
+	if len(device.Peers) > 1 && len(peers) == 0 {
 		ncutils.PrintLog("no peers pulled", 1)
 		return err
 	}
-	for _, peer := range peers {
-
-		for _, currentPeer := range devicePeers {
-			if currentPeer.AllowedIPs[0].String() == peer.AllowedIPs[0].String() &&
-				currentPeer.PublicKey.String() != peer.PublicKey.String() {
-				_, err := ncutils.RunCmd("wg set "+iface+" peer "+currentPeer.PublicKey.String()+" remove", true)
-				if err != nil {
-					log.Println("error removing peer", peer.Endpoint.String())
-				}
-			}
-		}
-		udpendpoint := peer.Endpoint.String()
-		var allowedips string
-		var iparr []string
-		for _, ipaddr := range peer.AllowedIPs {
-			iparr = append(iparr, ipaddr.String())
-		}
-		allowedips = strings.Join(iparr, ",")
-		keepAliveString := strconv.Itoa(int(keepalive))
-		if keepAliveString == "0" {
-			keepAliveString = "15"
-		}
-		if peer.Endpoint != nil {
-			_, err = ncutils.RunCmd("wg set "+iface+" peer "+peer.PublicKey.String()+
-				" endpoint "+udpendpoint+
-				" persistent-keepalive "+keepAliveString+
-				" allowed-ips "+allowedips, true)
-		} else {
-			_, err = ncutils.RunCmd("wg set "+iface+" peer "+peer.PublicKey.String()+
-				" persistent-keepalive "+keepAliveString+
-				" allowed-ips "+allowedips, true)
-		}
-		if err != nil {
-			log.Println("error setting peer", peer.PublicKey.String())
-		}
+	updates := link.PeerUpdates(device.Peers, peers, time.Duration(keepalive)*time.Second)
+	if err = client.ConfigureDevice(iface, wgtypes.Config{Peers: updates}); err != nil {
+		ncutils.PrintLog("error setting peers of "+iface+": "+err.Error(), 1)
+		return err
 	}
+	return nil
+}
 
-	for _, currentPeer := range devicePeers {
-		shouldDelete := true
-		for _, peer := range peers {
-			if peer.AllowedIPs[0].String() == currentPeer.AllowedIPs[0].String() {
-				shouldDelete = false
-			}
-		}
-		if shouldDelete {
-			output, err := ncutils.RunCmd("wg set "+iface+" peer "+currentPeer.PublicKey.String()+" remove", true)
-			if err != nil {
-				log.Println(output, "error removing peer", currentPeer.PublicKey.String())
-			}
+// setPeersWithWG - applies peer updates to an interface with wg set, one peer at a time
+func setPeersWithWG(iface string, updates []wgtypes.PeerConfig) error {
+	for _, peer := range updates {
+		if output, err := ncutils.RunCmd(wgSetCommand(iface, peer), true); err != nil {
+			ncutils.PrintLog("error setting peer "+peer.PublicKey.String()+" of "+iface+": "+output, 1)
+			return err
 		}
 	}
-
 	return nil
 }
 
+// wgSetCommand - the wg set command applying a peer update
+func wgSetCommand(iface string, peer wgtypes.PeerConfig) string {
+	command := "wg set " + iface + " peer " + peer.PublicKey.String()
+	if peer.Remove {
+		return command + " remove"
+	}
+	if peer.Endpoint != nil {
+		command += " endpoint " + peer.Endpoint.String()
+	}
+	if peer.PersistentKeepaliveInterval != nil {
+		command += " persistent-keepalive " + strconv.Itoa(int(peer.PersistentKeepaliveInterval.Seconds()))
+	}
+	var allowedips []string
+	for _, ipaddr := range peer.AllowedIPs {
+		allowedips = append(allowedips, ipaddr.String())
+	}
+	return command + " allowed-ips " + strings.Join(allowedips, ",")
+}
+
 // Initializes a WireGuard interface
 func InitWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig, hasGateway bool, gateways []string, syncconf bool) error {
 
@@ -150,7 +138,13 @@ func InitWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig
 	}
 
 	if ncutils.IsKernel() {
-		setKernelDevice(ifacename, node.Address)
+		addresses := []string{link.AddressWithMask(node.Address, nodecfg.NetworkSettings.AddressRange, 24)}
+		if node.Address6 != "" && node.IsDualStack == "yes" {
+			addresses = append(addresses, link.AddressWithMask(node.Address6, nodecfg.NetworkSettings.AddressRange6, 64))
+		}
+		if err = setKernelDevice(ifacename, addresses); err != nil {
+			return err
+		}
 	}
 
 	nodeport := int(node.ListenPort)
@@ -182,14 +176,14 @@ func InitWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig
 		}
 		confPath := ncutils.GetNetclientPathSpecific() + ifacename + ".conf"
 		ncutils.PrintLog("writing wg conf file to: "+confPath, 1)
-		err = ioutil.WriteFile(confPath, []byte(newConf), 0644)
+		err = ncutils.WriteFileAtomic(confPath, []byte(newConf), 0600)
 		if err != nil {
 			ncutils.PrintLog("error writing wg conf file to "+confPath+": "+err.Error(), 1)
 			return err
 		}
 		if ncutils.IsWindows() {
 			wgConfPath := ncutils.GetWGPathSpecific() + ifacename + ".conf"
-			err = ioutil.WriteFile(wgConfPath, []byte(newConf), 0644)
+			err = ncutils.WriteFileAtomic(wgConfPath, []byte(newConf), 0600)
 			if err != nil {
 				ncutils.PrintLog("error writing wg conf file to "+wgConfPath+": "+err.Error(), 1)
 				return err
@@ -239,11 +233,6 @@ func InitWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig
 			}
 		}
 	} else {
-		ipExec, err := exec.LookPath("ip")
-		if err != nil {
-			return err
-		}
-
 		_, err = wgclient.Device(ifacename)
 		if err != nil {
 			if os.IsNotExist(err) {
@@ -266,10 +255,14 @@ func InitWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig
 
 		//=========DNS Setup==========\\
 		if nodecfg.DNSOn == "yes" {
-			_ = local.UpdateDNS(ifacename, network, nameserver)
+			networksettings := modcfg.NetworkSettings
+			if networksettings.NetID == "" {
+				networksettings.NetID = network
+			}
+			_ = local.UpdateDNS(ifacename, modcfg.DNSConfigurator, networksettings, nameserver)
 		}
 		//=========End DNS Setup=======\\
-		if _, err := ncutils.RunCmd(ipExec+" link set down dev "+ifacename, false); err != nil {
+		if err := link.Down(ifacename); err != nil {
 			ncutils.Log("attempted to remove interface before editing")
 			return err
 		}
@@ -279,8 +272,8 @@ func InitWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig
 			_ = ncutils.RunCmds(runcmds, true)
 		}
 		// set MTU of node interface
-		if _, err := ncutils.RunCmd(ipExec+" link set mtu "+strconv.Itoa(int(nodecfg.MTU))+" up dev "+ifacename, true); err != nil {
-			ncutils.Log("failed to create interface with mtu " + ifacename)
+		if err := link.Up(ifacename, int(nodecfg.MTU)); err != nil {
+			ncutils.Log("failed to create interface with mtu " + ifacename + ": " + err.Error())
 			return err
 		}
 
@@ -288,15 +281,6 @@ func InitWireguard(node *models.Node, privkey string, peers []wgtypes.PeerConfig
 			runcmds := strings.Split(nodecfg.PostUp, "; ")
 			_ = ncutils.RunCmds(runcmds, true)
 		}
-		if hasGateway {
-			for _, gateway := range gateways {
-				_, _ = ncutils.RunCmd(ipExec+" -4 route add "+gateway+" dev "+ifacename, true)
-			}
-		}
-		if node.Address6 != "" && node.IsDualStack == "yes" {
-			log.Println("[netclient] adding address: "+node.Address6, 1)
-			_, _ = ncutils.RunCmd(ipExec+" address add dev "+ifacename+" "+node.Address6+"/64", true)
-		}
 	}
 
 	//extra network route setting required for freebsd and windows
@@ -317,7 +301,7 @@ func SetWGConfig(network string, peerupdate bool) error {
 	servercfg := cfg.Server
 	nodecfg := cfg.Node
 
-	peers, hasGateway, gateways, err := server.GetPeers(nodecfg.MacAddress, nodecfg.Network, servercfg.GRPCAddress, nodecfg.IsDualStack == "yes", nodecfg.IsIngressGateway == "yes", nodecfg.IsServer == "yes")
+	peers, hasGateway, gateways, err := server.GetPeers(nodecfg.GetNodeID(), nodecfg.Network, servercfg.GRPCAddress, nodecfg.IsDualStack == "yes", nodecfg.IsIngressGateway == "yes", nodecfg.IsServer == "yes")
 	if err != nil {
 		return err
 	}
@@ -340,7 +324,14 @@ func SetWGConfig(network string, peerupdate bool) error {
 	} else {
 		err = InitWireguard(&nodecfg, privkey, peers, hasGateway, gateways, false)
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	if !hasGateway {
+		gateways = nil
+	}
+	syncRoutes(network, nodecfg.Interface, gateways)
+	return nil
 }
 
 // RemoveConf - removes a configuration for a given WireGuard interface
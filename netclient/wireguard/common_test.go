@@ -0,0 +1,31 @@
+package wireguard
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func TestWGSetCommand(t *testing.T) {
+	key, err := wgtypes.GeneratePrivateKey()
+	assert.Nil(t, err)
+	keepalive := 20 * time.Second
+	_, allowed, _ := net.ParseCIDR("10.10.10.2/32")
+	_, gateway, _ := net.ParseCIDR("192.168.0.0/24")
+	peer := wgtypes.PeerConfig{
+		PublicKey:                   key.PublicKey(),
+		Endpoint:                    &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 51821},
+		PersistentKeepaliveInterval: &keepalive,
+		AllowedIPs:                  []net.IPNet{*allowed, *gateway},
+	}
+	assert.Equal(t, "wg set nm-skynet peer "+key.PublicKey().String()+" endpoint 1.2.3.4:51821 persistent-keepalive 20 allowed-ips 10.10.10.2/32,192.168.0.0/24",
+		wgSetCommand("nm-skynet", peer))
+	peer.Endpoint = nil
+	assert.Equal(t, "wg set nm-skynet peer "+key.PublicKey().String()+" persistent-keepalive 20 allowed-ips 10.10.10.2/32,192.168.0.0/24",
+		wgSetCommand("nm-skynet", peer))
+	assert.Equal(t, "wg set nm-skynet peer "+key.PublicKey().String()+" remove",
+		wgSetCommand("nm-skynet", wgtypes.PeerConfig{PublicKey: key.PublicKey(), Remove: true}))
+}
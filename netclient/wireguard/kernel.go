@@ -1,21 +1,13 @@
 package wireguard
 
 import (
-	"os/exec"
-
-	"github.com/gravitl/netmaker/netclient/ncutils"
-	//homedir "github.com/mitchellh/go-homedir"
+	"github.com/gravitl/netmaker/netclient/link"
 )
 
-func setKernelDevice(ifacename string, address string) error {
-	ipExec, err := exec.LookPath("ip")
-	if err != nil {
+// setKernelDevice - creates the wireguard link of a node unless it exists and sets its addresses
+func setKernelDevice(ifacename string, addresses []string) error {
+	if err := link.EnsureWireGuard(ifacename); err != nil {
 		return err
 	}
-
-	_, _ = ncutils.RunCmd("ip link delete dev "+ifacename, false)
-	_, _ = ncutils.RunCmd(ipExec+" link add dev "+ifacename+" type wireguard", true)
-	_, _ = ncutils.RunCmd(ipExec+" address add dev "+ifacename+" "+address+"/24", true)
-
-	return nil
+	return link.SetAddresses(ifacename, addresses)
 }
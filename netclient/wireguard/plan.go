@@ -0,0 +1,320 @@
+package wireguard
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/gravitl/netmaker/netclient/local"
+	"github.com/gravitl/netmaker/netclient/ncutils"
+	"github.com/gravitl/netmaker/netclient/routes"
+	"github.com/gravitl/netmaker/netclient/server"
+	"golang.zx2c4.com/wireguard/wgctrl"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// InterfaceState - the configuration of a wireguard interface, as read from the system or as a node wants it
+type InterfaceState struct {
+	Name       string
+	Exists     bool
+	Addresses  []string
+	MTU        int
+	ListenPort int
+	Peers      []PeerState
+	// Routes - destinations the netclient routes through the interface, only compared when RoutesKnown
+	Routes      []string
+	RoutesKnown bool
+}
+
+// PeerState - a peer of a wireguard interface
+type PeerState struct {
+	PublicKey  string
+	Endpoint   string
+	AllowedIPs []string
+	Keepalive  time.Duration
+}
+
+// PeerChange - the settings that change on a peer that stays
+type PeerChange struct {
+	PublicKey string
+	Changes   []string
+}
+
+// Plan - the changes setting the wireguard config of a node would make, computed without touching the system
+type Plan struct {
+	Interface     string
+	Changes       []string
+	PeersAdded    []PeerState
+	PeersRemoved  []PeerState
+	PeersChanged  []PeerChange
+	RoutesAdded   []string
+	RoutesRemoved []string
+	// Commands - the postdown and postup commands that would run
+	Commands []string
+}
+
+// Plan.Empty - checks if the plan changes nothing
+func (plan *Plan) Empty() bool {
+	return len(plan.Changes) == 0 && len(plan.PeersAdded) == 0 && len(plan.PeersRemoved) == 0 &&
+		len(plan.PeersChanged) == 0 && len(plan.RoutesAdded) == 0 && len(plan.RoutesRemoved) == 0 &&
+		len(plan.Commands) == 0
+}
+
+// Plan.String - describes the plan, a line per change
+func (plan *Plan) String() string {
+	lines := []string{"interface " + plan.Interface}
+	if plan.Empty() {
+		lines = append(lines, "  no changes")
+	}
+	for _, change := range plan.Changes {
+		lines = append(lines, "  "+change)
+	}
+	for _, peer := range plan.PeersAdded {
+		lines = append(lines, "  + peer "+peer.describe())
+	}
+	for _, peer := range plan.PeersRemoved {
+		lines = append(lines, "  - peer "+peer.describe())
+	}
+	for _, peer := range plan.PeersChanged {
+		lines = append(lines, "  ~ peer "+peer.PublicKey+": "+strings.Join(peer.Changes, ", "))
+	}
+	for _, route := range plan.RoutesAdded {
+		lines = append(lines, "  + route "+route)
+	}
+	for _, route := range plan.RoutesRemoved {
+		lines = append(lines, "  - route "+route)
+	}
+	for _, command := range plan.Commands {
+		lines = append(lines, "  run "+command)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (peer *PeerState) describe() string {
+	description := peer.PublicKey + " allowedips " + strings.Join(peer.AllowedIPs, ",")
+	if peer.Endpoint != "" {
+		description += " endpoint " + peer.Endpoint
+	}
+	return description
+}
+
+// PlanWGConfig - computes what SetWGConfig would change on the system to apply a node and the peers the server has for it,
+// the previous config is the one on disk before the node is written to it
+func PlanWGConfig(node *models.Node, previous *config.ClientConfig, peerupdate bool) (*Plan, error) {
+	peers, hasGateway, gateways, err := server.GetPeers(node.GetNodeID(), node.Network, previous.Server.GRPCAddress, node.IsDualStack == "yes", node.IsIngressGateway == "yes", node.IsServer == "yes")
+	if err != nil {
+		return nil, err
+	}
+	if !hasGateway {
+		gateways = nil
+	}
+	desired := NodeInterfaceState(node, peers, gateways)
+	ifacename := node.Interface
+	if ncutils.IsMac() {
+		if ifacename, err = local.GetMacIface(node.Address); err != nil || ifacename == "" {
+			ifacename = node.Interface
+		}
+	}
+	current := ReadInterfaceState(ifacename)
+	plan := ComputePlan(current, desired)
+	if peerupdate && !ncutils.IsFreeBSD() {
+		// only the peers are set on a peer update
+		return &Plan{Interface: plan.Interface, PeersAdded: plan.PeersAdded, PeersRemoved: plan.PeersRemoved, PeersChanged: plan.PeersChanged}, nil
+	}
+	if previous.Node.Interface != "" && previous.Node.Interface != node.Interface {
+		plan.Changes = append([]string{"delete interface " + previous.Node.Interface}, plan.Changes...)
+	}
+	if ncutils.IsKernel() {
+		for _, commands := range []string{node.PostDown, node.PostUp} {
+			if commands != "" {
+				plan.Commands = append(plan.Commands, strings.Split(commands, "; ")...)
+			}
+		}
+	}
+	return &plan, nil
+}
+
+// NodeInterfaceState - the interface a node wants, with its peers and the gateway ranges routed through it
+func NodeInterfaceState(node *models.Node, peers []wgtypes.PeerConfig, gateways []string) InterfaceState {
+	state := InterfaceState{
+		Name:        node.Interface,
+		Exists:      true,
+		MTU:         int(node.MTU),
+		ListenPort:  int(node.ListenPort),
+		RoutesKnown: true,
+	}
+	// the kernel lists routes by their network
+	for _, gateway := range gateways {
+		if _, ipnet, err := net.ParseCIDR(gateway); err == nil {
+			gateway = ipnet.String()
+		}
+		state.Routes = append(state.Routes, gateway)
+	}
+	if node.Address != "" {
+		state.Addresses = append(state.Addresses, node.Address)
+	}
+	if node.Address6 != "" && node.IsDualStack == "yes" {
+		state.Addresses = append(state.Addresses, node.Address6)
+	}
+	// with udp hole punching the port is left to wireguard
+	if node.UDPHolePunch == "yes" && node.IsServer == "no" && node.IsIngressGateway != "yes" && node.IsStatic != "yes" {
+		state.ListenPort = 0
+	}
+	for _, peer := range peers {
+		peerState := PeerState{PublicKey: peer.PublicKey.String()}
+		if peer.Endpoint != nil {
+			peerState.Endpoint = peer.Endpoint.String()
+		}
+		for _, allowedIP := range peer.AllowedIPs {
+			peerState.AllowedIPs = append(peerState.AllowedIPs, allowedIP.String())
+		}
+		if peer.PersistentKeepaliveInterval != nil {
+			peerState.Keepalive = *peer.PersistentKeepaliveInterval
+		}
+		state.Peers = append(state.Peers, peerState)
+	}
+	return state
+}
+
+// ReadInterfaceState - reads the configuration of a wireguard interface from the system, a missing interface does not exist
+func ReadInterfaceState(ifacename string) InterfaceState {
+	state := InterfaceState{Name: ifacename}
+	iface, err := net.InterfaceByName(ifacename)
+	if err != nil {
+		return state
+	}
+	state.Exists = true
+	state.MTU = iface.MTU
+	if addrs, err := iface.Addrs(); err == nil {
+		for _, addr := range addrs {
+			state.Addresses = append(state.Addresses, addr.String())
+		}
+	}
+	var devicePeers []wgtypes.Peer
+	if ncutils.IsFreeBSD() {
+		devicePeers, _ = ncutils.GetPeers(ifacename)
+	} else if wgclient, err := wgctrl.New(); err == nil {
+		if device, err := wgclient.Device(ifacename); err == nil {
+			devicePeers = device.Peers
+			state.ListenPort = device.ListenPort
+		}
+		wgclient.Close()
+	}
+	for _, peer := range devicePeers {
+		peerState := PeerState{PublicKey: peer.PublicKey.String(), Keepalive: peer.PersistentKeepaliveInterval}
+		if peer.Endpoint != nil {
+			peerState.Endpoint = peer.Endpoint.String()
+		}
+		for _, allowedIP := range peer.AllowedIPs {
+			peerState.AllowedIPs = append(peerState.AllowedIPs, allowedIP.String())
+		}
+		state.Peers = append(state.Peers, peerState)
+	}
+	if current, err := routes.Current(ifacename); err == nil {
+		state.Routes = current
+		state.RoutesKnown = true
+	}
+	return state
+}
+
+// ComputePlan - compares the current state of an interface with the state wanted for it
+func ComputePlan(current InterfaceState, desired InterfaceState) Plan {
+	plan := Plan{Interface: desired.Name}
+	if !current.Exists {
+		plan.Changes = append(plan.Changes, "create interface "+desired.Name)
+	}
+	added, removed := diffStrings(addressIPs(current.Addresses), addressIPs(desired.Addresses))
+	for _, address := range added {
+		plan.Changes = append(plan.Changes, "add address "+address)
+	}
+	for _, address := range removed {
+		plan.Changes = append(plan.Changes, "remove address "+address)
+	}
+	if desired.MTU != 0 && current.MTU != desired.MTU {
+		plan.Changes = append(plan.Changes, "mtu: "+strconv.Itoa(current.MTU)+" -> "+strconv.Itoa(desired.MTU))
+	}
+	if desired.ListenPort != 0 && current.ListenPort != desired.ListenPort {
+		plan.Changes = append(plan.Changes, "listen port: "+strconv.Itoa(current.ListenPort)+" -> "+strconv.Itoa(desired.ListenPort))
+	}
+
+	currentPeers := make(map[string]PeerState)
+	for _, peer := range current.Peers {
+		currentPeers[peer.PublicKey] = peer
+	}
+	desiredPeers := make(map[string]bool)
+	for _, peer := range desired.Peers {
+		desiredPeers[peer.PublicKey] = true
+		existing, ok := currentPeers[peer.PublicKey]
+		if !ok {
+			plan.PeersAdded = append(plan.PeersAdded, peer)
+			continue
+		}
+		var changes []string
+		if added, removed := diffStrings(existing.AllowedIPs, peer.AllowedIPs); len(added) > 0 || len(removed) > 0 {
+			changes = append(changes, "allowedips "+strings.Join(existing.AllowedIPs, ",")+" -> "+strings.Join(peer.AllowedIPs, ","))
+		}
+		if peer.Endpoint != "" && existing.Endpoint != peer.Endpoint {
+			changes = append(changes, "endpoint "+existing.Endpoint+" -> "+peer.Endpoint)
+		}
+		if peer.Keepalive != 0 && existing.Keepalive != peer.Keepalive {
+			changes = append(changes, "keepalive "+existing.Keepalive.String()+" -> "+peer.Keepalive.String())
+		}
+		if len(changes) > 0 {
+			plan.PeersChanged = append(plan.PeersChanged, PeerChange{PublicKey: peer.PublicKey, Changes: changes})
+		}
+	}
+	for _, peer := range current.Peers {
+		if !desiredPeers[peer.PublicKey] {
+			plan.PeersRemoved = append(plan.PeersRemoved, peer)
+		}
+	}
+
+	if current.RoutesKnown {
+		plan.RoutesAdded, plan.RoutesRemoved = diffStrings(current.Routes, desired.Routes)
+	}
+	return plan
+}
+
+// diffStrings - the sorted values only in wanted, and the sorted values only in current
+func diffStrings(current []string, wanted []string) ([]string, []string) {
+	currentSet := make(map[string]bool)
+	for _, value := range current {
+		currentSet[value] = true
+	}
+	wantedSet := make(map[string]bool)
+	var added, removed []string
+	for _, value := range wanted {
+		wantedSet[value] = true
+		if !currentSet[value] {
+			added = append(added, value)
+		}
+	}
+	for _, value := range current {
+		if !wantedSet[value] {
+			removed = append(removed, value)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// addressIPs - the ips of addresses with or without a mask, link local addresses are left out as the os sets them
+func addressIPs(addresses []string) []string {
+	var ips []string
+	for _, address := range addresses {
+		ip := net.ParseIP(address)
+		if ipNet, _, err := net.ParseCIDR(address); err == nil {
+			ip = ipNet
+		}
+		if ip == nil || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		ips = append(ips, ip.String())
+	}
+	return ips
+}
@@ -0,0 +1,97 @@
+package wireguard
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func TestComputePlan(t *testing.T) {
+	current := InterfaceState{
+		Name:       "nm-skynet",
+		Exists:     true,
+		Addresses:  []string{"10.10.10.5/24", "fe80::1/64"},
+		MTU:        1280,
+		ListenPort: 51821,
+		Peers: []PeerState{
+			{PublicKey: "stays", Endpoint: "1.1.1.1:51821", AllowedIPs: []string{"10.10.10.1/32"}, Keepalive: 20 * time.Second},
+			{PublicKey: "changes", Endpoint: "2.2.2.2:51821", AllowedIPs: []string{"10.10.10.2/32"}},
+			{PublicKey: "goes", AllowedIPs: []string{"10.10.10.3/32"}},
+		},
+		Routes:      []string{"10.20.0.0/16", "10.30.0.0/16"},
+		RoutesKnown: true,
+	}
+	desired := InterfaceState{
+		Name:       "nm-skynet",
+		Exists:     true,
+		Addresses:  []string{"10.10.10.5", "fd00::5"},
+		MTU:        1380,
+		ListenPort: 51821,
+		Peers: []PeerState{
+			{PublicKey: "stays", Endpoint: "1.1.1.1:51821", AllowedIPs: []string{"10.10.10.1/32"}},
+			{PublicKey: "changes", Endpoint: "3.3.3.3:51821", AllowedIPs: []string{"10.10.10.2/32", "10.20.0.0/16"}},
+			{PublicKey: "comes", AllowedIPs: []string{"10.10.10.4/32"}},
+		},
+		Routes:      []string{"10.20.0.0/16", "10.40.0.0/16"},
+		RoutesKnown: true,
+	}
+
+	t.Run("Changes", func(t *testing.T) {
+		plan := ComputePlan(current, desired)
+		assert.Equal(t, []string{"add address fd00::5", "mtu: 1280 -> 1380"}, plan.Changes)
+		assert.Len(t, plan.PeersAdded, 1)
+		assert.Equal(t, "comes", plan.PeersAdded[0].PublicKey)
+		assert.Len(t, plan.PeersRemoved, 1)
+		assert.Equal(t, "goes", plan.PeersRemoved[0].PublicKey)
+		assert.Equal(t, []PeerChange{{PublicKey: "changes", Changes: []string{
+			"allowedips 10.10.10.2/32 -> 10.10.10.2/32,10.20.0.0/16",
+			"endpoint 2.2.2.2:51821 -> 3.3.3.3:51821",
+		}}}, plan.PeersChanged)
+		assert.Equal(t, []string{"10.40.0.0/16"}, plan.RoutesAdded)
+		assert.Equal(t, []string{"10.30.0.0/16"}, plan.RoutesRemoved)
+		assert.False(t, plan.Empty())
+	})
+	t.Run("NoChanges", func(t *testing.T) {
+		plan := ComputePlan(current, current)
+		assert.True(t, plan.Empty())
+		assert.Equal(t, "interface nm-skynet\n  no changes", plan.String())
+	})
+	t.Run("NewInterface", func(t *testing.T) {
+		plan := ComputePlan(InterfaceState{Name: "nm-skynet"}, desired)
+		assert.Equal(t, "create interface nm-skynet", plan.Changes[0])
+		assert.Len(t, plan.PeersAdded, 3)
+		// routes of a missing interface are not known, so none are listed
+		assert.Empty(t, plan.RoutesAdded)
+	})
+}
+
+func TestNodeInterfaceState(t *testing.T) {
+	key, err := wgtypes.GeneratePrivateKey()
+	assert.Nil(t, err)
+	_, allowed, _ := net.ParseCIDR("10.10.10.1/32")
+	keepalive := 20 * time.Second
+	peers := []wgtypes.PeerConfig{{
+		PublicKey:                   key.PublicKey(),
+		Endpoint:                    &net.UDPAddr{IP: net.ParseIP("1.1.1.1"), Port: 51821},
+		AllowedIPs:                  []net.IPNet{*allowed},
+		PersistentKeepaliveInterval: &keepalive,
+	}}
+	node := &models.Node{Interface: "nm-skynet", Address: "10.10.10.5", Address6: "fd00::5", MTU: 1280,
+		ListenPort: 51821, UDPHolePunch: "yes", IsServer: "no"}
+	state := NodeInterfaceState(node, peers, []string{"10.20.0.0/16"})
+	assert.Equal(t, []string{"10.10.10.5"}, state.Addresses)
+	assert.Equal(t, 0, state.ListenPort)
+	assert.Equal(t, []string{"10.20.0.0/16"}, state.Routes)
+	assert.Equal(t, []PeerState{{PublicKey: key.PublicKey().String(), Endpoint: "1.1.1.1:51821",
+		AllowedIPs: []string{"10.10.10.1/32"}, Keepalive: keepalive}}, state.Peers)
+
+	node.IsDualStack = "yes"
+	node.UDPHolePunch = "no"
+	state = NodeInterfaceState(node, nil, nil)
+	assert.Equal(t, []string{"10.10.10.5", "fd00::5"}, state.Addresses)
+	assert.Equal(t, 51821, state.ListenPort)
+}
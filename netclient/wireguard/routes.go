@@ -0,0 +1,43 @@
+package wireguard
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gravitl/netmaker/netclient/config"
+	"github.com/gravitl/netmaker/netclient/ncutils"
+	"github.com/gravitl/netmaker/netclient/routes"
+)
+
+// syncRoutes - routes the gateway ranges of the peers through the interface,
+// the routes that failed are kept on the node in the config and reported to the server on the next push
+func syncRoutes(network string, iface string, gateways []string) {
+	result, err := routes.Sync(iface, gateways)
+	if errors.Is(err, routes.ErrNotSupported) {
+		return
+	}
+	routeErrors := append([]string{}, result.Errors...)
+	if err != nil {
+		routeErrors = append(routeErrors, "could not read routes of "+iface+": "+err.Error())
+	}
+	for _, route := range result.Added {
+		ncutils.PrintLog("added route "+route+" to "+iface, 1)
+	}
+	for _, route := range result.Removed {
+		ncutils.PrintLog("removed route "+route+" from "+iface, 1)
+	}
+	for _, routeError := range routeErrors {
+		ncutils.PrintLog(routeError, 1)
+	}
+	cfg, err := config.ReadConfig(network)
+	if err != nil {
+		return
+	}
+	if cfg.Node.RouteErrors != nil && strings.Join(cfg.Node.RouteErrors, "\n") == strings.Join(routeErrors, "\n") {
+		return
+	}
+	cfg.Node.RouteErrors = routeErrors
+	if err = config.Write(cfg, network); err != nil {
+		ncutils.PrintLog("could not save route errors of "+network+": "+err.Error(), 1)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/netclient/config"
 	"github.com/gravitl/netmaker/netclient/ncutils"
+	"github.com/gravitl/netmaker/netclient/secrets"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 )
 
@@ -67,7 +68,7 @@ func SyncWGQuickConf(iface string, confPath string) error {
 	}
 	regex := regexp.MustCompile(".*Warning.*\n")
 	conf := regex.ReplaceAllString(confRaw, "")
-	err = ioutil.WriteFile(tmpConf, []byte(conf), 0644)
+	err = ioutil.WriteFile(tmpConf, []byte(conf), 0600)
 	if err != nil {
 		return err
 	}
@@ -90,16 +91,20 @@ func RemoveWGQuickConf(confPath string, printlog bool) error {
 	return err
 }
 
-// StorePrivKey - stores wg priv key on disk locally
+// StorePrivKey - stores wg priv key in the secret store of the network
 func StorePrivKey(key string, network string) error {
-	var err error
-	d1 := []byte(key)
-	err = ioutil.WriteFile(ncutils.GetNetclientPathSpecific()+"wgkey-"+network, d1, 0644)
-	return err
+	store, err := config.GetSecretStore(network)
+	if err != nil {
+		return err
+	}
+	return store.Store(secrets.PrivateKeyName(network), key)
 }
 
-// RetrievePrivKey - reads wg priv key from local disk
+// RetrievePrivKey - reads wg priv key from the secret store of the network
 func RetrievePrivKey(network string) (string, error) {
-	dat, err := ioutil.ReadFile(ncutils.GetNetclientPathSpecific() + "wgkey-" + network)
-	return string(dat), err
+	store, err := config.GetSecretStore(network)
+	if err != nil {
+		return "", err
+	}
+	return store.Retrieve(secrets.PrivateKeyName(network))
 }
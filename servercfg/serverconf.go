@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -38,6 +39,7 @@ func GetServerConfig() config.ServerConfig {
 	cfg.RestBackend = "off"
 	cfg.Verbosity = GetVerbose()
 	cfg.NodeID = GetNodeID()
+	cfg.ServerID = GetServerID()
 	cfg.CheckinInterval = GetCheckinInterval()
 	cfg.ServerCheckinInterval = GetServerCheckinInterval()
 	if IsRestBackend() {
@@ -55,6 +57,12 @@ func GetServerConfig() config.ServerConfig {
 	if IsDNSMode() {
 		cfg.DNSMode = "on"
 	}
+	cfg.EmbeddedDNS = "off"
+	if IsEmbeddedDNS() {
+		cfg.EmbeddedDNS = "on"
+	}
+	cfg.DNSListenAddr = GetDNSListenAddr()
+	cfg.DNSUpstreams = strings.Join(GetDNSUpstreams(), ",")
 	cfg.DisplayKeys = "off"
 	if IsDisplayKeys() {
 		cfg.DisplayKeys = "on"
@@ -71,6 +79,20 @@ func GetServerConfig() config.ServerConfig {
 	if DisableDefaultNet() {
 		cfg.DisableRemoteIPCheck = "on"
 	}
+	cfg.KeyRotationInterval = GetKeyRotationInterval()
+	cfg.BackupMode = "off"
+	if IsBackupMode() {
+		cfg.BackupMode = "on"
+	}
+	cfg.BackupDir = GetBackupDir()
+	cfg.BackupInterval = GetBackupInterval()
+	cfg.BackupRetention = GetBackupRetention()
+	cfg.CacheMode = "off"
+	if IsCacheMode() {
+		cfg.CacheMode = "on"
+	}
+	cfg.CacheSize = GetCacheSize()
+	cfg.CacheTTL = GetCacheTTL()
 	cfg.Database = GetDB()
 	cfg.Platform = GetPlatform()
 	cfg.Version = GetVersion()
@@ -127,6 +149,12 @@ func GetDB() string {
 	return database
 }
 
+// IsSharedDB - checks if the database is a server that several netmaker instances can share, rather than a local file
+func IsSharedDB() bool {
+	database := GetDB()
+	return database == "postgres" || database == "rqlite"
+}
+
 // GetAPIHost - gets the api host
 func GetAPIHost() string {
 	serverhost := "127.0.0.1"
@@ -339,6 +367,57 @@ func IsDNSMode() bool {
 	return isdns
 }
 
+// IsEmbeddedDNS - should the server answer dns queries itself instead of writing CoreDNS files
+func IsEmbeddedDNS() bool {
+	isembedded := false
+	if os.Getenv("EMBEDDED_DNS") != "" {
+		if os.Getenv("EMBEDDED_DNS") == "on" {
+			isembedded = true
+		}
+	} else if config.Config.Server.EmbeddedDNS != "" {
+		if config.Config.Server.EmbeddedDNS == "on" {
+			isembedded = true
+		}
+	}
+	return isembedded
+}
+
+// GetDNSListenAddr - gets the address the embedded dns server listens on
+func GetDNSListenAddr() string {
+	addr := ":53"
+	if os.Getenv("DNS_LISTEN_ADDR") != "" {
+		addr = os.Getenv("DNS_LISTEN_ADDR")
+	} else if config.Config.Server.DNSListenAddr != "" {
+		addr = config.Config.Server.DNSListenAddr
+	}
+	return addr
+}
+
+// GetDNSUpstreams - gets the resolvers the embedded dns server forwards non-network queries to, "off" disables forwarding
+func GetDNSUpstreams() []string {
+	upstreams := "8.8.8.8,8.8.4.4"
+	if os.Getenv("DNS_UPSTREAMS") != "" {
+		upstreams = os.Getenv("DNS_UPSTREAMS")
+	} else if config.Config.Server.DNSUpstreams != "" {
+		upstreams = config.Config.Server.DNSUpstreams
+	}
+	var servers []string
+	if upstreams == "off" {
+		return servers
+	}
+	for _, upstream := range strings.Split(upstreams, ",") {
+		upstream = strings.TrimSpace(upstream)
+		if upstream == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(upstream); err != nil {
+			upstream = net.JoinHostPort(upstream, "53")
+		}
+		servers = append(servers, upstream)
+	}
+	return servers
+}
+
 // IsDisplayKeys - should server be able to display keys?
 func IsDisplayKeys() bool {
 	isdisplay := true
@@ -484,6 +563,21 @@ func GetNodeID() string {
 	return id
 }
 
+// GetServerID - gets the id of this server instance, unique among the instances sharing a database,
+// defaults to the hostname, which is unique for containers
+func GetServerID() string {
+	id, err := os.Hostname()
+	if err != nil || id == "" {
+		id = GetNodeID()
+	}
+	if os.Getenv("SERVER_ID") != "" {
+		id = os.Getenv("SERVER_ID")
+	} else if config.Config.Server.ServerID != "" {
+		id = config.Config.Server.ServerID
+	}
+	return id
+}
+
 // GetServerCheckinInterval - gets the server check-in time
 func GetServerCheckinInterval() int64 {
 	var t = int64(5)
@@ -496,6 +590,107 @@ func GetServerCheckinInterval() int64 {
 	return t
 }
 
+// GetKeyRotationInterval - gets the seconds between runs of the key rotation policies of the networks
+func GetKeyRotationInterval() int64 {
+	var t = int64(3600)
+	var envt, _ = strconv.Atoi(os.Getenv("KEY_ROTATION_INTERVAL"))
+	if envt > 0 {
+		t = int64(envt)
+	} else if config.Config.Server.KeyRotationInterval > 0 {
+		t = config.Config.Server.KeyRotationInterval
+	}
+	return t
+}
+
+// IsBackupMode - checks if the server takes scheduled backups of the database
+func IsBackupMode() bool {
+	isbackup := true
+	if os.Getenv("BACKUP_MODE") != "" {
+		if os.Getenv("BACKUP_MODE") == "off" {
+			isbackup = false
+		}
+	} else if config.Config.Server.BackupMode != "" {
+		if config.Config.Server.BackupMode == "off" {
+			isbackup = false
+		}
+	}
+	return isbackup
+}
+
+// GetBackupDir - gets the directory the backups of the database are kept in
+func GetBackupDir() string {
+	dir := filepath.Join("data", "backups")
+	if os.Getenv("BACKUP_DIR") != "" {
+		dir = os.Getenv("BACKUP_DIR")
+	} else if config.Config.Server.BackupDir != "" {
+		dir = config.Config.Server.BackupDir
+	}
+	return dir
+}
+
+// GetBackupInterval - gets the seconds between scheduled backups of the database
+func GetBackupInterval() int64 {
+	var t = int64(86400)
+	var envt, _ = strconv.Atoi(os.Getenv("BACKUP_INTERVAL"))
+	if envt > 0 {
+		t = int64(envt)
+	} else if config.Config.Server.BackupInterval > 0 {
+		t = config.Config.Server.BackupInterval
+	}
+	return t
+}
+
+// GetBackupRetention - gets how many scheduled backups are kept
+func GetBackupRetention() int32 {
+	var retention = int32(7)
+	var envretention, _ = strconv.Atoi(os.Getenv("BACKUP_RETENTION"))
+	if envretention > 0 {
+		retention = int32(envretention)
+	} else if config.Config.Server.BackupRetention > 0 {
+		retention = config.Config.Server.BackupRetention
+	}
+	return retention
+}
+
+// IsCacheMode - checks if networks, nodes and peer lists are cached in memory
+func IsCacheMode() bool {
+	iscache := true
+	if os.Getenv("CACHE_MODE") != "" {
+		if os.Getenv("CACHE_MODE") == "off" {
+			iscache = false
+		}
+	} else if config.Config.Server.CacheMode != "" {
+		if config.Config.Server.CacheMode == "off" {
+			iscache = false
+		}
+	}
+	return iscache
+}
+
+// GetCacheSize - gets how many entries each cache holds before the least recently used are evicted
+func GetCacheSize() int32 {
+	var size = int32(10000)
+	var envsize, _ = strconv.Atoi(os.Getenv("CACHE_SIZE"))
+	if envsize > 0 {
+		size = int32(envsize)
+	} else if config.Config.Server.CacheSize > 0 {
+		size = config.Config.Server.CacheSize
+	}
+	return size
+}
+
+// GetCacheTTL - gets the seconds a cache entry is used before it is read from the database again
+func GetCacheTTL() int64 {
+	var t = int64(60)
+	var envt, _ = strconv.Atoi(os.Getenv("CACHE_TTL"))
+	if envt > 0 {
+		t = int64(envt)
+	} else if config.Config.Server.CacheTTL > 0 {
+		t = config.Config.Server.CacheTTL
+	}
+	return t
+}
+
 // GetAuthProviderInfo = gets the oauth provider info
 func GetAuthProviderInfo() []string {
 	var authProvider = ""
@@ -138,7 +138,7 @@ func SyncNetworks(servernets []models.Network) error {
 					err = errors.New("network add failed for " + servernet.NetID)
 				}
 				if servercfg.GetVerbose() >= 1 {
-					if !strings.Contains(err.Error(), "macaddress_unique") { // ignore macaddress unique error throws
+					if !strings.Contains(err.Error(), "id_unique") { // ignore node id unique error throws
 						log.Printf("[netmaker] error adding network %s during sync %s \n", servernet.NetID, err)
 					}
 				}